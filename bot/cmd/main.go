@@ -5,22 +5,35 @@ import (
 	"bot/internal/clients/kafka"
 	"bot/internal/clients/scraper"
 	botconfig "bot/internal/config"
+	"bot/internal/health"
+	dlqHandler "bot/internal/http/handlers/dlq"
 	updateHandler "bot/internal/http/handlers/update"
 	"bot/internal/http/middleware/logger"
+	"bot/internal/http/openapi"
 	"bot/internal/metrics"
-	botModel "bot/internal/model/bot"
 	db "bot/internal/storage/redis"
 	bothandlers "bot/internal/tg/handlers"
+	"bot/internal/tg/queue"
+	"bot/internal/tg/webhook"
+	"bot/internal/tracing"
 	botUC "bot/internal/usecase"
+	"bot/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/httprate"
 	"gopkg.in/telebot.v3"
+	"pkg/admin"
+	"pkg/auth"
+	"pkg/breaker"
+	breakersHandler "pkg/breakers"
+	"pkg/ratelimit"
+	"pkg/render"
 	"sync"
 
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -32,14 +45,15 @@ const (
 	envDev     = "dev"
 	envProd    = "prod"
 	KafkaGroup = "1"
+
+	modePolling = "polling"
+	modeWebhook = "webhook"
 )
 
 type App struct {
 	BotServer *botapplication.App
 }
 
-var states = make(map[int64]*botModel.UserState)
-
 func main() {
 	cfg := botconfig.MustLoad()
 	metricManager := metrics.NewMetricManager()
@@ -53,51 +67,121 @@ func main() {
 		return
 	}
 
-	storage := db.New(cfg.Bot.StoragePath, cfg.Bot.MaxIdle, cfg.Bot.MaxActive)
+	reloader := botconfig.NewReloader(log, cfg)
+
+	shutdownTracing, err := tracing.Setup(ctx, &cfg.Tracing)
+	if err != nil {
+		log.Error("Failed to initialize tracing", slog.String("error", err.Error()))
+		return
+	}
+
+	storage := db.New(cfg.Bot.StoragePath, cfg.Bot.MaxIdle, cfg.Bot.MaxActive, metricManager)
+
+	poller, webhookHandler, err := createPoller(log, cfg)
+	if err != nil {
+		log.Error("Failed to create poller", slog.String("error", err.Error()))
+		return
+	}
+
+	trackedPoller := health.NewPoller(poller)
 
-	tgBot, err := createTgBot(cfg)
+	tgBot, err := createTgBot(cfg, trackedPoller, metricManager)
 	if err != nil {
 		log.Error("Failed to create bot")
 		return
 	}
 
-	scraperClient, err := scraperclient.New(log, &cfg.Clients)
+	scraperClient, err := createScraperClient(log, &cfg.Clients)
 	if err != nil {
-		log.Error("Failed to create scraper client")
+		log.Error("Failed to create scraper client", slog.String("error", err.Error()))
+		return
+	}
+
+	sendQueue := queue.New(log, tgBot, cfg.Bot.Sender)
+	sendQueue.Start(ctx)
+
+	renderer, err := render.New(render.Format(cfg.Bot.MessageFormat), cfg.Bot.TemplatesPath, time.Now)
+	if err != nil {
+		log.Error("Failed to create renderer", slog.String("error", err.Error()))
 		return
 	}
 
 	bot := bothandlers.Bot{
 		Handler:       tgBot,
+		Sender:        sendQueue,
+		Renderer:      renderer,
 		Logger:        log,
-		States:        states,
 		MetricManager: metricManager,
 	}
 
-	router := setupRouter(ctx, log, &bot, scraperClient, storage, cfg)
-	errChan := make(chan error, 2)
+	deadLetters, err := kafka.NewDLQProducer([]string{cfg.Clients.Kafka.Address}, cfg.Clients.Kafka.DeadLetterTopic)
+	if err != nil {
+		log.Error("Failed to create DLQ producer", slog.String("error", err.Error()))
+		return
+	}
+	defer deadLetters.Close()
+
+	dlqAdmin, err := kafka.NewDLQAdmin([]string{cfg.Clients.Kafka.Address}, cfg.Clients.Kafka.DeadLetterTopic,
+		cfg.Clients.Kafka.Topic)
+	if err != nil {
+		log.Error("Failed to create DLQ admin", slog.String("error", err.Error()))
+		return
+	}
+	defer dlqAdmin.Close()
+
+	scraperDLQAdmin, err := kafka.NewDLQAdmin([]string{cfg.Clients.Kafka.Address},
+		cfg.Clients.Kafka.ScraperDeadLetterTopic, cfg.Clients.Kafka.Topic)
+	if err != nil {
+		log.Error("Failed to create scraper DLQ admin", slog.String("error", err.Error()))
+		return
+	}
+	defer scraperDLQAdmin.Close()
+
+	consumerStatus := &kafka.GroupStatus{}
+
+	checker := health.New(cfg.Bot.Timeout)
+	checker.Add("redis", storage.Ping)
+	checker.Add("kafka_consumer", consumerStatus.Check)
+	checker.Add("telegram_poller", trackedPoller.Check)
+
+	setupReload(reloader, scraperClient)
+
+	dlqTopics := dlqHandler.Topics{dlqHandler.TopicBot: dlqAdmin, dlqHandler.TopicScraper: scraperDLQAdmin}
+
+	router, err := setupRouter(ctx, log, &bot, scraperClient, storage, cfg, webhookHandler, dlqTopics, checker,
+		setupBreakers(scraperClient), reloader)
+	if err != nil {
+		log.Error("Failed to setup router", slog.String("error", err.Error()))
+		return
+	}
+
+	errChan := make(chan error, 1)
+
+	retryPolicy := kafka.RetryPolicy{Attempts: cfg.Clients.Kafka.Retry, Backoff: cfg.Clients.Kafka.Backoff}
 
 	var wg sync.WaitGroup
 
-	wg.Add(2)
+	wg.Add(1)
 
 	go func() {
 		if err = kafka.RunConsumerGroup(ctx, log, []string{cfg.Clients.Kafka.Address}, KafkaGroup,
-			[]string{cfg.Clients.Kafka.Topic}, botUC.New(log, &bot, scraperClient, storage).Update); err != nil {
+			[]string{cfg.Clients.Kafka.Topic}, retryPolicy, deadLetters, botUC.New(log, &bot, scraperClient, storage).Update,
+			consumerStatus); err != nil {
 			errChan <- fmt.Errorf("base consumer error: %w", err)
 		}
 	}()
 
-	go func() {
-		if err = kafka.RunConsumerGroup(ctx, log, []string{cfg.Clients.Kafka.Address}, KafkaGroup,
-			[]string{cfg.Clients.Kafka.DLQTopic}, botUC.New(log, &bot, scraperClient, storage).ProcessFail); err != nil {
-			errChan <- fmt.Errorf("base consumer error: %w", err)
-		}
-	}()
+	// Over gRPC the scraper pushes updates on a stream instead of calling
+	// the bot's /updates endpoint.
+	if stream, ok := scraperClient.(*scraperclient.GRPCClient); ok {
+		defer stream.Close()
+
+		go stream.StreamUpdates(ctx, "bot", botUC.New(log, &bot, scraperClient, storage).Update)
+	}
 
 	select {
 	case err = <-errChan:
-		log.Error("Kafka consumer failed to start", err)
+		log.Error("Kafka consumer failed to start", slog.String("error", err.Error()))
 		cancel()
 		return
 	case <-time.After(2 * time.Second):
@@ -110,6 +194,7 @@ func main() {
 	}
 
 	go app.BotServer.MustRun()
+	go reloader.Run(ctx)
 
 	// Graceful shutdown
 
@@ -120,13 +205,74 @@ func main() {
 
 	cancel()
 	app.BotServer.Stop()
+
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Error("Failed to flush traces", slog.String("error", err.Error()))
+	}
+
 	log.Info("Gracefully stopped")
 }
 
-func createTgBot(cfg *botconfig.Config) (*telebot.Bot, error) {
+func createPoller(log *slog.Logger, cfg *botconfig.Config) (telebot.Poller, http.Handler, error) {
+	switch cfg.Bot.Mode {
+	case modePolling:
+		return &telebot.LongPoller{Timeout: cfg.Bot.Timeout}, nil, nil
+	case modeWebhook:
+		poller, err := webhook.New(log, cfg.Bot.Webhook)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return poller, poller, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported bot mode: %s", cfg.Bot.Mode)
+	}
+}
+
+func createScraperClient(log *slog.Logger, cfg *botconfig.ClientsConfig) (botUC.ScraperClient, error) {
+	switch cfg.Scrapper.Transport {
+	case scraperclient.TransportHTTP:
+		return scraperclient.New(log, cfg)
+	case scraperclient.TransportGRPC:
+		return scraperclient.NewGRPC(log, cfg)
+	default:
+		return nil, fmt.Errorf("unsupported scraper transport: %s", cfg.Scrapper.Transport)
+	}
+}
+
+// setupBreakers collects the circuit breakers shown and reset under
+// /admin/breakers.
+func setupBreakers(client botUC.ScraperClient) *breaker.Registry {
+	breakers := breaker.NewRegistry()
+
+	if guarded, ok := client.(interface{ Breaker() *breaker.Breaker }); ok {
+		breakers.Add("scraper", guarded.Breaker())
+	}
+
+	return breakers
+}
+
+// setupReload hands the settings a config reload may change to the scraper
+// client. The rate limit is handled by setupRouter.
+func setupReload(reloader *botconfig.Reloader, client botUC.ScraperClient) {
+	configurable, ok := client.(interface {
+		Configure(*botconfig.ClientsConfig)
+	})
+	if !ok {
+		return
+	}
+
+	reloader.Subscribe(func(cfg *botconfig.Config) {
+		configurable.Configure(&cfg.Clients)
+	})
+}
+
+func createTgBot(cfg *botconfig.Config, poller telebot.Poller, metricManager *metrics.MetricManager) (*telebot.Bot,
+	error) {
 	pref := telebot.Settings{
 		Token:  cfg.Bot.Token,
-		Poller: &telebot.LongPoller{Timeout: cfg.Bot.Timeout},
+		Poller: poller,
+		Client: &http.Client{Timeout: time.Minute, Transport: metricManager.TelegramTransport(nil)},
 	}
 
 	tgBot, err := telebot.NewBot(pref)
@@ -138,26 +284,102 @@ func createTgBot(cfg *botconfig.Config) (*telebot.Bot, error) {
 }
 
 func setupRouter(ctx context.Context, log *slog.Logger, bot *bothandlers.Bot,
-	client *scraperclient.Client, storage *db.Storage, cfg *botconfig.Config) *chi.Mux {
+	client botUC.ScraperClient, storage *db.Storage, cfg *botconfig.Config, webhookHandler http.Handler,
+	dlqTopics dlqHandler.Topics, checker *health.Checker, breakers *breaker.Registry,
+	reloader *botconfig.Reloader) (*chi.Mux, error) {
+	doc, err := openapi.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	validate, err := openapi.Validate(log, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimit := ratelimit.NewLimit(cfg.Bot.RateLimit)
+
+	reloader.Subscribe(func(cfg *botconfig.Config) {
+		rateLimit.Set(cfg.Bot.RateLimit)
+	})
+
 	router := chi.NewRouter()
 
 	router.Use(middleware.RequestID)
+	router.Use(tracing.Middleware)
 	router.Use(logger.New(log))
 	router.Use(middleware.Recoverer)
-	router.Use(httprate.LimitByIP(cfg.Bot.RateLimit, 1*time.Minute))
 
-	router.Route("/updates", func(r chi.Router) {
-		r.Post("/", updateHandler.New(log, botUC.New(log, bot, client, storage)))
+	// The contract is checked after authentication, so that an unauthenticated
+	// caller gets a 401 and learns nothing about the schema.
+	router.Group(func(r chi.Router) {
+		r.Use(validate)
+
+		r.Get("/openapi.json", openapi.Handler(doc))
+		r.Get("/healthz", checker.Live())
+		r.Get("/readyz", checker.Ready())
 	})
 
-	bot.Handler.Handle("/start", bot.StartHandler(ctx, botUC.New(log, bot, client, storage)))
-	bot.Handler.Handle("/help", bot.HelpHandler)
-	bot.Handler.Handle("/track", bot.TrackHandler)
-	bot.Handler.Handle(telebot.OnText, bot.StatesHandler(ctx, botUC.New(log, bot, client, storage)))
-	bot.Handler.Handle("/untrack", bot.UntrackHandler(ctx, botUC.New(log, bot, client, storage)))
-	bot.Handler.Handle("/list", bot.ListHandler(ctx, botUC.New(log, bot, client, storage)))
+	// Telegram delivers all updates from a handful of addresses, so the
+	// webhook is kept out of the per-IP rate limit.
+	if webhookHandler != nil {
+		router.Method(http.MethodPost, cfg.Bot.Webhook.Path, webhookHandler)
+	}
+
+	router.Group(func(r chi.Router) {
+		r.Use(rateLimit.Apply(httprate.LimitByIP(cfg.Bot.RateLimit, 1*time.Minute)))
+
+		if keys := cfg.Clients.Scrapper.ServiceKeys; len(keys) > 0 {
+			// Nonces share the bot's Redis, so every replica rejects a request
+			// that one of them accepted.
+			r.Use(auth.Middleware(log, keys, auth.NewRedisNonces(cfg.Bot.StoragePath), utils.RespondWithError))
+		} else {
+			log.Warn("No service keys configured, /updates accepts unsigned requests")
+		}
+
+		r.Use(validate)
+
+		r.Route("/updates", func(r chi.Router) {
+			r.Post("/", updateHandler.New(log, botUC.New(log, bot, client, storage)))
+		})
+	})
+
+	// Admin endpoints are only served when a token is configured.
+	if cfg.Bot.AdminToken != "" {
+		router.Route("/admin", func(r chi.Router) {
+			r.Use(admin.New(cfg.Bot.AdminToken, utils.RespondWithError))
+			r.Use(validate)
+
+			r.Get("/dlq", dlqHandler.List(log, dlqTopics))
+			r.Post("/dlq/replay", dlqHandler.Replay(log, dlqTopics))
+			r.Delete("/dlq", dlqHandler.Purge(log, dlqTopics))
+
+			r.Get("/breakers", breakersHandler.List(breakers))
+			r.Post("/breakers/{name}/reset", breakersHandler.Reset(log, breakers, utils.RespondWithError))
+		})
+	}
 
-	return router
+	adminOnly := bot.AdminOnly(ctx, botUC.New(log, bot, client, storage))
+
+	bot.Handler.Use(bot.Localize(ctx, botUC.New(log, bot, client, storage)))
+
+	bot.Handle("/start", bot.StartHandler(ctx, botUC.New(log, bot, client, storage)), adminOnly)
+	bot.Handle("/help", bot.HelpHandler)
+	bot.Handle("/track", bot.TrackHandler(ctx, botUC.New(log, bot, client, storage)), adminOnly)
+	bot.Handle(telebot.OnText, bot.StatesHandler(ctx, botUC.New(log, bot, client, storage)))
+	bot.Handle("/untrack", bot.UntrackHandler(ctx, botUC.New(log, bot, client, storage)), adminOnly)
+	bot.Handle("/list", bot.ListHandler(ctx, botUC.New(log, bot, client, storage)))
+	bot.Handle("/status", bot.StatusHandler(ctx, botUC.New(log, bot, client, storage)))
+	bot.Handle("/history", bot.HistoryHandler(ctx, botUC.New(log, bot, client, storage)))
+	bot.Handle("/token", bot.TokenHandler(ctx, botUC.New(log, bot, client, storage)))
+	bot.Handle("/revoke", bot.RevokeHandler(ctx, botUC.New(log, bot, client, storage)), adminOnly)
+	bot.Handle("/settings", bot.SettingsHandler(ctx, botUC.New(log, bot, client, storage)))
+	bot.Handle("/lang", bot.LangHandler(ctx, botUC.New(log, bot, client, storage)), adminOnly)
+	bot.Handle(telebot.OnMyChatMember, bot.ChatMemberHandler(ctx, botUC.New(log, bot, client, storage)))
+	bot.Handle(telebot.OnMigration, bot.MigrationHandler(ctx, botUC.New(log, bot, client, storage)))
+	bot.Handle(telebot.OnChannelPost, bot.ChannelPostHandler)
+
+	return router, nil
 }
 
 func setupLogger(env string) *slog.Logger {
@@ -3,52 +3,273 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"sync"
+	"time"
 
 	"bot/internal/model/bot"
+	"bot/internal/tracing"
 	"github.com/Shopify/sarama"
+	"github.com/avast/retry-go/v4"
 )
 
+const (
+	HeaderEventID       = "event-id"
+	HeaderSchemaVersion = "schema-version"
+	HeaderTraceID       = "trace-id"
+)
+
+// The dead letter topic is retried from deadLetterMinDelay, doubling up to
+// deadLetterMaxDelay, for as long as the session lasts.
+const (
+	deadLetterMinDelay = 100 * time.Millisecond
+	deadLetterMaxDelay = time.Minute
+)
+
+type RetryPolicy struct {
+	Attempts uint
+	Backoff  time.Duration
+}
+
+// GroupStatus tracks whether the consumer group holds a session, i.e. has
+// partitions assigned. A nil status tracks nothing.
+type GroupStatus struct {
+	mu      sync.Mutex
+	joined  bool
+	lastErr error
+}
+
+func (s *GroupStatus) set(joined bool, err error) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.joined = joined
+	s.lastErr = err
+}
+
+// Check fails while the group has no session.
+func (s *GroupStatus) Check(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.joined:
+		return nil
+	case s.lastErr != nil:
+		return fmt.Errorf("consumer group failed: %w", s.lastErr)
+	default:
+		return errors.New("consumer group has not joined")
+	}
+}
+
 type linkUpdateHandler struct {
-	process func(upd *bot.LinkUpdate) error
+	log         *slog.Logger
+	policy      RetryPolicy
+	deadLetters DeadLetterPublisher
+	process     func(ctx context.Context, upd *bot.LinkUpdate) error
+	status      *GroupStatus
+}
+
+func (h *linkUpdateHandler) Setup(_ sarama.ConsumerGroupSession) error {
+	h.status.set(true, nil)
+	return nil
 }
 
-func (h *linkUpdateHandler) Setup(_ sarama.ConsumerGroupSession) error   { return nil }
-func (h *linkUpdateHandler) Cleanup(_ sarama.ConsumerGroupSession) error { return nil }
+func (h *linkUpdateHandler) Cleanup(_ sarama.ConsumerGroupSession) error {
+	h.status.set(false, nil)
+	return nil
+}
+
+// ConsumeClaim never moves past a message it could neither process nor park
+// in the dead letter topic: marking a later offset would commit the failed
+// one too. The partition waits while the dead letter topic is retried, and
+// the message is redelivered if the session ends first.
 func (h *linkUpdateHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	for msg := range claim.Messages() {
-		var upd bot.LinkUpdate
+		if err := h.handle(sess.Context(), msg); err != nil {
+			return err
+		}
 
-		if err := json.Unmarshal(msg.Value, &upd); err != nil {
-			sess.MarkMessage(msg, "unmarshal error")
+		sess.MarkMessage(msg, "")
 
-			continue
-		}
+		// The high water mark is the offset of the next message written.
+		consumerLag.WithLabelValues(msg.Topic, strconv.Itoa(int(msg.Partition))).
+			Set(float64(max(claim.HighWaterMarkOffset()-msg.Offset-1, 0)))
+	}
+
+	return nil
+}
+
+// handle returns nil when the message is done with and its offset can be
+// committed.
+func (h *linkUpdateHandler) handle(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	var upd bot.LinkUpdate
+
+	if err := json.Unmarshal(msg.Value, &upd); err != nil {
+		return h.deadLetter(ctx, msg, ReasonUndecodable, err, 0)
+	}
+
+	ctx, span := tracing.StartConsumer(ctx, msg)
+	defer span.End()
+
+	// process skips events it has already handled, whichever transport
+	// brought them; the header wins over the body as older scrapers only
+	// set the former.
+	if eventID := header(msg, HeaderEventID); eventID != "" {
+		upd.EventID = eventID
+	}
+
+	log := h.log.With(
+		slog.String("topic", msg.Topic),
+		slog.Int64("offset", msg.Offset),
+		slog.String("event_id", upd.EventID),
+		slog.String("trace_id", header(msg, HeaderTraceID)),
+	)
+
+	chats := len(upd.TgChatIDs)
 
-		if err := h.process(&upd); err != nil {
-			continue
+	attempts, err := h.processWithRetry(ctx, msg.Topic, &upd)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
 
-		sess.MarkMessage(msg, "")
+		span.RecordError(err)
+		log.Error("giving up on message", slog.Uint64("attempts", uint64(attempts)),
+			slog.String("error", err.Error()))
+
+		return h.deadLetter(ctx, parked(msg, &upd, chats), ReasonProcessing, err, attempts)
 	}
 
 	return nil
 }
 
+// parked returns the message to park for upd, which was sent to chats chats
+// at first. Retries narrow upd down to the chats that failed, and only those
+// must get it again on a replay.
+func parked(msg *sarama.ConsumerMessage, upd *bot.LinkUpdate, chats int) *sarama.ConsumerMessage {
+	if len(upd.TgChatIDs) == chats {
+		return msg
+	}
+
+	value, err := json.Marshal(upd)
+	if err != nil {
+		return msg
+	}
+
+	narrowed := *msg
+	narrowed.Value = value
+
+	return &narrowed
+}
+
+// processWithRetry retries with exponential backoff. When only some chats
+// failed, later attempts are sent to those chats alone.
+func (h *linkUpdateHandler) processWithRetry(ctx context.Context, topic string, upd *bot.LinkUpdate) (uint, error) {
+	attempts := max(h.policy.Attempts, 1)
+
+	var made uint
+
+	err := retry.Do(
+		func() error {
+			made++
+
+			err := h.process(ctx, upd)
+
+			var partial interface{ FailedChats() []int64 }
+			if errors.As(err, &partial) && len(partial.FailedChats()) > 0 {
+				upd.TgChatIDs = partial.FailedChats()
+			}
+
+			return err
+		},
+		retry.Context(ctx),
+		retry.Attempts(attempts),
+		retry.Delay(h.policy.Backoff),
+		retry.DelayType(retry.BackOffDelay),
+		retry.LastErrorOnly(true),
+		retry.OnRetry(func(_ uint, _ error) {
+			consumerRetries.WithLabelValues(topic).Inc()
+		}),
+	)
+
+	return made, err
+}
+
+// deadLetter parks msg, retrying the dead letter topic until ctx is done.
+func (h *linkUpdateHandler) deadLetter(ctx context.Context, msg *sarama.ConsumerMessage, reason string, cause error,
+	attempts uint) error {
+	log := h.log.With(slog.String("topic", msg.Topic), slog.Int("partition", int(msg.Partition)),
+		slog.Int64("offset", msg.Offset), slog.String("reason", reason))
+
+	if h.deadLetters == nil {
+		log.Warn("dropping message without dead letter topic")
+		return nil
+	}
+
+	err := retry.Do(
+		func() error {
+			return h.deadLetters.Publish(msg, reason, cause, attempts)
+		},
+		retry.Context(ctx),
+		retry.Attempts(0),
+		retry.Delay(max(h.policy.Backoff, deadLetterMinDelay)),
+		retry.MaxDelay(deadLetterMaxDelay),
+		retry.DelayType(retry.BackOffDelay),
+		retry.LastErrorOnly(true),
+		retry.OnRetry(func(n uint, err error) {
+			log.Error("cannot park message, partition paused until it is", slog.Uint64("attempt", uint64(n+1)),
+				slog.String("error", err.Error()))
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("dead letter %s/%d/%d: %w", msg.Topic, msg.Partition, msg.Offset, err)
+	}
+
+	return nil
+}
+
+func header(msg *sarama.ConsumerMessage, key string) string {
+	for _, h := range msg.Headers {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+
+	return ""
+}
+
+// RunConsumerGroup consumes topics until ctx is done and keeps status, which
+// may be nil, up to date.
 func RunConsumerGroup(ctx context.Context, log *slog.Logger, brokers []string, groupID string, topics []string,
-	processFn func(upd *bot.LinkUpdate) error,
+	policy RetryPolicy, deadLetters DeadLetterPublisher,
+	processFn func(ctx context.Context, upd *bot.LinkUpdate) error, status *GroupStatus,
 ) error {
 	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V2_1_0_0
 
 	consumerGroup, err := sarama.NewConsumerGroup(brokers, groupID, cfg)
 	if err != nil {
+		status.set(false, err)
 		log.Error("cannot create consumer group")
 		return fmt.Errorf("cannot create consumer group: %w", err)
 	}
 	defer consumerGroup.Close()
 
-	handler := &linkUpdateHandler{process: processFn}
+	handler := &linkUpdateHandler{
+		log:         log,
+		policy:      policy,
+		deadLetters: deadLetters,
+		process:     processFn,
+		status:      status,
+	}
 
 	for {
 		select {
@@ -56,7 +277,13 @@ func RunConsumerGroup(ctx context.Context, log *slog.Logger, brokers []string, g
 			return ctx.Err()
 		default:
 			if err = consumerGroup.Consume(ctx, topics, handler); err != nil {
-				log.Error("consumer error")
+				status.set(false, err)
+				log.Error("consumer error", slog.String("error", err.Error()))
+
+				select {
+				case <-ctx.Done():
+				case <-time.After(policy.Backoff):
+				}
 			}
 		}
 	}
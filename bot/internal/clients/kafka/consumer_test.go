@@ -18,9 +18,10 @@ import (
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
-func TestConsumer_Integration(t *testing.T) {
+func startKafka(t *testing.T) []string {
+	t.Helper()
+
 	ctx := context.Background()
-	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
 	req := testcontainers.ContainerRequest{
 		Image:        "bitnami/kafka:latest",
 		ExposedPorts: []string{"9092/tcp", "9093/tcp"},
@@ -44,6 +45,8 @@ func TestConsumer_Integration(t *testing.T) {
 	})
 	require.NoError(t, err)
 
+	t.Cleanup(func() { _ = kafkaC.Terminate(context.Background()) })
+
 	host, err := kafkaC.Host(ctx)
 
 	require.NoError(t, err)
@@ -52,8 +55,13 @@ func TestConsumer_Integration(t *testing.T) {
 
 	require.NoError(t, err)
 
-	brokerAddr := fmt.Sprintf("%s:%s", host, mappedPort.Port())
-	brokers := []string{brokerAddr}
+	return []string{fmt.Sprintf("%s:%s", host, mappedPort.Port())}
+}
+
+func TestConsumer_Integration(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	brokers := startKafka(t)
 
 	prodCfg := sarama.NewConfig()
 
@@ -77,20 +85,22 @@ func TestConsumer_Integration(t *testing.T) {
 			brokers,
 			"test-group",
 			[]string{topic},
-			func(upd *bot.LinkUpdate) error {
+			kafka.RetryPolicy{Attempts: 1},
+			nil,
+			func(_ context.Context, upd *bot.LinkUpdate) error {
 				gotCh <- upd
 				return nil
 			},
+			nil,
 		)
 	}()
 
 	time.Sleep(2 * time.Second)
 
 	want := &bot.LinkUpdate{
-		ID:          7,
-		URL:         "https://go.dev",
-		Description: "golang",
-		TgChatIDs:   []int64{42, 43},
+		ID:        7,
+		URL:       "https://go.dev",
+		TgChatIDs: []int64{42, 43},
 	}
 	data, _ := json.Marshal(want)
 
@@ -0,0 +1,105 @@
+package kafka
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+const (
+	HeaderDLQError     = "dlq-error"
+	HeaderDLQReason    = "dlq-reason"
+	HeaderDLQTopic     = "dlq-original-topic"
+	HeaderDLQPartition = "dlq-original-partition"
+	HeaderDLQOffset    = "dlq-original-offset"
+	HeaderDLQAttempts  = "dlq-attempts"
+	HeaderDLQFailedAt  = "dlq-failed-at"
+
+	ReasonUndecodable = "undecodable"
+	ReasonProcessing  = "processing_failed"
+)
+
+type DeadLetterPublisher interface {
+	Publish(msg *sarama.ConsumerMessage, reason string, cause error, attempts uint) error
+}
+
+// DLQProducer forwards messages the bot gave up on to its own dead letter
+// topic, keeping the original key, value and headers and describing the
+// failure in dlq-* headers.
+type DLQProducer struct {
+	producer sarama.SyncProducer
+	topic    string
+}
+
+func NewDLQProducer(brokers []string, topic string) (*DLQProducer, error) {
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V2_1_0_0
+	cfg.Producer.Idempotent = true
+	cfg.Producer.RequiredAcks = sarama.WaitForAll
+	cfg.Producer.Return.Successes = true
+	cfg.Net.MaxOpenRequests = 1
+
+	producer, err := sarama.NewSyncProducer(brokers, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create Kafka DLQ producer: %w", err)
+	}
+
+	return &DLQProducer{producer: producer, topic: topic}, nil
+}
+
+func (p *DLQProducer) Publish(msg *sarama.ConsumerMessage, reason string, cause error, attempts uint) error {
+	out := &sarama.ProducerMessage{
+		Topic: p.topic,
+		Value: sarama.ByteEncoder(msg.Value),
+	}
+
+	if msg.Key != nil {
+		out.Key = sarama.ByteEncoder(msg.Key)
+	}
+
+	for _, h := range msg.Headers {
+		if h != nil {
+			out.Headers = append(out.Headers, *h)
+		}
+	}
+
+	errText := ""
+	if cause != nil {
+		errText = cause.Error()
+	}
+
+	out.Headers = append(out.Headers,
+		recordHeader(HeaderDLQError, errText),
+		recordHeader(HeaderDLQReason, reason),
+		recordHeader(HeaderDLQTopic, msg.Topic),
+		recordHeader(HeaderDLQPartition, strconv.FormatInt(int64(msg.Partition), 10)),
+		recordHeader(HeaderDLQOffset, strconv.FormatInt(msg.Offset, 10)),
+		recordHeader(HeaderDLQAttempts, strconv.FormatUint(uint64(attempts), 10)),
+		recordHeader(HeaderDLQFailedAt, time.Now().UTC().Format(time.RFC3339)),
+	)
+
+	_, _, err := p.producer.SendMessage(out)
+
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+
+	deadLetters.WithLabelValues(msg.Topic, reason, result).Inc()
+
+	if err != nil {
+		return fmt.Errorf("publish to %s: %w", p.topic, err)
+	}
+
+	return nil
+}
+
+func (p *DLQProducer) Close() error {
+	return p.producer.Close()
+}
+
+func recordHeader(key, value string) sarama.RecordHeader {
+	return sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+}
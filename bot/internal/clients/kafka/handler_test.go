@@ -0,0 +1,245 @@
+package kafka
+
+import (
+	"bot/internal/model/bot"
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+)
+
+// fakeDeadLetters fails the first failures publishes.
+type fakeDeadLetters struct {
+	failures  int
+	published []string
+	attempts  []uint
+	values    []string
+}
+
+func (f *fakeDeadLetters) Publish(msg *sarama.ConsumerMessage, reason string, _ error, attempts uint) error {
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("kafka is down")
+	}
+
+	f.published = append(f.published, reason)
+	f.attempts = append(f.attempts, attempts)
+	f.values = append(f.values, string(msg.Value))
+
+	return nil
+}
+
+type partialError struct {
+	chats []int64
+}
+
+func (e *partialError) Error() string        { return "partial delivery" }
+func (e *partialError) FailedChats() []int64 { return e.chats }
+
+func message(eventID string) *sarama.ConsumerMessage {
+	return &sarama.ConsumerMessage{
+		Topic: "updates",
+		Value: []byte(`{"id":1,"url":"https://github.com/a/b","tgChatIds":[1,2,3]}`),
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte(HeaderEventID), Value: []byte(eventID)},
+		},
+	}
+}
+
+func newHandler(process func(ctx context.Context, upd *bot.LinkUpdate) error) (*linkUpdateHandler, *fakeDeadLetters) {
+	dlq := &fakeDeadLetters{}
+
+	return &linkUpdateHandler{
+		log:         slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		policy:      RetryPolicy{Attempts: 3},
+		deadLetters: dlq,
+		process:     process,
+	}, dlq
+}
+
+func TestLinkUpdateHandler_PassesEventID(t *testing.T) {
+	var ids []string
+
+	h, _ := newHandler(func(_ context.Context, upd *bot.LinkUpdate) error {
+		ids = append(ids, upd.EventID)
+		return nil
+	})
+
+	require.NoError(t, h.handle(context.Background(), message("header-id")))
+	require.NoError(t, h.handle(context.Background(),
+		&sarama.ConsumerMessage{Value: []byte(`{"id":1,"eventId":"body-id","tgChatIds":[1]}`)}))
+
+	require.Equal(t, []string{"header-id", "body-id"}, ids)
+}
+
+func TestLinkUpdateHandler_RetriesOnlyFailedChats(t *testing.T) {
+	var calls [][]int64
+
+	h, dlq := newHandler(func(_ context.Context, upd *bot.LinkUpdate) error {
+		calls = append(calls, upd.TgChatIDs)
+		if len(calls) == 1 {
+			return &partialError{chats: []int64{2}}
+		}
+
+		return nil
+	})
+
+	require.NoError(t, h.handle(context.Background(), message("e1")))
+
+	require.Equal(t, [][]int64{{1, 2, 3}, {2}}, calls)
+	require.Empty(t, dlq.published)
+}
+
+func TestLinkUpdateHandler_DeadLettersAfterRetries(t *testing.T) {
+	calls := 0
+	h, dlq := newHandler(func(_ context.Context, _ *bot.LinkUpdate) error {
+		calls++
+		return errors.New("telegram is down")
+	})
+
+	require.NoError(t, h.handle(context.Background(), message("e1")))
+
+	require.Equal(t, 3, calls)
+	require.Equal(t, []string{ReasonProcessing}, dlq.published)
+	require.Equal(t, []uint{3}, dlq.attempts)
+}
+
+func TestLinkUpdateHandler_DeadLettersUndecodable(t *testing.T) {
+	h, dlq := newHandler(func(_ context.Context, _ *bot.LinkUpdate) error {
+		t.Fatal("undecodable message must not be processed")
+		return nil
+	})
+
+	require.NoError(t, h.handle(context.Background(), &sarama.ConsumerMessage{Value: []byte("{")}))
+	require.Equal(t, []string{ReasonUndecodable}, dlq.published)
+}
+
+func TestLinkUpdateHandler_DeadLettersFailedChatsOnly(t *testing.T) {
+	h, dlq := newHandler(func(_ context.Context, _ *bot.LinkUpdate) error {
+		return &partialError{chats: []int64{2}}
+	})
+
+	require.NoError(t, h.handle(context.Background(), message("e1")))
+
+	require.Len(t, dlq.values, 1)
+
+	var parked bot.LinkUpdate
+
+	require.NoError(t, json.Unmarshal([]byte(dlq.values[0]), &parked))
+	require.Equal(t, []int64{2}, parked.TgChatIDs, "a replay must not notify the chats that got the update")
+	require.Equal(t, "e1", parked.EventID)
+}
+
+func TestLinkUpdateHandler_RetriesDLQ(t *testing.T) {
+	h, dlq := newHandler(func(_ context.Context, _ *bot.LinkUpdate) error {
+		return errors.New("telegram is down")
+	})
+	dlq.failures = 2
+
+	require.NoError(t, h.handle(context.Background(), message("e1")))
+	require.Equal(t, []string{ReasonProcessing}, dlq.published)
+}
+
+func TestLinkUpdateHandler_KeepsOffsetWhenDLQFails(t *testing.T) {
+	h, dlq := newHandler(func(_ context.Context, _ *bot.LinkUpdate) error {
+		return errors.New("telegram is down")
+	})
+	dlq.failures = math.MaxInt
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	require.Error(t, h.handle(ctx, message("e1")),
+		"message must not be committed when it could not be parked")
+	require.Empty(t, dlq.published)
+}
+
+func TestDLQProducer_Publish(t *testing.T) {
+	cfg := mocks.NewTestConfig()
+	cfg.Producer.Return.Successes = true
+
+	producer := mocks.NewSyncProducer(t, cfg)
+	defer producer.Close()
+
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		headers := make(map[string]string)
+		for _, h := range msg.Headers {
+			headers[string(h.Key)] = string(h.Value)
+		}
+
+		switch {
+		case msg.Topic != "bot-dlq":
+			return errors.New("unexpected topic " + msg.Topic)
+		case headers[HeaderEventID] != "e1":
+			return errors.New("original headers must be kept")
+		case headers[HeaderDLQError] != "boom" || headers[HeaderDLQReason] != ReasonProcessing:
+			return errors.New("missing error headers")
+		case headers[HeaderDLQTopic] != "updates" || headers[HeaderDLQAttempts] != "3":
+			return errors.New("missing origin headers")
+		}
+
+		return nil
+	})
+
+	p := &DLQProducer{producer: producer, topic: "bot-dlq"}
+
+	require.NoError(t, p.Publish(message("e1"), ReasonProcessing, errors.New("boom"), 3))
+}
+
+func TestLinkUpdateHandler_ContinuesTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var got trace.SpanContext
+
+	h, _ := newHandler(func(ctx context.Context, _ *bot.LinkUpdate) error {
+		got = trace.SpanContextFromContext(ctx)
+		return nil
+	})
+
+	msg := message("e1")
+	msg.Headers = append(msg.Headers, &sarama.RecordHeader{
+		Key:   []byte("traceparent"),
+		Value: []byte("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"),
+	})
+
+	require.NoError(t, h.handle(context.Background(), msg))
+	require.Equal(t, "0af7651916cd43dd8448eb211c80319c", got.TraceID().String())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, trace.SpanKindConsumer, spans[0].SpanKind())
+	require.Equal(t, "b7ad6b7169203331", spans[0].Parent().SpanID().String())
+	require.Equal(t, got.SpanID(), spans[0].SpanContext().SpanID())
+}
+
+func TestGroupStatus_Check(t *testing.T) {
+	status := &GroupStatus{}
+	h, _ := newHandler(func(_ context.Context, _ *bot.LinkUpdate) error { return nil })
+	h.status = status
+
+	require.EqualError(t, status.Check(context.Background()), "consumer group has not joined")
+
+	require.NoError(t, h.Setup(nil))
+	require.NoError(t, status.Check(context.Background()))
+
+	require.NoError(t, h.Cleanup(nil))
+	require.Error(t, status.Check(context.Background()))
+
+	status.set(false, sarama.ErrOutOfBrokers)
+	require.ErrorIs(t, status.Check(context.Background()), sarama.ErrOutOfBrokers)
+}
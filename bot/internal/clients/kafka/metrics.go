@@ -0,0 +1,48 @@
+package kafka
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	consumerRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "myapp",
+			Name:      "kafka_consumer_retries_total",
+			Help:      "Retries of Kafka messages that failed to process",
+		},
+		[]string{"topic"},
+	)
+
+	deadLetters = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "myapp",
+			Name:      "kafka_dlq_published_total",
+			Help:      "Kafka messages forwarded to the dead letter topic",
+		},
+		[]string{"topic", "reason", "result"},
+	)
+
+	replayedLetters = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "myapp",
+			Name:      "kafka_dlq_replayed_total",
+			Help:      "Dead letters sent back to their original topic",
+		},
+		[]string{"topic"},
+	)
+
+	consumerLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "myapp",
+			Name:      "kafka_consumer_lag",
+			Help:      "Messages of a partition not yet consumed by the bot",
+		},
+		[]string{"topic", "partition"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(consumerRetries)
+	prometheus.MustRegister(deadLetters)
+	prometheus.MustRegister(replayedLetters)
+	prometheus.MustRegister(consumerLag)
+}
@@ -0,0 +1,275 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"bot/internal/model/bot"
+	"github.com/Shopify/sarama"
+)
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DLQAdmin lets an operator look into the bot's dead letter topic, send
+// selected messages back to the topic they came from and purge the rest.
+type DLQAdmin struct {
+	client   sarama.Client
+	admin    sarama.ClusterAdmin
+	consumer sarama.Consumer
+	producer sarama.SyncProducer
+	topic    string
+	fallback string
+}
+
+// NewDLQAdmin works on topic. Messages without a dlq-original-topic header
+// are replayed to fallback.
+func NewDLQAdmin(brokers []string, topic, fallback string) (*DLQAdmin, error) {
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V2_1_0_0
+	cfg.Producer.RequiredAcks = sarama.WaitForAll
+	cfg.Producer.Return.Successes = true
+
+	client, err := sarama.NewClient(brokers, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create Kafka client: %w", err)
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("cannot create DLQ consumer: %w", err)
+	}
+
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		_ = consumer.Close()
+		_ = client.Close()
+
+		return nil, fmt.Errorf("cannot create replay producer: %w", err)
+	}
+
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		_ = producer.Close()
+		_ = consumer.Close()
+		_ = client.Close()
+
+		return nil, fmt.Errorf("cannot create cluster admin: %w", err)
+	}
+
+	return &DLQAdmin{
+		client:   client,
+		admin:    admin,
+		consumer: consumer,
+		producer: producer,
+		topic:    topic,
+		fallback: fallback,
+	}, nil
+}
+
+// List returns up to limit parked messages, oldest first within a partition.
+func (a *DLQAdmin) List(ctx context.Context, limit int) ([]bot.DeadLetter, error) {
+	partitions, err := a.client.Partitions(a.topic)
+	if err != nil {
+		return nil, fmt.Errorf("list partitions of %s: %w", a.topic, err)
+	}
+
+	letters := make([]bot.DeadLetter, 0)
+
+	for _, partition := range partitions {
+		if len(letters) >= limit {
+			break
+		}
+
+		oldest, newest, err := a.bounds(partition)
+		if err != nil {
+			return nil, err
+		}
+
+		if oldest >= newest {
+			continue
+		}
+
+		err = a.read(ctx, partition, oldest, newest, func(msg *sarama.ConsumerMessage) bool {
+			letters = append(letters, deadLetterOf(msg))
+			return len(letters) < limit
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return letters, nil
+}
+
+// Replay sends the messages at the given positions back to their original
+// topic without the dlq-* headers. Replayed messages stay in the dead letter
+// topic until it is purged.
+func (a *DLQAdmin) Replay(ctx context.Context, positions []bot.DeadLetterPosition) (int, error) {
+	replayed := 0
+
+	for _, pos := range positions {
+		msg, err := a.fetch(ctx, pos)
+		if err != nil {
+			return replayed, err
+		}
+
+		out := &sarama.ProducerMessage{
+			Topic: header(msg, HeaderDLQTopic),
+			Value: sarama.ByteEncoder(msg.Value),
+		}
+
+		if out.Topic == "" {
+			out.Topic = a.fallback
+		}
+
+		if msg.Key != nil {
+			out.Key = sarama.ByteEncoder(msg.Key)
+		}
+
+		for _, h := range msg.Headers {
+			if h != nil && !strings.HasPrefix(string(h.Key), "dlq-") {
+				out.Headers = append(out.Headers, *h)
+			}
+		}
+
+		if _, _, err = a.producer.SendMessage(out); err != nil {
+			return replayed, fmt.Errorf("replay %d/%d to %s: %w", pos.Partition, pos.Offset, out.Topic, err)
+		}
+
+		replayedLetters.WithLabelValues(out.Topic).Inc()
+
+		replayed++
+	}
+
+	return replayed, nil
+}
+
+// Purge deletes the messages of each given partition up to and including
+// the given offset, e.g. the last one an operator listed, and returns the
+// number of deleted messages. Messages parked after that are kept.
+func (a *DLQAdmin) Purge(_ context.Context, through []bot.DeadLetterPosition) (int64, error) {
+	offsets := make(map[int32]int64, len(through))
+
+	for _, pos := range through {
+		offsets[pos.Partition] = max(offsets[pos.Partition], pos.Offset+1)
+	}
+
+	var purged int64
+
+	for partition, end := range offsets {
+		oldest, newest, err := a.bounds(partition)
+		if err != nil {
+			return 0, err
+		}
+
+		end = min(end, newest)
+		if end <= oldest {
+			delete(offsets, partition)
+			continue
+		}
+
+		offsets[partition] = end
+		purged += end - oldest
+	}
+
+	if len(offsets) == 0 {
+		return 0, nil
+	}
+
+	if err := a.admin.DeleteRecords(a.topic, offsets); err != nil {
+		return 0, fmt.Errorf("delete records of %s: %w", a.topic, err)
+	}
+
+	return purged, nil
+}
+
+// Close also closes the underlying client.
+func (a *DLQAdmin) Close() error {
+	_ = a.producer.Close()
+	_ = a.consumer.Close()
+
+	return a.admin.Close()
+}
+
+func (a *DLQAdmin) bounds(partition int32) (int64, int64, error) {
+	oldest, err := a.client.GetOffset(a.topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return 0, 0, fmt.Errorf("oldest offset of %s/%d: %w", a.topic, partition, err)
+	}
+
+	newest, err := a.client.GetOffset(a.topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, 0, fmt.Errorf("newest offset of %s/%d: %w", a.topic, partition, err)
+	}
+
+	return oldest, newest, nil
+}
+
+func (a *DLQAdmin) fetch(ctx context.Context, pos bot.DeadLetterPosition) (*sarama.ConsumerMessage, error) {
+	oldest, newest, err := a.bounds(pos.Partition)
+	if err != nil {
+		return nil, err
+	}
+
+	if pos.Offset < oldest || pos.Offset >= newest {
+		return nil, fmt.Errorf("%w: %d/%d", ErrDeadLetterNotFound, pos.Partition, pos.Offset)
+	}
+
+	var found *sarama.ConsumerMessage
+
+	err = a.read(ctx, pos.Partition, pos.Offset, pos.Offset+1, func(msg *sarama.ConsumerMessage) bool {
+		found = msg
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if found == nil || found.Offset != pos.Offset {
+		return nil, fmt.Errorf("%w: %d/%d", ErrDeadLetterNotFound, pos.Partition, pos.Offset)
+	}
+
+	return found, nil
+}
+
+// read calls fn for the messages in [from, to) until it returns false.
+func (a *DLQAdmin) read(ctx context.Context, partition int32, from, to int64,
+	fn func(msg *sarama.ConsumerMessage) bool) error {
+	pc, err := a.consumer.ConsumePartition(a.topic, partition, from)
+	if err != nil {
+		return fmt.Errorf("consume %s/%d: %w", a.topic, partition, err)
+	}
+	defer pc.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-pc.Errors():
+			return fmt.Errorf("consume %s/%d: %w", a.topic, partition, err)
+		case msg := <-pc.Messages():
+			if msg.Offset >= to || !fn(msg) || msg.Offset >= to-1 {
+				return nil
+			}
+		}
+	}
+}
+
+func deadLetterOf(msg *sarama.ConsumerMessage) bot.DeadLetter {
+	return bot.DeadLetter{
+		Partition:      msg.Partition,
+		Offset:         msg.Offset,
+		Key:            string(msg.Key),
+		EventID:        header(msg, HeaderEventID),
+		Reason:         header(msg, HeaderDLQReason),
+		Error:          header(msg, HeaderDLQError),
+		OriginalTopic:  header(msg, HeaderDLQTopic),
+		OriginalOffset: header(msg, HeaderDLQOffset),
+		Attempts:       header(msg, HeaderDLQAttempts),
+		FailedAt:       header(msg, HeaderDLQFailedAt),
+		Payload:        string(msg.Value),
+	}
+}
@@ -0,0 +1,111 @@
+package kafka_test
+
+import (
+	"bot/internal/clients/kafka"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bot/internal/model/bot"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDLQAdmin_Integration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	brokers := startKafka(t)
+
+	const (
+		dlqTopic     = "bot-dlq-test"
+		updatesTopic = "updates-test"
+	)
+
+	producer, err := kafka.NewDLQProducer(brokers, dlqTopic)
+	require.NoError(t, err)
+
+	defer producer.Close()
+
+	for i, eventID := range []string{"e1", "e2"} {
+		err = producer.Publish(&sarama.ConsumerMessage{
+			Topic:  updatesTopic,
+			Offset: int64(i),
+			Key:    []byte("42"),
+			Value:  []byte(`{"id":1,"url":"https://go.dev","tgChatIds":[42]}`),
+			Headers: []*sarama.RecordHeader{
+				{Key: []byte(kafka.HeaderEventID), Value: []byte(eventID)},
+			},
+		}, kafka.ReasonProcessing, errors.New("telegram is down"), 3)
+		require.NoError(t, err)
+	}
+
+	admin, err := kafka.NewDLQAdmin(brokers, dlqTopic, updatesTopic)
+	require.NoError(t, err)
+
+	defer admin.Close()
+
+	letters, err := admin.List(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, letters, 2)
+	require.Equal(t, "e1", letters[0].EventID)
+	require.Equal(t, kafka.ReasonProcessing, letters[0].Reason)
+	require.Equal(t, "telegram is down", letters[0].Error)
+	require.Equal(t, updatesTopic, letters[0].OriginalTopic)
+	require.Equal(t, "3", letters[0].Attempts)
+
+	limited, err := admin.List(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, limited, 1)
+
+	replayed, err := admin.Replay(ctx, []bot.DeadLetterPosition{{Partition: letters[1].Partition, Offset: letters[1].Offset}})
+	require.NoError(t, err)
+	require.Equal(t, 1, replayed)
+
+	consumer, err := sarama.NewConsumer(brokers, sarama.NewConfig())
+	require.NoError(t, err)
+
+	defer consumer.Close()
+
+	pc, err := consumer.ConsumePartition(updatesTopic, 0, sarama.OffsetOldest)
+	require.NoError(t, err)
+
+	defer pc.Close()
+
+	select {
+	case msg := <-pc.Messages():
+		require.Equal(t, "42", string(msg.Key))
+
+		for _, h := range msg.Headers {
+			require.False(t, strings.HasPrefix(string(h.Key), "dlq-"), "dlq headers must be stripped")
+		}
+
+		require.Len(t, msg.Headers, 1)
+		require.Equal(t, "e2", string(msg.Headers[0].Value))
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the replayed message")
+	}
+
+	_, err = admin.Replay(ctx, []bot.DeadLetterPosition{{Offset: 100}})
+	require.ErrorIs(t, err, kafka.ErrDeadLetterNotFound)
+
+	purged, err := admin.Purge(ctx, []bot.DeadLetterPosition{{Partition: letters[0].Partition, Offset: letters[0].Offset}})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, purged)
+
+	left, err := admin.List(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, left, 1, "letters after the offset must be kept")
+	require.Equal(t, "e2", left[0].EventID)
+
+	purged, err = admin.Purge(ctx, []bot.DeadLetterPosition{{Partition: left[0].Partition, Offset: 100}})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, purged, "an offset past the end purges what there is")
+
+	letters, err = admin.List(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, letters)
+}
@@ -2,107 +2,80 @@ package scraperclient
 
 import (
 	"bot/internal/config"
-	"bytes"
+	"bot/internal/tracing"
 	"context"
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"pkg/auth"
+	"pkg/breaker"
+	"pkg/httpclient"
 	"strconv"
-	"time"
 
 	"bot/internal/model/bot"
-	"github.com/avast/retry-go/v4"
-	"github.com/go-chi/render"
-	"github.com/sony/gobreaker"
 )
 
 type Client struct {
-	addr    string
-	log     *slog.Logger
-	client  *http.Client
-	retries uint
-	backoff time.Duration
-	breaker *gobreaker.CircuitBreaker
+	addr   string
+	log    *slog.Logger
+	client *httpclient.Client
 }
 
 const (
 	registerChat = "/tg-chat/%d"
 	deleteChat   = "/tg-chat/%d"
 	links        = "/links"
+	tokens       = "/tg-chat/%d/tokens"
+	history      = "/history"
 )
 
 func New(log *slog.Logger, cfg *config.ClientsConfig) (*Client, error) {
-	httpClient := &http.Client{
-		Timeout: cfg.Scrapper.Timeout,
-	}
+	transport := tracing.Transport(nil)
 
-	cbSettings := gobreaker.Settings{
-		Name:        "Scrapper API Circuit Breaker",
-		MaxRequests: cfg.CircuitBreaker.SlidingWindowSize,
-		Timeout:     cfg.CircuitBreaker.Timeout,
-		ReadyToTrip: func(counts gobreaker.Counts) bool {
-			return counts.Requests >= cfg.CircuitBreaker.MaxRequests &&
-				counts.TotalFailures >= cfg.CircuitBreaker.FailureCount
-		},
+	if len(cfg.Scrapper.ServiceKeys) > 0 {
+		transport = auth.NewTransport(cfg.Scrapper.ServiceKeys, transport)
 	}
 
+	client := httpclient.New(log, httpclient.Options{
+		Name:        "scraper",
+		BreakerName: "Scrapper API Circuit Breaker",
+		Breaker:     cfg.CircuitBreaker,
+		Timeout:     cfg.Scrapper.Timeout,
+		Retries:     cfg.Scrapper.Retry,
+		Backoff:     cfg.Scrapper.Backoff,
+		Transport:   transport,
+	})
+
 	return &Client{
-		addr:    cfg.Scrapper.Address,
-		log:     log,
-		client:  httpClient,
-		backoff: cfg.Scrapper.Backoff,
-		retries: cfg.Scrapper.Retry,
-		breaker: gobreaker.NewCircuitBreaker(cbSettings),
+		addr:   cfg.Scrapper.Address,
+		log:    log,
+		client: client,
 	}, nil
 }
 
+// Breaker is the circuit breaker guarding the scraper's HTTP API.
+func (c *Client) Breaker() *breaker.Breaker {
+	return c.client.Breaker()
+}
+
+// Configure applies reloaded timeouts, retries and breaker settings.
+func (c *Client) Configure(cfg *config.ClientsConfig) {
+	c.client.Configure(httpclient.Options{
+		Breaker: cfg.CircuitBreaker,
+		Timeout: cfg.Scrapper.Timeout,
+		Retries: cfg.Scrapper.Retry,
+		Backoff: cfg.Scrapper.Backoff,
+	})
+}
+
 func (c *Client) RegisterChat(ctx context.Context, id int64) error {
 	const op = "Client.Scraper.RegisterChat"
 
-	url := fmt.Sprintf(c.addr+registerChat, id)
-
-	_, err := c.breaker.Execute(func() (any, error) {
-		err := retry.Do(
-			func() error {
-				req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, http.NoBody)
-				if err != nil {
-					return retry.Unrecoverable(fmt.Errorf("%s: %w", op, err))
-				}
-
-				req.Header.Set("Content-Type", "application/json")
-
-				c.log.Debug("Sending POST request", slog.String("url", url))
-
-				resp, err := c.client.Do(req)
-				if err != nil {
-					return fmt.Errorf("%s: %w", op, err)
-				}
-				defer resp.Body.Close()
-
-				if resp.StatusCode >= 500 {
-					return fmt.Errorf("%s: temporary server error: %s", op, resp.Status)
-				} else if resp.StatusCode != http.StatusOK {
-					return retry.Unrecoverable(fmt.Errorf("%s: bad status: %s", op, resp.Status))
-				}
-
-				return nil
-			},
-			retry.Attempts(c.retries),
-			retry.Delay(c.backoff),
-			retry.DelayType(retry.BackOffDelay),
-			retry.Context(ctx),
-		)
-
-		if err != nil {
-			return nil, err
-		}
-
-		return nil, nil
-	})
+	req := httpclient.Request{Method: http.MethodPost, URL: fmt.Sprintf(c.addr+registerChat, id)}
 
-	if err != nil {
-		return fmt.Errorf("%s: retries exhausted: %w", op, err)
+	if err := c.client.Do(ctx, req, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
@@ -111,47 +84,10 @@ func (c *Client) RegisterChat(ctx context.Context, id int64) error {
 func (c *Client) DeleteChat(ctx context.Context, id int64) error {
 	const op = "Client.Scraper.DeleteChat"
 
-	url := fmt.Sprintf(c.addr+deleteChat, id)
-
-	_, err := c.breaker.Execute(func() (any, error) {
-		err := retry.Do(
-			func() error {
-				req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, http.NoBody)
-				if err != nil {
-					return retry.Unrecoverable(fmt.Errorf("%s: %w", op, err))
-				}
-
-				c.log.Debug("Sending DELETE request", slog.String("url", url))
-
-				resp, err := c.client.Do(req)
-				if err != nil {
-					return fmt.Errorf("%s: %w", op, err)
-				}
-				defer resp.Body.Close()
-
-				if resp.StatusCode >= 500 {
-					return fmt.Errorf("%s: temporary server error: %s", op, resp.Status)
-				} else if resp.StatusCode != http.StatusOK {
-					return retry.Unrecoverable(fmt.Errorf("%s: bad status: %s", op, resp.Status))
-				}
-
-				return nil
-			},
-			retry.Attempts(c.retries),
-			retry.Delay(c.backoff),
-			retry.DelayType(retry.BackOffDelay),
-			retry.Context(ctx),
-		)
-
-		if err != nil {
-			return nil, err
-		}
-
-		return nil, nil
-	})
+	req := httpclient.Request{Method: http.MethodDelete, URL: fmt.Sprintf(c.addr+deleteChat, id)}
 
-	if err != nil {
-		return fmt.Errorf("%s: retries exhausted: %w", op, err)
+	if err := c.client.Do(ctx, req, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
@@ -160,184 +96,97 @@ func (c *Client) DeleteChat(ctx context.Context, id int64) error {
 func (c *Client) GetLinks(ctx context.Context, id int64) (*bot.ListLinkResponse, error) {
 	const op = "Client.Scraper.GetLinks"
 
-	var result *bot.ListLinkResponse
-
-	_, err := c.breaker.Execute(func() (any, error) {
-		err := retry.Do(
-			func() error {
-				req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.addr+links, http.NoBody)
-				if err != nil {
-					return retry.Unrecoverable(fmt.Errorf("%s: %w", op, err))
-				}
-
-				req.Header.Set("Tg-Chat-Id", strconv.FormatInt(id, 10))
+	req := httpclient.Request{Method: http.MethodGet, URL: c.addr + links, Header: chatHeader(id)}
 
-				c.log.Debug("Sending GET request", slog.String("url", req.URL.String()))
+	var result bot.ListLinkResponse
 
-				resp, err := c.client.Do(req)
-				if err != nil {
-					return fmt.Errorf("%s: %w", op, err)
-				}
-				defer resp.Body.Close()
-
-				if resp.StatusCode >= 500 {
-					return fmt.Errorf("%s: temporary server error: %s", op, resp.Status)
-				} else if resp.StatusCode != http.StatusOK {
-					return retry.Unrecoverable(fmt.Errorf("%s: bad status: %s", op, resp.Status))
-				}
-
-				var linksResp bot.ListLinkResponse
-				if err := render.DecodeJSON(resp.Body, &linksResp); err != nil {
-					return retry.Unrecoverable(fmt.Errorf("failed to deserialize response: %s", op))
-				}
+	if err := c.client.Do(ctx, req, &result); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-				result = &linksResp
+	return &result, nil
+}
 
-				return nil
-			},
-			retry.Attempts(c.retries),
-			retry.Delay(c.backoff),
-			retry.DelayType(retry.BackOffDelay),
-			retry.Context(ctx),
-		)
+func (c *Client) DeleteLink(ctx context.Context, link bot.RemoveLinkRequest, id int64) (*bot.Link, error) {
+	const op = "Client.Scraper.DeleteLink"
 
-		if err != nil {
-			return nil, err
-		}
+	req := httpclient.Request{Method: http.MethodDelete, URL: c.addr + links, Header: chatHeader(id), Body: link}
 
-		return result, nil
-	})
+	var result bot.Link
 
-	if err != nil {
-		return nil, fmt.Errorf("%s: retries exhausted: %w", op, err)
+	if err := c.client.Do(ctx, req, &result); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return result, nil
+	return &result, nil
 }
 
-func (c *Client) DeleteLink(ctx context.Context, link bot.RemoveLinkRequest, id int64) (*bot.Link, error) {
-	const op = "Client.Scraper.DeleteLink"
+func (c *Client) AddLink(ctx context.Context, link bot.AddLinkRequest, id int64) (*bot.Link, error) {
+	const op = "Client.Scraper.AddLink"
 
-	body, err := json.Marshal(link)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
+	req := httpclient.Request{Method: http.MethodPost, URL: c.addr + links, Header: chatHeader(id), Body: link}
 
-	var result *bot.Link
+	var result bot.Link
 
-	_, err = c.breaker.Execute(func() (any, error) {
-		err = retry.Do(
-			func() error {
-				req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.addr+links, bytes.NewBuffer(body))
-				if err != nil {
-					return retry.Unrecoverable(fmt.Errorf("%s: %w", op, err))
-				}
+	if err := c.client.Do(ctx, req, &result); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-				req.Header.Set("Tg-Chat-Id", strconv.FormatInt(id, 10))
+	return &result, nil
+}
 
-				c.log.Debug("Sending DELETE request", slog.String("url", req.URL.String()))
+func (c *Client) IssueToken(ctx context.Context, id int64, scopes []string) (*bot.IssueTokenResponse, error) {
+	const op = "Client.Scraper.IssueToken"
 
-				resp, err := c.client.Do(req)
-				if err != nil {
-					return fmt.Errorf("%s: %w", op, err)
-				}
-				defer resp.Body.Close()
+	req := httpclient.Request{Method: http.MethodPost, URL: fmt.Sprintf(c.addr+tokens, id),
+		Body: bot.IssueTokenRequest{Scopes: scopes}}
 
-				if resp.StatusCode >= 500 {
-					return fmt.Errorf("%s: temporary server error: %s", op, resp.Status)
-				} else if resp.StatusCode != http.StatusOK {
-					return retry.Unrecoverable(fmt.Errorf("%s: bad status: %s", op, resp.Status))
-				}
+	var result bot.IssueTokenResponse
 
-				var linkResp bot.Link
-				if err := render.DecodeJSON(resp.Body, &linkResp); err != nil {
-					return retry.Unrecoverable(fmt.Errorf("failed to deserialize response: %s", op))
-				}
+	if err := c.client.Do(ctx, req, &result); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-				result = &linkResp
+	return &result, nil
+}
 
-				return nil
-			},
-			retry.Attempts(c.retries),
-			retry.Delay(c.backoff),
-			retry.DelayType(retry.BackOffDelay),
-			retry.Context(ctx),
-		)
+func (c *Client) RevokeTokens(ctx context.Context, id int64) (int64, error) {
+	const op = "Client.Scraper.RevokeTokens"
 
-		if err != nil {
-			return nil, err
-		}
+	req := httpclient.Request{Method: http.MethodDelete, URL: fmt.Sprintf(c.addr+tokens, id)}
 
-		return result, nil
-	})
+	var result bot.RevokeTokensResponse
 
-	if err != nil {
-		return nil, fmt.Errorf("%s: retries exhausted: %w", op, err)
+	if err := c.client.Do(ctx, req, &result); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return result, nil
+	return result.Revoked, nil
 }
 
-func (c *Client) AddLink(ctx context.Context, link bot.AddLinkRequest, id int64) (*bot.Link, error) {
-	const op = "Client.Scraper.AddLink"
+// GetHistory returns the last limit updates sent to the chat about the link.
+func (c *Client) GetHistory(ctx context.Context, id int64, link string, limit int) ([]bot.HistoryEntry, error) {
+	const op = "Client.Scraper.GetHistory"
+
+	query := url.Values{}
+	query.Set("link", link)
+	query.Set("limit", strconv.Itoa(limit))
 
-	body, err := json.Marshal(link)
-	if err != nil {
+	req := httpclient.Request{Method: http.MethodGet, URL: c.addr + history + "?" + query.Encode(),
+		Header: chatHeader(id)}
+
+	var result bot.HistoryResponse
+
+	if err := c.client.Do(ctx, req, &result); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var linksResp bot.Link
-
-	url := c.addr + links
-
-	_, err = c.breaker.Execute(func() (any, error) {
-		err = retry.Do(
-			func() error {
-				req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-				if err != nil {
-					return retry.Unrecoverable(fmt.Errorf("%s: %w", op, err))
-				}
-
-				req.Header.Set("Content-Type", "application/json")
-				req.Header.Set("Tg-Chat-Id", strconv.FormatInt(id, 10))
-
-				c.log.Debug("Sending POST request", slog.String("url", url))
-
-				resp, err := c.client.Do(req)
-				if err != nil {
-					return fmt.Errorf("%s: %w", op, err)
-				}
-				defer resp.Body.Close()
-
-				if resp.StatusCode >= 500 || resp.StatusCode == 429 {
-					return fmt.Errorf("%s: temporary server error: %s", op, resp.Status)
-				} else if resp.StatusCode != http.StatusOK {
-					return retry.Unrecoverable(fmt.Errorf("%s: bad status: %s", op, resp.Status))
-				}
-
-				err = render.DecodeJSON(resp.Body, &linksResp)
-				if err != nil {
-					return retry.Unrecoverable(fmt.Errorf("failed to deserialize request: %s", op))
-				}
-
-				return nil
-			},
-			retry.Attempts(c.retries),
-			retry.Delay(c.backoff),
-			retry.DelayType(retry.BackOffDelay),
-			retry.Context(ctx),
-		)
-
-		if err != nil {
-			return nil, err
-		}
-
-		return nil, nil
-	})
+	return result.Updates, nil
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("%s: retries exhausted: %w", op, err)
-	}
+func chatHeader(id int64) http.Header {
+	header := http.Header{}
+	header.Set("Tg-Chat-Id", strconv.FormatInt(id, 10))
 
-	return &linksResp, nil
+	return header
 }
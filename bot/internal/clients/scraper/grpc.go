@@ -0,0 +1,441 @@
+package scraperclient
+
+import (
+	"bot/internal/config"
+	"bot/internal/model/bot"
+	"bot/internal/tracing"
+	"pkg/api/scraperv1"
+	"pkg/auth"
+	"pkg/breaker"
+
+	"github.com/avast/retry-go/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	TransportHTTP = "http"
+	TransportGRPC = "grpc"
+)
+
+// GRPCClient talks to the scraper over the contract in api/proto. Besides the
+// calls the HTTP client makes it can subscribe to the scraper's update stream.
+type GRPCClient struct {
+	log     *slog.Logger
+	conn    *grpc.ClientConn
+	api     scraperv1.ScraperServiceClient
+	breaker *breaker.Breaker
+
+	// mu guards timeout, retries and backoff against Configure.
+	mu      sync.RWMutex
+	timeout time.Duration
+	retries uint
+	backoff time.Duration
+}
+
+func NewGRPC(log *slog.Logger, cfg *config.ClientsConfig, opts ...grpc.DialOption) (*GRPCClient, error) {
+	defaults := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		tracing.DialOption(),
+	}
+
+	if keys := auth.Keys(cfg.Scrapper.ServiceKeys); len(keys) > 0 {
+		defaults = append(defaults,
+			grpc.WithChainUnaryInterceptor(auth.UnaryClientInterceptor(keys)),
+			grpc.WithChainStreamInterceptor(auth.StreamClientInterceptor(keys)),
+		)
+	}
+
+	opts = append(defaults, opts...)
+
+	conn, err := grpc.NewClient(cfg.Scrapper.GRPCAddress, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create scraper gRPC client: %w", err)
+	}
+
+	// Answers like NotFound mean the scraper is fine.
+	isSuccessful := func(err error) bool {
+		return err == nil || !temporary(err)
+	}
+
+	return &GRPCClient{
+		log:     log,
+		conn:    conn,
+		api:     scraperv1.NewScraperServiceClient(conn),
+		timeout: cfg.Scrapper.Timeout,
+		retries: cfg.Scrapper.Retry,
+		backoff: cfg.Scrapper.Backoff,
+		breaker: breaker.New(log, "Scrapper gRPC Circuit Breaker", cfg.CircuitBreaker, isSuccessful),
+	}, nil
+}
+
+// Breaker is the circuit breaker guarding the scraper's gRPC API.
+func (c *GRPCClient) Breaker() *breaker.Breaker {
+	return c.breaker
+}
+
+// Configure applies reloaded timeouts, retries and breaker settings.
+func (c *GRPCClient) Configure(cfg *config.ClientsConfig) {
+	c.mu.Lock()
+	c.timeout = cfg.Scrapper.Timeout
+	c.retries = cfg.Scrapper.Retry
+	c.backoff = cfg.Scrapper.Backoff
+	c.mu.Unlock()
+
+	c.breaker.Configure(cfg.CircuitBreaker)
+}
+
+func (c *GRPCClient) settings() (timeout time.Duration, retries uint, backoff time.Duration) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.timeout, c.retries, c.backoff
+}
+
+func (c *GRPCClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *GRPCClient) RegisterChat(ctx context.Context, id int64) error {
+	return c.call(ctx, "Client.Scraper.gRPC.RegisterChat", func(ctx context.Context) error {
+		_, err := c.api.RegisterChat(ctx, &scraperv1.RegisterChatRequest{ChatId: id})
+		return err
+	})
+}
+
+func (c *GRPCClient) DeleteChat(ctx context.Context, id int64) error {
+	return c.call(ctx, "Client.Scraper.gRPC.DeleteChat", func(ctx context.Context) error {
+		_, err := c.api.DeleteChat(ctx, &scraperv1.DeleteChatRequest{ChatId: id})
+		return err
+	})
+}
+
+func (c *GRPCClient) GetLinks(ctx context.Context, id int64) (*bot.ListLinkResponse, error) {
+	var resp *scraperv1.ListLinksResponse
+
+	err := c.call(ctx, "Client.Scraper.gRPC.GetLinks", func(ctx context.Context) error {
+		var err error
+
+		resp, err = c.api.ListLinks(ctx, &scraperv1.ListLinksRequest{ChatId: id})
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &bot.ListLinkResponse{Links: make([]bot.Link, 0, len(resp.GetLinks()))}
+
+	for _, link := range resp.GetLinks() {
+		result.Links = append(result.Links, *linkFromProto(link))
+	}
+
+	result.Size = len(result.Links)
+
+	return result, nil
+}
+
+func (c *GRPCClient) AddLink(ctx context.Context, link bot.AddLinkRequest, id int64) (*bot.Link, error) {
+	var added *scraperv1.Link
+
+	err := c.call(ctx, "Client.Scraper.gRPC.AddLink", func(ctx context.Context) error {
+		var err error
+
+		added, err = c.api.AddLink(ctx, &scraperv1.AddLinkRequest{
+			ChatId:  id,
+			Url:     link.Link,
+			Tags:    link.Tags,
+			Filters: link.Filters,
+		})
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return linkFromProto(added), nil
+}
+
+func (c *GRPCClient) DeleteLink(ctx context.Context, link bot.RemoveLinkRequest, id int64) (*bot.Link, error) {
+	var removed *scraperv1.Link
+
+	err := c.call(ctx, "Client.Scraper.gRPC.DeleteLink", func(ctx context.Context) error {
+		var err error
+
+		removed, err = c.api.RemoveLink(ctx, &scraperv1.RemoveLinkRequest{ChatId: id, Url: link.Link})
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return linkFromProto(removed), nil
+}
+
+func (c *GRPCClient) IssueToken(ctx context.Context, id int64, scopes []string) (*bot.IssueTokenResponse, error) {
+	var issued *scraperv1.IssueTokenResponse
+
+	err := c.call(ctx, "Client.Scraper.gRPC.IssueToken", func(ctx context.Context) error {
+		var err error
+
+		issued, err = c.api.IssueToken(ctx, &scraperv1.IssueTokenRequest{ChatId: id, Scopes: scopes})
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &bot.IssueTokenResponse{Token: issued.GetToken(), Scopes: issued.GetScopes()}, nil
+}
+
+func (c *GRPCClient) RevokeTokens(ctx context.Context, id int64) (int64, error) {
+	var revoked *scraperv1.RevokeTokensResponse
+
+	err := c.call(ctx, "Client.Scraper.gRPC.RevokeTokens", func(ctx context.Context) error {
+		var err error
+
+		revoked, err = c.api.RevokeTokens(ctx, &scraperv1.RevokeTokensRequest{ChatId: id})
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return revoked.GetRevoked(), nil
+}
+
+func (c *GRPCClient) GetHistory(ctx context.Context, id int64, link string, limit int) ([]bot.HistoryEntry,
+	error) {
+	var resp *scraperv1.GetHistoryResponse
+
+	err := c.call(ctx, "Client.Scraper.gRPC.GetHistory", func(ctx context.Context) error {
+		var err error
+
+		resp, err = c.api.GetHistory(ctx, &scraperv1.GetHistoryRequest{
+			ChatId: id,
+			Url:    link,
+			Limit:  uint64(max(limit, 0)), //nolint:gosec // not negative
+		})
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]bot.HistoryEntry, 0, len(resp.GetEntries()))
+
+	for _, entry := range resp.GetEntries() {
+		entries = append(entries, bot.HistoryEntry{
+			ID:        entry.GetId(),
+			LinkID:    entry.GetLinkId(),
+			URL:       entry.GetUrl(),
+			Tags:      entry.GetTags(),
+			Provider:  entry.GetProvider(),
+			Type:      entry.GetType(),
+			Title:     entry.GetTitle(),
+			ItemURL:   entry.GetItemUrl(),
+			Timestamp: entry.GetTimestamp().AsTime(),
+		})
+	}
+
+	return entries, nil
+}
+
+// StreamUpdates passes every update pushed by the scraper to handle and
+// reconnects with backoff until ctx is done. Each update is acked with the
+// result of handle: the scraper sends the updates that failed, like those it
+// could not stream, through Kafka, where the consumer retries them and parks
+// them in the dead letter topic.
+func (c *GRPCClient) StreamUpdates(ctx context.Context, consumer string, handle func(context.Context, *bot.LinkUpdate) error) {
+	const op = "Client.Scraper.gRPC.StreamUpdates"
+
+	log := c.log.With(slog.String("op", op))
+	_, _, delay := c.settings()
+
+	for ctx.Err() == nil {
+		received, err := c.receive(ctx, consumer, func(update *bot.LinkUpdate) error {
+			err := handle(ctx, update)
+			if err != nil {
+				log.Error("failed to handle update, the scraper falls back to Kafka",
+					slog.String("event_id", update.EventID), slog.String("error", err.Error()))
+			}
+
+			return err
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		if received {
+			_, _, delay = c.settings()
+		}
+
+		log.Warn("update stream broken, reconnecting", slog.String("error", err.Error()),
+			slog.Duration("delay", delay))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay = min(2*delay, time.Minute)
+	}
+}
+
+func (c *GRPCClient) receive(ctx context.Context, consumer string, handle func(*bot.LinkUpdate) error) (bool, error) {
+	stream, err := c.api.StreamUpdates(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if err = stream.Send(&scraperv1.StreamUpdatesRequest{Consumer: consumer}); err != nil {
+		return false, streamError(err)
+	}
+
+	received := false
+
+	for {
+		update, err := stream.Recv()
+		if err != nil {
+			return received, streamError(err)
+		}
+
+		received = true
+
+		ack := &scraperv1.UpdateAck{EventId: update.GetEventId()}
+		if err = handle(updateFromProto(update)); err != nil {
+			ack.Error = err.Error()
+		}
+
+		if err = stream.Send(&scraperv1.StreamUpdatesRequest{Ack: ack}); err != nil {
+			return received, streamError(err)
+		}
+	}
+}
+
+func streamError(err error) error {
+	if errors.Is(err, io.EOF) {
+		return status.Error(codes.Unavailable, "stream closed by the scraper")
+	}
+
+	return err
+}
+
+func (c *GRPCClient) call(ctx context.Context, op string, fn func(ctx context.Context) error) error {
+	timeout, retries, backoff := c.settings()
+
+	_, err := c.breaker.Execute(func() (any, error) {
+		return nil, retry.Do(
+			func() error {
+				callCtx, cancel := context.WithTimeout(ctx, timeout)
+				defer cancel()
+
+				c.log.Debug("Sending gRPC request", slog.String("op", op))
+
+				if err := fn(callCtx); err != nil {
+					if !temporary(err) {
+						return retry.Unrecoverable(err)
+					}
+
+					return err
+				}
+
+				return nil
+			},
+			retry.Attempts(retries),
+			retry.Delay(backoff),
+			retry.DelayType(retry.BackOffDelay),
+			retry.Context(ctx),
+		)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: retries exhausted: %w", op, err)
+	}
+
+	return nil
+}
+
+// temporary matches the HTTP client, which retries 5xx and 429 answers.
+func temporary(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown,
+		codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+func linkFromProto(link *scraperv1.Link) *bot.Link {
+	out := &bot.Link{
+		ID:      link.GetId(),
+		URL:     link.GetUrl(),
+		Tags:    link.GetTags(),
+		Filters: link.GetFilters(),
+	}
+
+	if health := link.GetHealth(); health != nil {
+		out.Health = &bot.LinkHealth{
+			LastSuccess: timeOf(health.GetLastSuccess()),
+			LastError:   health.GetLastError(),
+			LastStatus:  int(health.GetLastStatus()),
+			Failures:    int(health.GetFailures()),
+			Broken:      health.GetBroken(),
+		}
+	}
+
+	return out
+}
+
+func updateFromProto(update *scraperv1.LinkUpdate) *bot.LinkUpdate {
+	out := &bot.LinkUpdate{
+		ID:            update.GetId(),
+		EventID:       update.GetEventId(),
+		URL:           update.GetUrl(),
+		SchemaVersion: int(update.GetSchemaVersion()),
+		TgChatIDs:     update.GetTgChatIds(),
+	}
+
+	for _, event := range update.GetEvents() {
+		out.Events = append(out.Events, bot.LinkEvent{
+			Provider:  event.GetProvider(),
+			Type:      event.GetType(),
+			ItemID:    event.GetItemId(),
+			Title:     event.GetTitle(),
+			Author:    event.GetAuthor(),
+			URL:       event.GetUrl(),
+			Timestamp: event.GetTimestamp().AsTime(),
+			Excerpt:   event.GetExcerpt(),
+			Reason:    event.GetReason(),
+		})
+	}
+
+	return out
+}
+
+func timeOf(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+
+	t := ts.AsTime()
+
+	return &t
+}
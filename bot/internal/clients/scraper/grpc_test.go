@@ -0,0 +1,230 @@
+package scraperclient_test
+
+import (
+	scraperclient "bot/internal/clients/scraper"
+	"bot/internal/config"
+	"bot/internal/model/bot"
+	"pkg/api/scraperv1"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeScraper struct {
+	scraperv1.UnimplementedScraperServiceServer
+
+	registerCalls atomic.Int32
+	streams       atomic.Int32
+	updates       []*scraperv1.LinkUpdate
+	acks          chan *scraperv1.UpdateAck
+}
+
+func (f *fakeScraper) RegisterChat(_ context.Context, req *scraperv1.RegisterChatRequest) (
+	*scraperv1.RegisterChatResponse, error) {
+	// The first call fails the way a restarting scraper does.
+	if f.registerCalls.Add(1) == 1 {
+		return nil, status.Error(codes.Unavailable, "starting")
+	}
+
+	if req.GetChatId() == 0 {
+		return nil, status.Error(codes.AlreadyExists, "chat exists")
+	}
+
+	return &scraperv1.RegisterChatResponse{}, nil
+}
+
+func (f *fakeScraper) ListLinks(_ context.Context, req *scraperv1.ListLinksRequest) (*scraperv1.ListLinksResponse,
+	error) {
+	return &scraperv1.ListLinksResponse{Links: []*scraperv1.Link{{
+		Id:   req.GetChatId(),
+		Url:  "https://github.com/some/repo",
+		Tags: []string{"work"},
+		Health: &scraperv1.LinkHealth{
+			LastSuccess: timestamppb.New(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)),
+			LastStatus:  502,
+			Failures:    2,
+		},
+	}}}, nil
+}
+
+func (f *fakeScraper) GetHistory(_ context.Context, req *scraperv1.GetHistoryRequest) (*scraperv1.GetHistoryResponse,
+	error) {
+	if req.GetUrl() == "" || req.GetLimit() != 3 {
+		return nil, status.Error(codes.InvalidArgument, "unexpected request")
+	}
+
+	return &scraperv1.GetHistoryResponse{Entries: []*scraperv1.HistoryEntry{{
+		Id:        1,
+		LinkId:    req.GetChatId(),
+		Url:       req.GetUrl(),
+		Type:      "github.issue",
+		Title:     "Issue",
+		ItemUrl:   req.GetUrl() + "/issues/1",
+		Timestamp: timestamppb.New(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)),
+	}}}, nil
+}
+
+// StreamUpdates sends its updates, records their acks and breaks the first
+// stream to make the client reconnect.
+func (f *fakeScraper) StreamUpdates(stream scraperv1.ScraperService_StreamUpdatesServer) error {
+	n := f.streams.Add(1)
+
+	if _, err := stream.Recv(); err != nil {
+		return err
+	}
+
+	if err := stream.Send(f.updates[n-1]); err != nil {
+		return err
+	}
+
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	f.acks <- req.GetAck()
+
+	if n == 1 {
+		return status.Error(codes.Unavailable, "restarting")
+	}
+
+	<-stream.Context().Done()
+
+	return nil
+}
+
+func newClient(t *testing.T, srv scraperv1.ScraperServiceServer) *scraperclient.GRPCClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	scraperv1.RegisterScraperServiceServer(server, srv)
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	cfg := &config.ClientsConfig{
+		Scrapper: config.Client{
+			GRPCAddress: "passthrough:///bufnet",
+			Timeout:     time.Second,
+			Retry:       3,
+			Backoff:     time.Millisecond,
+		},
+		CircuitBreaker: config.CBConfig{MinimumCalls: 10, Timeout: time.Second},
+	}
+
+	client, err := scraperclient.NewGRPC(slog.New(slog.NewJSONHandler(io.Discard, nil)), cfg,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = client.Close()
+		server.Stop()
+	})
+
+	return client
+}
+
+func TestGRPCClient_RetriesUnavailable(t *testing.T) {
+	srv := &fakeScraper{}
+	client := newClient(t, srv)
+
+	require.NoError(t, client.RegisterChat(context.Background(), 1))
+	require.Equal(t, int32(2), srv.registerCalls.Load())
+
+	err := client.RegisterChat(context.Background(), 0)
+	require.Error(t, err)
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+	require.Equal(t, int32(3), srv.registerCalls.Load(), "AlreadyExists must not be retried")
+}
+
+func TestGRPCClient_GetLinks(t *testing.T) {
+	client := newClient(t, &fakeScraper{})
+
+	resp, err := client.GetLinks(context.Background(), 5)
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Size)
+
+	link := resp.Links[0]
+	require.Equal(t, int64(5), link.ID)
+	require.Equal(t, []string{"work"}, link.Tags)
+	require.NotNil(t, link.Health)
+	require.Equal(t, 2, link.Health.Failures)
+	require.Equal(t, 502, link.Health.LastStatus)
+	require.True(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC).Equal(*link.Health.LastSuccess))
+	require.False(t, link.Healthy())
+}
+
+func TestGRPCClient_GetHistory(t *testing.T) {
+	client := newClient(t, &fakeScraper{})
+
+	entries, err := client.GetHistory(context.Background(), 5, "https://github.com/some/repo", 3)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "Issue", entries[0].Title)
+	require.Equal(t, "https://github.com/some/repo/issues/1", entries[0].ItemURL)
+	require.True(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC).Equal(entries[0].Timestamp))
+}
+
+func TestGRPCClient_StreamUpdatesReconnects(t *testing.T) {
+	srv := &fakeScraper{updates: []*scraperv1.LinkUpdate{
+		{Id: 1, EventId: "first", Url: "https://github.com/a/b", TgChatIds: []int64{1}},
+		{Id: 2, EventId: "second", Url: "https://github.com/a/b", TgChatIds: []int64{1, 2},
+			Events: []*scraperv1.LinkEvent{{Provider: "github", Type: "issue", ItemId: "42"}}},
+	}, acks: make(chan *scraperv1.UpdateAck, 2)}
+	client := newClient(t, srv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	got := make(chan *bot.LinkUpdate, 2)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		client.StreamUpdates(ctx, "test", func(_ context.Context, update *bot.LinkUpdate) error {
+			got <- update
+
+			if update.EventID == "first" {
+				return errors.New("telegram is down")
+			}
+
+			return nil
+		})
+	}()
+
+	first := <-got
+	require.Equal(t, "first", first.EventID)
+	ack := <-srv.acks
+	require.Equal(t, "first", ack.GetEventId())
+	require.Equal(t, "telegram is down", ack.GetError(), "a failed update must be reported to the scraper")
+
+	second := <-got
+	require.Equal(t, "second", second.EventID)
+	require.Equal(t, []int64{1, 2}, second.TgChatIDs)
+	require.Len(t, second.Events, 1)
+	require.Equal(t, "42", second.Events[0].ItemID)
+	ack = <-srv.acks
+	require.Equal(t, "second", ack.GetEventId())
+	require.Empty(t, ack.GetError())
+
+	cancel()
+	<-done
+}
@@ -1,46 +1,103 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
+	"pkg/breaker"
+	"pkg/reload"
 )
 
 type Config struct {
-	Env     string        `yaml:"env" env-default:"local"`
-	Bot     BotConfig     `yaml:"bot"`
-	Clients ClientsConfig `yaml:"bot_clients"`
+	Env string `yaml:"env" env-default:"local"`
+	// ReloadInterval is how often the file is checked for changes; zero, the
+	// default, leaves reloads to SIGHUP.
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+	Bot            BotConfig     `yaml:"bot"`
+	Clients        ClientsConfig `yaml:"bot_clients"`
+	Tracing        TracingConfig `yaml:"tracing"`
+
+	// Path is the file the config was read from and Version a hash of its
+	// content, both set by Load.
+	Path    string `yaml:"-"`
+	Version string `yaml:"-"`
+}
+
+// TracingConfig points the OTLP/HTTP trace exporter at a collector, e.g.
+// http://otel-collector:4318. Without an endpoint spans are not recorded.
+type TracingConfig struct {
+	Endpoint    string  `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
 }
 
 type BotConfig struct {
-	Address     string        `yaml:"address"`
-	Token       string        `env:"TOKEN"`
-	StoragePath string        `yaml:"storage_path"`
-	MaxIdle     int           `yaml:"max_idle" env-default:"5"`
-	MaxActive   int           `yaml:"max_active" env-default:"10"`
-	Timeout     time.Duration `yaml:"timeout" env-default:"10s"`
-	RateLimit   int           `yaml:"rate_limit" env-default:"50"`
+	Address       string        `yaml:"address"`
+	Token         string        `env:"TOKEN"`
+	StoragePath   string        `yaml:"storage_path"`
+	MaxIdle       int           `yaml:"max_idle" env-default:"5"`
+	MaxActive     int           `yaml:"max_active" env-default:"10"`
+	Timeout       time.Duration `yaml:"timeout" env-default:"10s"`
+	RateLimit     int           `yaml:"rate_limit" env-default:"50"`
+	MessageFormat string        `yaml:"message_format" env-default:"HTML"`
+	TemplatesPath string        `yaml:"templates_path" env-default:""`
+	Sender        SenderConfig  `yaml:"sender"`
+	Mode          string        `yaml:"mode" env-default:"polling"`
+	Webhook       WebhookConfig `yaml:"webhook"`
+	AdminToken    string        `yaml:"admin_token" env:"ADMIN_TOKEN"`
 }
 
-type Client struct {
-	Address  string        `yaml:"address"`
-	Timeout  time.Duration `yaml:"timeout"`
-	Retry    uint          `yaml:"retry"`
-	Backoff  time.Duration `yaml:"backoff"`
-	Token    string        `env:"TOKEN"`
-	Topic    string        `yaml:"base_topic"`
-	DLQTopic string        `yaml:"dlq_topic"`
+type SenderConfig struct {
+	Workers    int     `yaml:"workers" env-default:"4"`
+	QueueSize  int     `yaml:"queue_size" env-default:"100"`
+	GlobalRate float64 `yaml:"global_rate" env-default:"25"`
+	ChatRate   float64 `yaml:"chat_rate" env-default:"1"`
+	GroupRate  float64 `yaml:"group_rate" env-default:"0.33"`
+	MaxRetries int     `yaml:"max_retries" env-default:"3"`
+	MaxParts   int     `yaml:"max_parts" env-default:"3"`
 }
 
-type CBConfig struct {
-	MaxRequests       uint32        `yaml:"max_requests"`
-	Timeout           time.Duration `yaml:"timeout"`
-	SlidingWindowSize uint32        `yaml:"sliding_window_size"`
-	FailureCount      uint32        `yaml:"failure_count"`
+// WebhookConfig is used when Mode is "webhook". PublicURL is the address
+// Telegram calls and must be routed to Path on one of the bot replicas.
+// DeleteOnStop should be off when several replicas share the webhook.
+type WebhookConfig struct {
+	PublicURL      string `yaml:"public_url" env:"WEBHOOK_PUBLIC_URL"`
+	Path           string `yaml:"path" env-default:"/telegram/webhook"`
+	SecretToken    string `yaml:"secret_token" env:"WEBHOOK_SECRET"`
+	MaxConnections int    `yaml:"max_connections" env-default:"40"`
+	DropPending    bool   `yaml:"drop_pending"`
+	DeleteOnStop   bool   `yaml:"delete_on_stop" env-default:"true"`
 }
 
+// Client is shared by the scraper and Kafka clients. Transport picks how the
+// bot talks to the scraper: "http" uses Address, "grpc" uses GRPCAddress and
+// also receives updates over the scraper's update stream. ServiceKeys are
+// shared with the scraper: the first one signs requests to it, any of them is
+// accepted on requests from it. Without keys requests are not authenticated.
+// ScraperDeadLetterTopic is where the scraper parks updates it failed to
+// publish; the DLQ admin endpoints serve it next to DeadLetterTopic.
+type Client struct {
+	Address         string        `yaml:"address"`
+	GRPCAddress     string        `yaml:"grpc_address"`
+	Transport       string        `yaml:"transport" env-default:"http"`
+	Timeout         time.Duration `yaml:"timeout"`
+	Retry           uint          `yaml:"retry"`
+	Backoff         time.Duration `yaml:"backoff"`
+	Token           string        `env:"TOKEN"`
+	Topic           string        `yaml:"base_topic"`
+	DeadLetterTopic string        `yaml:"dead_letter_topic"`
+	ServiceKeys     []string      `yaml:"service_keys" env:"SERVICE_KEYS" env-separator:","`
+
+	ScraperDeadLetterTopic string `yaml:"scraper_dead_letter_topic" env-default:"dlq-update-link"`
+}
+
+// CBConfig describes the circuit breaker of every client.
+type CBConfig = breaker.Config
+
 type ClientsConfig struct {
 	Kafka          Client   `yaml:"kafka"`
 	Scrapper       Client   `yaml:"scraper"`
@@ -61,15 +118,92 @@ func MustLoadPath(configPath string) *Config {
 		panic("config file does not exist: " + configPath)
 	}
 
+	cfg, err := Load(configPath)
+	if err != nil {
+		panic("cannot read config: " + err.Error())
+	}
+
+	return cfg
+}
+
+// Load reads and validates the config at path.
+func Load(path string) (*Config, error) {
+	const op = "config.Load"
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	cfg, err := parse(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cfg, nil
+}
+
+func parse(path string, data []byte) (*Config, error) {
 	var cfg Config
 
-	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-		panic("cannot read config: " + err.Error())
+	if err := cleanenv.ParseYAML(bytes.NewReader(data), &cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cleanenv.ReadEnv(&cfg); err != nil {
+		return nil, err
 	}
 
 	cfg.Bot.Token = os.Getenv("BOT_TOKEN")
 
-	return &cfg
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	cfg.Path = path
+	cfg.Version = reload.Version(data)
+
+	return &cfg, nil
+}
+
+// Validate reports every setting the bot cannot run with.
+func (c *Config) Validate() error {
+	var errs []error
+
+	check := func(ok bool, msg string) {
+		if !ok {
+			errs = append(errs, errors.New(msg))
+		}
+	}
+
+	check(c.ReloadInterval >= 0, "reload_interval must not be negative")
+	check(c.Bot.RateLimit > 0, "bot.rate_limit must be positive")
+	check(c.Bot.Sender.Workers > 0, "bot.sender.workers must be positive")
+	check(c.Bot.Sender.QueueSize > 0, "bot.sender.queue_size must be positive")
+
+	clients := []struct {
+		name   string
+		client Client
+	}{
+		{"kafka", c.Clients.Kafka},
+		{"scraper", c.Clients.Scrapper},
+	}
+
+	for _, client := range clients {
+		check(client.client.Timeout >= 0, "bot_clients."+client.name+".timeout must not be negative")
+		check(client.client.Backoff >= 0, "bot_clients."+client.name+".backoff must not be negative")
+	}
+
+	cb := c.Clients.CircuitBreaker
+
+	check(cb.WindowSize > 0, "bot_clients.circuit_breaker.window_size must be positive")
+	check(cb.MinimumCalls > 0, "bot_clients.circuit_breaker.minimum_calls must be positive")
+	check(cb.FailureRateThreshold > 0 && cb.FailureRateThreshold <= 100,
+		"bot_clients.circuit_breaker.failure_rate_threshold must be in (0, 100]")
+	check(cb.HalfOpenCalls > 0, "bot_clients.circuit_breaker.half_open_calls must be positive")
+	check(cb.Timeout > 0, "bot_clients.circuit_breaker.timeout must be positive")
+
+	return errors.Join(errs...)
 }
 
 func fetchConfigPath() string {
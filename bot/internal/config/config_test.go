@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+reload_interval: %s
+bot:
+  address: %s
+  rate_limit: %d
+bot_clients:
+  scraper:
+    retry: 3
+  circuit_breaker:
+    failure_rate_threshold: 50
+`
+
+func writeConfig(t *testing.T, path, interval, address string, rateLimit int) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(testConfig, interval, address, rateLimit)), 0o600))
+}
+
+func newReloader(t *testing.T) (*Reloader, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "0s", "0.0.0.0:8080", 10)
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	return NewReloader(slog.New(slog.NewJSONHandler(io.Discard, nil)), cfg), path
+}
+
+func TestLoad_Validates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	require.NoError(t, os.WriteFile(path, []byte("bot:\n  rate_limit: -1\n"), 0o600))
+
+	_, err := Load(path)
+	require.ErrorContains(t, err, "bot.rate_limit must be positive")
+
+	require.NoError(t, os.WriteFile(path, []byte("bot_clients:\n  circuit_breaker:\n    failure_rate_threshold: 150\n"),
+		0o600))
+
+	_, err = Load(path)
+	require.ErrorContains(t, err, "failure_rate_threshold must be in (0, 100]")
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	writeConfig(t, path, "0s", "0.0.0.0:8080", 10)
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, path, cfg.Path)
+	require.Len(t, cfg.Version, 12)
+	require.Equal(t, 4, cfg.Bot.Sender.Workers)
+	require.Equal(t, uint32(10), cfg.Clients.CircuitBreaker.MinimumCalls)
+}
+
+func TestReloader_KeepsRestartOnlySettings(t *testing.T) {
+	reloader, path := newReloader(t)
+
+	writeConfig(t, path, "1h", "0.0.0.0:9090", 30)
+
+	require.NoError(t, reloader.Reload())
+
+	cfg := reloader.Current()
+	require.Equal(t, "0.0.0.0:8080", cfg.Bot.Address)
+	require.Zero(t, cfg.ReloadInterval)
+	require.Equal(t, 30, cfg.Bot.RateLimit)
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"log/slog"
+
+	"pkg/reload"
+)
+
+// Reloader hands the settings that are safe to change at runtime to its
+// subscribers: the rate limit, the timeout, retries and backoff of the
+// scraper client, and the circuit breaker.
+type Reloader = reload.Reloader[*Config]
+
+func NewReloader(log *slog.Logger, cfg *Config) *Reloader {
+	return reload.New(log, cfg, reload.Options[*Config]{
+		Path:       cfg.Path,
+		Version:    cfg.Version,
+		Interval:   cfg.ReloadInterval,
+		Parse:      parse,
+		Reloadable: reloadable,
+	})
+}
+
+// reloadable is current with the settings that are safe to change at runtime
+// taken from next.
+func reloadable(current, next *Config) *Config {
+	cfg := *current
+
+	cfg.Path = next.Path
+	cfg.Version = next.Version
+
+	cfg.Bot.RateLimit = next.Bot.RateLimit
+
+	cfg.Clients.Scrapper.Timeout = next.Clients.Scrapper.Timeout
+	cfg.Clients.Scrapper.Retry = next.Clients.Scrapper.Retry
+	cfg.Clients.Scrapper.Backoff = next.Clients.Scrapper.Backoff
+	cfg.Clients.CircuitBreaker = next.Clients.CircuitBreaker
+
+	return &cfg
+}
@@ -0,0 +1,133 @@
+// Package health serves the liveness and readiness endpoints. /healthz only
+// says the process is up; /readyz runs the registered checks and answers 503
+// when a critical one fails.
+package health
+
+import (
+	"github.com/go-chi/render"
+
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+)
+
+// Check reports a dependency as healthy by returning nil.
+type Check func(ctx context.Context) error
+
+type Result struct {
+	Status     string `json:"status"`
+	Critical   bool   `json:"critical"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+type check struct {
+	name     string
+	critical bool
+	fn       Check
+}
+
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+}
+
+// New returns a Checker that gives every check at most timeout.
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a check that makes the service unready when it fails.
+func (c *Checker) Add(name string, fn Check) {
+	c.checks = append(c.checks, check{name: name, critical: true, fn: fn})
+}
+
+// AddOptional registers a check that only degrades the report.
+func (c *Checker) AddOptional(name string, fn Check) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// Run runs the checks concurrently.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for _, ch := range c.checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			result := c.run(ctx, ch)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[ch.name] = result
+
+			switch {
+			case result.Status == StatusOK:
+			case ch.critical:
+				report.Status = StatusFail
+			case report.Status == StatusOK:
+				report.Status = StatusDegraded
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, ch check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ch.fn(ctx)
+
+	result := Result{Status: StatusOK, Critical: ch.critical, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// Live answers /healthz.
+func (c *Checker) Live() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		render.JSON(writer, request, Report{Status: StatusOK})
+	}
+}
+
+// Ready answers /readyz with the report, 503 when a critical check failed.
+func (c *Checker) Ready() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		report := c.Run(request.Context())
+
+		if report.Status == StatusFail {
+			render.Status(request, http.StatusServiceUnavailable)
+		}
+
+		render.JSON(writer, request, report)
+	}
+}
@@ -0,0 +1,116 @@
+package health_test
+
+import (
+	"bot/internal/health"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v3"
+
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func ready(t *testing.T, checker *health.Checker) (int, health.Report) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	checker.Ready()(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report health.Report
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+
+	return recorder.Code, report
+}
+
+func TestChecker_Ready(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	failing := func(context.Context) error { return errors.New("connection refused") }
+
+	t.Run("all checks pass", func(t *testing.T) {
+		checker := health.New(time.Second)
+		checker.Add("redis", ok)
+		checker.AddOptional("scraper", ok)
+
+		code, report := ready(t, checker)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, health.StatusOK, report.Status)
+		require.Len(t, report.Checks, 2)
+	})
+
+	t.Run("optional check fails", func(t *testing.T) {
+		checker := health.New(time.Second)
+		checker.Add("redis", ok)
+		checker.AddOptional("scraper", failing)
+
+		code, report := ready(t, checker)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, health.StatusDegraded, report.Status)
+		require.Equal(t, "connection refused", report.Checks["scraper"].Error)
+		require.False(t, report.Checks["scraper"].Critical)
+	})
+
+	t.Run("critical check fails", func(t *testing.T) {
+		checker := health.New(time.Second)
+		checker.Add("redis", failing)
+		checker.AddOptional("scraper", failing)
+
+		code, report := ready(t, checker)
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		require.Equal(t, health.StatusFail, report.Status)
+		require.Equal(t, health.StatusFail, report.Checks["redis"].Status)
+	})
+
+	t.Run("slow check times out", func(t *testing.T) {
+		checker := health.New(10 * time.Millisecond)
+		checker.Add("redis", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		code, report := ready(t, checker)
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		require.Equal(t, context.DeadlineExceeded.Error(), report.Checks["redis"].Error)
+	})
+}
+
+func TestChecker_Live(t *testing.T) {
+	checker := health.New(time.Second)
+	checker.Add("redis", func(context.Context) error { return errors.New("down") })
+
+	recorder := httptest.NewRecorder()
+	checker.Live()(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"status":"ok"}`, recorder.Body.String())
+}
+
+type blockingPoller struct{}
+
+func (blockingPoller) Poll(_ *telebot.Bot, _ chan telebot.Update, stop chan struct{}) {
+	<-stop
+}
+
+func TestPoller_Check(t *testing.T) {
+	poller := health.NewPoller(blockingPoller{})
+	require.Error(t, poller.Check(context.Background()))
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		poller.Poll(nil, nil, stop)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool { return poller.Check(context.Background()) == nil }, time.Second, time.Millisecond)
+
+	close(stop)
+	<-done
+
+	require.Error(t, poller.Check(context.Background()))
+}
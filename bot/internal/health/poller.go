@@ -0,0 +1,37 @@
+package health
+
+import (
+	"gopkg.in/telebot.v3"
+
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// Poller wraps the poller of the Telegram bot and reports whether it is
+// receiving updates.
+type Poller struct {
+	telebot.Poller
+
+	running atomic.Bool
+}
+
+func NewPoller(poller telebot.Poller) *Poller {
+	return &Poller{Poller: poller}
+}
+
+func (p *Poller) Poll(b *telebot.Bot, dest chan telebot.Update, stop chan struct{}) {
+	p.running.Store(true)
+	defer p.running.Store(false)
+
+	p.Poller.Poll(b, dest, stop)
+}
+
+// Check fails while the poller is not running.
+func (p *Poller) Check(context.Context) error {
+	if !p.running.Load() {
+		return errors.New("telegram poller is not running")
+	}
+
+	return nil
+}
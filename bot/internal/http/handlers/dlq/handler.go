@@ -0,0 +1,193 @@
+package dlq
+
+import (
+	"bot/internal/clients/kafka"
+	botModel "bot/internal/model/bot"
+	"bot/utils"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+
+	// TopicBot is the bot's own dead letter topic, TopicScraper the one the
+	// scraper parks updates it failed to publish in.
+	TopicBot     = "bot"
+	TopicScraper = "scraper"
+)
+
+type Admin interface {
+	List(ctx context.Context, limit int) ([]botModel.DeadLetter, error)
+	Replay(ctx context.Context, positions []botModel.DeadLetterPosition) (int, error)
+	Purge(ctx context.Context, through []botModel.DeadLetterPosition) (int64, error)
+}
+
+// Topics maps the values of the topic query parameter to the admins of the
+// dead letter topics. Requests without the parameter work on TopicBot.
+type Topics map[string]Admin
+
+// pick writes a 400 and returns false when the requested topic is unknown.
+func (t Topics) pick(writer http.ResponseWriter, request *http.Request) (Admin, bool) {
+	topic := request.URL.Query().Get("topic")
+	if topic == "" {
+		topic = TopicBot
+	}
+
+	admin, ok := t[topic]
+	if !ok {
+		utils.RespondWithError(writer, http.StatusBadRequest, "unknown topic provided", "BadRequest",
+			"APIError", "topic must be "+TopicBot+" or "+TopicScraper)
+	}
+
+	return admin, ok
+}
+
+// List handles GET /admin/dlq?limit=N&topic=T.
+func List(log *slog.Logger, topics Topics) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const op = "handlers.dlq.list"
+
+		log := log.With(slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(request.Context())))
+
+		admin, ok := topics.pick(writer, request)
+		if !ok {
+			return
+		}
+
+		limit := defaultLimit
+
+		if raw := request.URL.Query().Get("limit"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 || parsed > maxLimit {
+				utils.RespondWithError(writer, http.StatusBadRequest, "invalid limit provided", "BadRequest",
+					"APIError", "limit must be between 1 and "+strconv.Itoa(maxLimit))
+
+				return
+			}
+
+			limit = parsed
+		}
+
+		letters, err := admin.List(request.Context(), limit)
+		if err != nil {
+			log.Error("failed to list dead letters", slog.String("error", err.Error()))
+			utils.RespondWithError(writer, http.StatusInternalServerError, "failed to list dead letters",
+				"StatusInternalServerError", "APIError", "failed to list dead letters")
+
+			return
+		}
+
+		render.JSON(writer, request, letters)
+	}
+}
+
+// Replay handles POST /admin/dlq/replay?topic=T.
+func Replay(log *slog.Logger, topics Topics) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const op = "handlers.dlq.replay"
+
+		log := log.With(slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(request.Context())))
+
+		admin, ok := topics.pick(writer, request)
+		if !ok {
+			return
+		}
+
+		var req botModel.ReplayRequest
+
+		if err := render.DecodeJSON(request.Body, &req); err != nil {
+			log.Error("failed to deserialize request", slog.String("error", err.Error()))
+			utils.RespondWithError(writer, http.StatusBadRequest, "failed to deserialize request", "StatusBadRequest",
+				"APIError", "failed to deserialize request")
+
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("fail to validate request", slog.String("error", err.Error()))
+			utils.RespondWithError(writer, http.StatusBadRequest, "fail to validate request", "StatusBadRequest",
+				"APIError", "fail to validate request")
+
+			return
+		}
+
+		replayed, err := admin.Replay(request.Context(), req.Messages)
+		if err != nil {
+			log.Error("failed to replay dead letters", slog.Int("replayed", replayed),
+				slog.String("error", err.Error()))
+
+			if errors.Is(err, kafka.ErrDeadLetterNotFound) {
+				utils.RespondWithError(writer, http.StatusNotFound, "dead letter does not exist", "StatusNotFound",
+					"APIError", err.Error())
+
+				return
+			}
+
+			utils.RespondWithError(writer, http.StatusInternalServerError, "failed to replay dead letters",
+				"StatusInternalServerError", "APIError", err.Error())
+
+			return
+		}
+
+		log.Info("dead letters replayed", slog.Int("replayed", replayed))
+
+		render.JSON(writer, request, botModel.ReplayResponse{Replayed: replayed})
+	}
+}
+
+// Purge handles DELETE /admin/dlq?topic=T.
+func Purge(log *slog.Logger, topics Topics) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const op = "handlers.dlq.purge"
+
+		log := log.With(slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(request.Context())))
+
+		admin, ok := topics.pick(writer, request)
+		if !ok {
+			return
+		}
+
+		var req botModel.PurgeRequest
+
+		if err := render.DecodeJSON(request.Body, &req); err != nil {
+			log.Error("failed to deserialize request", slog.String("error", err.Error()))
+			utils.RespondWithError(writer, http.StatusBadRequest, "failed to deserialize request", "StatusBadRequest",
+				"APIError", "failed to deserialize request")
+
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("fail to validate request", slog.String("error", err.Error()))
+			utils.RespondWithError(writer, http.StatusBadRequest, "fail to validate request", "StatusBadRequest",
+				"APIError", "fail to validate request")
+
+			return
+		}
+
+		purged, err := admin.Purge(request.Context(), req.Through)
+		if err != nil {
+			log.Error("failed to purge dead letters", slog.String("error", err.Error()))
+			utils.RespondWithError(writer, http.StatusInternalServerError, "failed to purge dead letters",
+				"StatusInternalServerError", "APIError", "failed to purge dead letters")
+
+			return
+		}
+
+		log.Info("dead letters purged", slog.Int64("purged", purged))
+
+		render.JSON(writer, request, botModel.PurgeResponse{Purged: purged})
+	}
+}
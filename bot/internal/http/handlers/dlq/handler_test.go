@@ -0,0 +1,152 @@
+package dlq_test
+
+import (
+	"bot/internal/clients/kafka"
+	"bot/internal/http/handlers/dlq"
+	botModel "bot/internal/model/bot"
+	"github.com/stretchr/testify/assert"
+
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeAdmin struct {
+	letters  []botModel.DeadLetter
+	replayed []botModel.DeadLetterPosition
+	through  []botModel.DeadLetterPosition
+	limit    int
+	err      error
+}
+
+func (f *fakeAdmin) List(_ context.Context, limit int) ([]botModel.DeadLetter, error) {
+	f.limit = limit
+	return f.letters, f.err
+}
+
+func (f *fakeAdmin) Replay(_ context.Context, positions []botModel.DeadLetterPosition) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+
+	f.replayed = append(f.replayed, positions...)
+
+	return len(positions), nil
+}
+
+func (f *fakeAdmin) Purge(_ context.Context, through []botModel.DeadLetterPosition) (int64, error) {
+	f.through = through
+	return int64(len(f.letters)), f.err
+}
+
+func TestDLQHandler_List(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	admin := &fakeAdmin{letters: []botModel.DeadLetter{{Offset: 3, Reason: kafka.ReasonProcessing, Error: "boom"}}}
+
+	rec := httptest.NewRecorder()
+	dlq.List(logger, dlq.Topics{dlq.TopicBot: admin})(rec, httptest.NewRequest(http.MethodGet, "/admin/dlq?limit=10", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 10, admin.limit)
+
+	var got []botModel.DeadLetter
+
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	assert.Equal(t, admin.letters, got)
+}
+
+func TestDLQHandler_ListScraperTopic(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	bot := &fakeAdmin{}
+	scraper := &fakeAdmin{letters: []botModel.DeadLetter{{Offset: 1, Error: "kafka is down"}}}
+
+	rec := httptest.NewRecorder()
+	dlq.List(logger, dlq.Topics{dlq.TopicBot: bot, dlq.TopicScraper: scraper})(rec,
+		httptest.NewRequest(http.MethodGet, "/admin/dlq?topic=scraper", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 50, scraper.limit)
+	assert.Zero(t, bot.limit, "the bot's topic must not be read")
+}
+
+func TestDLQHandler_UnknownTopic(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	rec := httptest.NewRecorder()
+	dlq.List(logger, dlq.Topics{dlq.TopicBot: &fakeAdmin{}})(rec,
+		httptest.NewRequest(http.MethodGet, "/admin/dlq?topic=scraper", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestDLQHandler_ListInvalidLimit(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	rec := httptest.NewRecorder()
+	dlq.List(logger, dlq.Topics{dlq.TopicBot: &fakeAdmin{}})(rec, httptest.NewRequest(http.MethodGet, "/admin/dlq?limit=-1", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestDLQHandler_Replay(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	admin := &fakeAdmin{}
+
+	body, _ := json.Marshal(botModel.ReplayRequest{Messages: []botModel.DeadLetterPosition{{Partition: 0, Offset: 2}}})
+
+	rec := httptest.NewRecorder()
+	dlq.Replay(logger, dlq.Topics{dlq.TopicBot: admin})(rec, httptest.NewRequest(http.MethodPost, "/admin/dlq/replay", bytes.NewBuffer(body)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []botModel.DeadLetterPosition{{Partition: 0, Offset: 2}}, admin.replayed)
+	assert.JSONEq(t, `{"replayed":1}`, rec.Body.String())
+}
+
+func TestDLQHandler_ReplayValidationError(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	rec := httptest.NewRecorder()
+	dlq.Replay(logger, dlq.Topics{dlq.TopicBot: &fakeAdmin{}})(rec, httptest.NewRequest(http.MethodPost, "/admin/dlq/replay",
+		bytes.NewBufferString(`{"messages":[]}`)))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestDLQHandler_ReplayNotFound(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	admin := &fakeAdmin{err: fmt.Errorf("%w: 0/9", kafka.ErrDeadLetterNotFound)}
+
+	rec := httptest.NewRecorder()
+	dlq.Replay(logger, dlq.Topics{dlq.TopicBot: admin})(rec, httptest.NewRequest(http.MethodPost, "/admin/dlq/replay",
+		bytes.NewBufferString(`{"messages":[{"partition":0,"offset":9}]}`)))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestDLQHandler_Purge(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	admin := &fakeAdmin{letters: make([]botModel.DeadLetter, 2)}
+
+	rec := httptest.NewRecorder()
+	dlq.Purge(logger, dlq.Topics{dlq.TopicBot: admin})(rec, httptest.NewRequest(http.MethodDelete, "/admin/dlq",
+		bytes.NewBufferString(`{"through":[{"partition":0,"offset":1}]}`)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"purged":2}`, rec.Body.String())
+	assert.Equal(t, []botModel.DeadLetterPosition{{Partition: 0, Offset: 1}}, admin.through)
+}
+
+func TestDLQHandler_PurgeNeedsOffsets(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	rec := httptest.NewRecorder()
+	dlq.Purge(logger, dlq.Topics{dlq.TopicBot: &fakeAdmin{}})(rec, httptest.NewRequest(http.MethodDelete, "/admin/dlq", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "a purge of everything must name the offsets")
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 
+	"context"
 	"errors"
 	"log/slog"
 	"net/http"
@@ -14,7 +15,7 @@ import (
 
 //go:generate mockery --name=UseCase --output=mocks/ --outpkg=mocks
 type UseCase interface {
-	Update(linkUpdate *botModel.LinkUpdate) error
+	Update(ctx context.Context, linkUpdate *botModel.LinkUpdate) error
 }
 
 func New(log *slog.Logger, uc UseCase) http.HandlerFunc {
@@ -48,7 +49,7 @@ func New(log *slog.Logger, uc UseCase) http.HandlerFunc {
 			return
 		}
 
-		err = uc.Update(&req)
+		err = uc.Update(request.Context(), &req)
 		if err != nil {
 			log.Error("failed to update")
 			utils.RespondWithError(writer, http.StatusInternalServerError, "failed to update", "StatusInternalServerError",
@@ -14,6 +14,7 @@ import (
 
 	botModel "bot/internal/model/bot"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestUpdateHandler_Success(t *testing.T) {
@@ -23,17 +24,16 @@ func TestUpdateHandler_Success(t *testing.T) {
 	handler := update.New(logger, mockUseCase)
 
 	reqBody := botModel.LinkUpdate{
-		ID:          1,
-		URL:         "URL",
-		Description: "Description",
-		TgChatIDs:   []int64{1, 2},
+		ID:        1,
+		URL:       "URL",
+		TgChatIDs: []int64{1, 2},
 	}
 
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest(http.MethodPost, "/update", bytes.NewBuffer(body))
 	rec := httptest.NewRecorder()
 
-	mockUseCase.On("Update", &reqBody).Return(nil)
+	mockUseCase.On("Update", mock.Anything, &reqBody).Return(nil)
 
 	handler(rec, req)
 
@@ -69,7 +69,7 @@ func TestUpdateHandler_ValidationError(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/update", bytes.NewBuffer(body))
 	rec := httptest.NewRecorder()
 
-	mockUseCase.On("Update", &reqBody).Return(nil)
+	mockUseCase.On("Update", mock.Anything, &reqBody).Return(nil)
 
 	handler(rec, req)
 
@@ -84,17 +84,16 @@ func TestUpdateHandler_UseCaseError(t *testing.T) {
 	handler := update.New(logger, mockUseCase)
 
 	reqBody := botModel.LinkUpdate{
-		ID:          1,
-		URL:         "URL",
-		Description: "Description",
-		TgChatIDs:   []int64{1, 2},
+		ID:        1,
+		URL:       "URL",
+		TgChatIDs: []int64{1, 2},
 	}
 
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest(http.MethodPost, "/update", bytes.NewBuffer(body))
 	rec := httptest.NewRecorder()
 
-	mockUseCase.On("Update", &reqBody).Return(errors.New("some error"))
+	mockUseCase.On("Update", mock.Anything, &reqBody).Return(errors.New("some error"))
 
 	handler(rec, req)
 
@@ -3,7 +3,10 @@
 package mocks
 
 import (
+	context "context"
+
 	bot "bot/internal/model/bot"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -20,17 +23,17 @@ func (_m *UseCase) EXPECT() *UseCase_Expecter {
 	return &UseCase_Expecter{mock: &_m.Mock}
 }
 
-// Update provides a mock function with given fields: linkUpdate
-func (_m *UseCase) Update(linkUpdate *bot.LinkUpdate) error {
-	ret := _m.Called(linkUpdate)
+// Update provides a mock function with given fields: ctx, linkUpdate
+func (_m *UseCase) Update(ctx context.Context, linkUpdate *bot.LinkUpdate) error {
+	ret := _m.Called(ctx, linkUpdate)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Update")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*bot.LinkUpdate) error); ok {
-		r0 = rf(linkUpdate)
+	if rf, ok := ret.Get(0).(func(context.Context, *bot.LinkUpdate) error); ok {
+		r0 = rf(ctx, linkUpdate)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -44,14 +47,15 @@ type UseCase_Update_Call struct {
 }
 
 // Update is a helper method to define mock.On call
+//   - ctx context.Context
 //   - linkUpdate *bot.LinkUpdate
-func (_e *UseCase_Expecter) Update(linkUpdate interface{}) *UseCase_Update_Call {
-	return &UseCase_Update_Call{Call: _e.mock.On("Update", linkUpdate)}
+func (_e *UseCase_Expecter) Update(ctx interface{}, linkUpdate interface{}) *UseCase_Update_Call {
+	return &UseCase_Update_Call{Call: _e.mock.On("Update", ctx, linkUpdate)}
 }
 
-func (_c *UseCase_Update_Call) Run(run func(linkUpdate *bot.LinkUpdate)) *UseCase_Update_Call {
+func (_c *UseCase_Update_Call) Run(run func(ctx context.Context, linkUpdate *bot.LinkUpdate)) *UseCase_Update_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*bot.LinkUpdate))
+		run(args[0].(context.Context), args[1].(*bot.LinkUpdate))
 	})
 	return _c
 }
@@ -61,7 +65,7 @@ func (_c *UseCase_Update_Call) Return(_a0 error) *UseCase_Update_Call {
 	return _c
 }
 
-func (_c *UseCase_Update_Call) RunAndReturn(run func(*bot.LinkUpdate) error) *UseCase_Update_Call {
+func (_c *UseCase_Update_Call) RunAndReturn(run func(context.Context, *bot.LinkUpdate) error) *UseCase_Update_Call {
 	_c.Call.Return(run)
 	return _c
 }
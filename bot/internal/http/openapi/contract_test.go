@@ -0,0 +1,280 @@
+package openapi_test
+
+import (
+	"bot/internal/clients/kafka"
+	"bot/internal/config"
+	dlqHandler "bot/internal/http/handlers/dlq"
+	updateHandler "bot/internal/http/handlers/update"
+	"bot/internal/http/openapi"
+	botModel "bot/internal/model/bot"
+	"bot/utils"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"pkg/admin"
+	"pkg/breaker"
+	breakersHandler "pkg/breakers"
+
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const adminToken = "secret"
+
+type fakeUseCase struct{}
+
+// Update fails for updates of link 3.
+func (fakeUseCase) Update(_ context.Context, update *botModel.LinkUpdate) error {
+	if update.ID == 3 {
+		return errors.New("telegram is down")
+	}
+
+	return nil
+}
+
+type fakeAdmin struct {
+	err error
+}
+
+func (f *fakeAdmin) List(_ context.Context, limit int) ([]botModel.DeadLetter, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	letters := make([]botModel.DeadLetter, 0, limit)
+	letters = append(letters, botModel.DeadLetter{Partition: 0, Offset: 3, Reason: kafka.ReasonProcessing,
+		Error: "boom", OriginalTopic: "update-link", Payload: `{"id":1}`})
+
+	return letters, nil
+}
+
+func (f *fakeAdmin) Replay(_ context.Context, positions []botModel.DeadLetterPosition) (int, error) {
+	for _, pos := range positions {
+		if pos.Offset == 404 {
+			return 0, fmt.Errorf("%w: %d/%d", kafka.ErrDeadLetterNotFound, pos.Partition, pos.Offset)
+		}
+	}
+
+	return len(positions), f.err
+}
+
+func (f *fakeAdmin) Purge(_ context.Context, _ []botModel.DeadLetterPosition) (int64, error) {
+	return 1, f.err
+}
+
+func newRouter(t *testing.T, dlqAdmin dlqHandler.Admin) http.Handler {
+	t.Helper()
+
+	log := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	validate, err := openapi.Validate(log, doc)
+	require.NoError(t, err)
+
+	breakers := breaker.NewRegistry()
+	breakers.Add("scraper", breaker.New(log, "Scraper API Circuit Breaker", config.CBConfig{}, nil))
+
+	router := chi.NewRouter()
+	router.With(validate).Get("/openapi.json", openapi.Handler(doc))
+	router.With(validate).Post("/updates", updateHandler.New(log, fakeUseCase{}))
+
+	router.Route("/admin", func(r chi.Router) {
+		r.Use(admin.New(adminToken, utils.RespondWithError))
+		r.Use(validate)
+
+		topics := dlqHandler.Topics{dlqHandler.TopicBot: dlqAdmin, dlqHandler.TopicScraper: dlqAdmin}
+
+		r.Get("/dlq", dlqHandler.List(log, topics))
+		r.Post("/dlq/replay", dlqHandler.Replay(log, topics))
+		r.Delete("/dlq", dlqHandler.Purge(log, topics))
+
+		r.Get("/breakers", breakersHandler.List(breakers))
+		r.Post("/breakers/{name}/reset", breakersHandler.Reset(log, breakers, utils.RespondWithError))
+	})
+
+	return router
+}
+
+// TestContract runs every documented answer of every handler and checks the
+// request and the response against the document.
+func TestContract(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	specRouter, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err)
+
+	update := `{"id":%d,"url":"https://github.com/a/b","tgChatIds":[1],"schemaVersion":1,"events":[` +
+		`{"provider":"github","type":"issue","itemId":"42","url":"https://github.com/a/b/issues/42",` +
+		`"timestamp":"2025-01-02T03:04:05Z"}]}`
+
+	tests := []struct {
+		name   string
+		admin  *fakeAdmin
+		method string
+		path   string
+		token  string
+		body   string
+		status int
+	}{
+		{"deliver update", nil, http.MethodPost, "/updates", "", fmt.Sprintf(update, 1), http.StatusOK},
+		{"deliver legacy update", nil, http.MethodPost, "/updates", "",
+			`{"id":1,"url":"https://github.com/a/b","description":"new issue","tgChatIds":[1,2]}`, http.StatusOK},
+		{"deliver update fails", nil, http.MethodPost, "/updates", "", fmt.Sprintf(update, 3),
+			http.StatusInternalServerError},
+		{"list dead letters", &fakeAdmin{}, http.MethodGet, "/admin/dlq?limit=10", adminToken, "", http.StatusOK},
+		{"list scraper dead letters", &fakeAdmin{}, http.MethodGet, "/admin/dlq?topic=scraper", adminToken, "",
+			http.StatusOK},
+		{"list dead letters fails", &fakeAdmin{err: errors.New("kafka is down")}, http.MethodGet, "/admin/dlq",
+			adminToken, "", http.StatusInternalServerError},
+		{"list dead letters without token", &fakeAdmin{}, http.MethodGet, "/admin/dlq", "wrong", "",
+			http.StatusUnauthorized},
+		{"replay dead letters", &fakeAdmin{}, http.MethodPost, "/admin/dlq/replay", adminToken,
+			`{"messages":[{"partition":0,"offset":3}]}`, http.StatusOK},
+		{"replay missing dead letter", &fakeAdmin{}, http.MethodPost, "/admin/dlq/replay", adminToken,
+			`{"messages":[{"partition":0,"offset":404}]}`, http.StatusNotFound},
+		{"purge dead letters", &fakeAdmin{}, http.MethodDelete, "/admin/dlq", adminToken,
+			`{"through":[{"partition":0,"offset":3}]}`, http.StatusOK},
+		{"purge dead letters fails", &fakeAdmin{err: errors.New("kafka is down")}, http.MethodDelete,
+			"/admin/dlq", adminToken, `{"through":[{"partition":0,"offset":3}]}`, http.StatusInternalServerError},
+		{"list breakers", nil, http.MethodGet, "/admin/breakers", adminToken, "", http.StatusOK},
+		{"reset breaker", nil, http.MethodPost, "/admin/breakers/scraper/reset", adminToken, "", http.StatusOK},
+		{"reset missing breaker", nil, http.MethodPost, "/admin/breakers/github/reset", adminToken, "",
+			http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := newRequest(tc.method, tc.path, tc.token, tc.body)
+
+			route, params, err := specRouter.FindRoute(request)
+			require.NoError(t, err)
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    request,
+				PathParams: params,
+				Route:      route,
+				Options:    &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+			}
+			require.NoError(t, openapi3filter.ValidateRequest(context.Background(), input))
+
+			dlqAdmin := tc.admin
+			if dlqAdmin == nil {
+				dlqAdmin = &fakeAdmin{}
+			}
+
+			rec := httptest.NewRecorder()
+			newRouter(t, dlqAdmin).ServeHTTP(rec, newRequest(tc.method, tc.path, tc.token, tc.body))
+
+			require.Equal(t, tc.status, rec.Code, rec.Body.String())
+			require.NoError(t, openapi.ValidateResponse(input, rec.Code, rec.Header(), rec.Body.Bytes()))
+		})
+	}
+}
+
+func TestValidate_RejectsRequestsOutsideTheContract(t *testing.T) {
+	router := newRouter(t, &fakeAdmin{})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"update without chats", http.MethodPost, "/updates", `{"id":1,"url":"https://github.com/a/b"}`},
+		{"update with string id", http.MethodPost, "/updates",
+			`{"id":"1","url":"https://github.com/a/b","tgChatIds":[1]}`},
+		{"event without timestamp", http.MethodPost, "/updates",
+			`{"id":1,"url":"u","tgChatIds":[1],"events":[{"provider":"github","type":"issue","itemId":"1","url":"u"}]}`},
+		{"limit out of range", http.MethodGet, "/admin/dlq?limit=1000", ""},
+		{"unknown topic", http.MethodGet, "/admin/dlq?topic=telegram", ""},
+		{"replay nothing", http.MethodPost, "/admin/dlq/replay", `{"messages":[]}`},
+		{"replay negative offset", http.MethodPost, "/admin/dlq/replay",
+			`{"messages":[{"partition":0,"offset":-1}]}`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, newRequest(tc.method, tc.path, adminToken, tc.body))
+
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+
+			var resp botModel.APIErrorResponse
+
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			require.Equal(t, "ValidationError", resp.ExceptionName)
+		})
+	}
+}
+
+// TestValidate_AuthenticatesFirst checks that the contract is only checked
+// for authenticated callers.
+func TestValidate_AuthenticatesFirst(t *testing.T) {
+	router := newRouter(t, &fakeAdmin{})
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"without token", "wrong", http.StatusUnauthorized},
+		{"with token", adminToken, http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, newRequest(http.MethodPost, "/admin/dlq/replay", tc.token, `{"messages":[]}`))
+
+			require.Equal(t, tc.status, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestHandler_ServesDocument(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRouter(t, &fakeAdmin{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", http.NoBody))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var doc struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&doc))
+	require.Equal(t, "3.0.3", doc.OpenAPI)
+	require.Contains(t, doc.Paths, "/updates")
+	require.Contains(t, doc.Paths, "/admin/dlq")
+}
+
+func newRequest(method, path, token, body string) *http.Request {
+	var reader io.Reader = http.NoBody
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	request := httptest.NewRequest(method, path, reader)
+
+	if body != "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return request
+}
@@ -0,0 +1,58 @@
+// Package openapi holds the OpenAPI document of the bot HTTP API, serves
+// it and checks requests and responses against it.
+package openapi
+
+import (
+	"bot/utils"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/go-chi/render"
+	pkgopenapi "pkg/openapi"
+
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+// Load parses and validates the embedded document.
+func Load() (*openapi3.T, error) {
+	const op = "openapi.Load"
+
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return doc, nil
+}
+
+// Handler serves the document as JSON at /openapi.json.
+func Handler(doc *openapi3.T) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		render.JSON(writer, request, doc)
+	}
+}
+
+// Validate rejects requests that break the contract with 400 and an
+// APIErrorResponse, see package pkg/openapi.
+func Validate(log *slog.Logger, doc *openapi3.T) (func(http.Handler) http.Handler, error) {
+	return pkgopenapi.Validate(log, doc, func(writer http.ResponseWriter, err error) {
+		utils.RespondWithError(writer, http.StatusBadRequest, "request does not match the API contract",
+			"BadRequest", "ValidationError", err.Error())
+	})
+}
+
+// ValidateResponse checks a response to the request in input.
+func ValidateResponse(input *openapi3filter.RequestValidationInput, status int, header http.Header,
+	body []byte) error {
+	return pkgopenapi.ValidateResponse(input, status, header, body)
+}
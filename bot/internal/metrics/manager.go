@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	NotificationDelivered = "delivered"
+	NotificationFailed    = "failed"
+
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// MetricManager holds the bot's metrics. A nil manager records nothing, so
+// the parts of the bot can be used without one in tests.
+type MetricManager struct {
+	commands        *prometheus.CounterVec
+	notifications   *prometheus.CounterVec
+	telegramLatency *prometheus.HistogramVec
+	cache           *prometheus.CounterVec
+}
+
+func NewMetricManager() *MetricManager {
+	return newMetricManager(prometheus.DefaultRegisterer)
+}
+
+func newMetricManager(registerer prometheus.Registerer) *MetricManager {
+	commands := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "myapp",
+			Name:      "bot_commands_total",
+			Help:      "Telegram commands received",
+		},
+		[]string{"command"},
+	)
+
+	notifications := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "myapp",
+			Name:      "bot_notifications_total",
+			Help:      "Link updates sent to chats by result",
+		},
+		[]string{"result"},
+	)
+
+	telegramLatency := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "myapp",
+			Name:      "telegram_request_duration_seconds",
+			Help:      "Duration of Telegram Bot API calls",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method"},
+	)
+
+	cache := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "myapp",
+			Name:      "cache_requests_total",
+			Help:      "Redis cache lookups by result",
+		},
+		[]string{"cache", "result"},
+	)
+
+	registerer.MustRegister(commands)
+	registerer.MustRegister(notifications)
+	registerer.MustRegister(telegramLatency)
+	registerer.MustRegister(cache)
+
+	return &MetricManager{commands: commands, notifications: notifications,
+		telegramLatency: telegramLatency, cache: cache}
+}
+
+func (m *MetricManager) IncCommand(command string) {
+	if m == nil {
+		return
+	}
+
+	m.commands.WithLabelValues(command).Inc()
+}
+
+// IncNotification counts a message sent to one chat, result is
+// NotificationDelivered or NotificationFailed.
+func (m *MetricManager) IncNotification(result string) {
+	if m == nil {
+		return
+	}
+
+	m.notifications.WithLabelValues(result).Inc()
+}
+
+func (m *MetricManager) ObserveTelegramCall(method string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.telegramLatency.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+// IncCache counts a lookup in the named cache, result is CacheHit or
+// CacheMiss.
+func (m *MetricManager) IncCache(cache, result string) {
+	if m == nil {
+		return
+	}
+
+	m.cache.WithLabelValues(cache, result).Inc()
+}
+
+// TelegramTransport wraps next, or the default transport when next is nil,
+// timing every Bot API call by its method.
+func (m *MetricManager) TelegramTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		m.ObserveTelegramCall(telegramMethod(req), time.Since(start))
+
+		return resp, err
+	})
+}
+
+// telegramMethod keeps the method of /bot<token>/<method> and never the
+// token or file paths.
+func telegramMethod(req *http.Request) string {
+	if strings.HasPrefix(req.URL.Path, "/file/") {
+		return "file"
+	}
+
+	return path.Base(req.URL.Path)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
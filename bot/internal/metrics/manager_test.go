@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetricManager_Counters(t *testing.T) {
+	m := newMetricManager(prometheus.NewRegistry())
+
+	m.IncCommand("/list")
+	m.IncCommand("/list")
+	m.IncNotification(NotificationDelivered)
+	m.IncNotification(NotificationFailed)
+	m.IncCache("links", CacheHit)
+	m.IncCache("links", CacheMiss)
+	m.IncCache("links", CacheMiss)
+
+	require.InDelta(t, 2, testutil.ToFloat64(m.commands.WithLabelValues("/list")), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(m.notifications.WithLabelValues(NotificationDelivered)), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(m.notifications.WithLabelValues(NotificationFailed)), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(m.cache.WithLabelValues("links", CacheHit)), 0)
+	require.InDelta(t, 2, testutil.ToFloat64(m.cache.WithLabelValues("links", CacheMiss)), 0)
+}
+
+func TestMetricManager_Nil(t *testing.T) {
+	var m *MetricManager
+
+	require.NotPanics(t, func() {
+		m.IncCommand("/start")
+		m.IncNotification(NotificationDelivered)
+		m.IncCache("links", CacheHit)
+	})
+}
+
+func TestMetricManager_TelegramTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	client := &http.Client{Transport: newMetricManager(registry).TelegramTransport(nil)}
+
+	for _, path := range []string{"/bot123:secret/sendMessage", "/bot123:secret/sendMessage", "/file/bot123:secret/a.jpg"} {
+		resp, err := client.Get(server.URL + path)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	calls := make(map[string]uint64)
+
+	for _, family := range families {
+		if family.GetName() != "myapp_telegram_request_duration_seconds" {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			calls[metric.GetLabel()[0].GetValue()] = metric.GetHistogram().GetSampleCount()
+		}
+	}
+
+	require.Equal(t, map[string]uint64{"sendMessage": 2, "file": 1}, calls)
+}
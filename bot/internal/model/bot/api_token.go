@@ -0,0 +1,20 @@
+package bot
+
+// Scopes of a public API token.
+const (
+	ScopeLinksRead  = "links:read"
+	ScopeLinksWrite = "links:write"
+)
+
+type IssueTokenRequest struct {
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+type IssueTokenResponse struct {
+	Token  string   `json:"token"`
+	Scopes []string `json:"scopes"`
+}
+
+type RevokeTokensResponse struct {
+	Revoked int64 `json:"revoked"`
+}
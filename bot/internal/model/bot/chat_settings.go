@@ -0,0 +1,6 @@
+package bot
+
+type ChatSettings struct {
+	MembersCanManage bool   `json:"membersCanManage"`
+	Language         string `json:"language,omitempty"`
+}
@@ -0,0 +1,39 @@
+package bot
+
+// DeadLetter is a message parked in the bot's dead letter topic together with
+// the reason the consumer gave up on it.
+type DeadLetter struct {
+	Partition      int32  `json:"partition"`
+	Offset         int64  `json:"offset"`
+	Key            string `json:"key,omitempty"`
+	EventID        string `json:"eventId,omitempty"`
+	Reason         string `json:"reason"`
+	Error          string `json:"error"`
+	OriginalTopic  string `json:"originalTopic"`
+	OriginalOffset string `json:"originalOffset,omitempty"`
+	Attempts       string `json:"attempts,omitempty"`
+	FailedAt       string `json:"failedAt,omitempty"`
+	Payload        string `json:"payload"`
+}
+
+type DeadLetterPosition struct {
+	Partition int32 `json:"partition"`
+	Offset    int64 `json:"offset" validate:"gte=0"`
+}
+
+type ReplayRequest struct {
+	Messages []DeadLetterPosition `json:"messages" validate:"required,min=1,dive"`
+}
+
+type ReplayResponse struct {
+	Replayed int `json:"replayed"`
+}
+
+// PurgeRequest names, per partition, the last message to delete.
+type PurgeRequest struct {
+	Through []DeadLetterPosition `json:"through" validate:"required,min=1,dive"`
+}
+
+type PurgeResponse struct {
+	Purged int64 `json:"purged"`
+}
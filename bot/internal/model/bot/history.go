@@ -0,0 +1,22 @@
+package bot
+
+import "time"
+
+// HistoryEntry is an update the scraper sent to the chat. URL is the tracked
+// link, ItemURL the issue, answer or comment the update is about.
+type HistoryEntry struct {
+	ID        int64     `json:"id"`
+	LinkID    int64     `json:"linkId"`
+	URL       string    `json:"url"`
+	Tags      []string  `json:"tags"`
+	Provider  string    `json:"provider"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	ItemURL   string    `json:"itemUrl"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type HistoryResponse struct {
+	Updates []HistoryEntry `json:"updates"`
+	Size    int            `json:"size"`
+}
@@ -0,0 +1,9 @@
+package bot
+
+import "time"
+
+type InactiveChat struct {
+	ChatID     int64     `json:"chatId"`
+	Reason     string    `json:"reason"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
@@ -1,8 +1,26 @@
 package bot
 
+import "time"
+
 type Link struct {
-	ID      int64    `json:"id"`
-	URL     string   `json:"url"`
-	Tags    []string `json:"tags"`
-	Filters []string `json:"filters"`
+	ID      int64       `json:"id"`
+	URL     string      `json:"url"`
+	Tags    []string    `json:"tags"`
+	Filters []string    `json:"filters"`
+	Health  *LinkHealth `json:"health,omitempty"`
+}
+
+// LinkHealth is how polling the link went lately, as reported by the scraper.
+type LinkHealth struct {
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	LastStatus  int        `json:"lastStatus,omitempty"`
+	Failures    int        `json:"failures"`
+	Broken      bool       `json:"broken"`
+}
+
+// Healthy reports whether the last poll of the link succeeded. Links from
+// scrapers that do not report health are treated as healthy.
+func (l *Link) Healthy() bool {
+	return l.Health == nil || (l.Health.Failures == 0 && !l.Health.Broken)
 }
@@ -0,0 +1,29 @@
+package bot
+
+import "time"
+
+// LinkUpdateSchemaVersion is the newest LinkEvent schema the bot can render.
+const LinkUpdateSchemaVersion = 1
+
+// EventLinkRemoved means the scraper stopped tracking the link; Reason is
+// one of the Removal* constants.
+const EventLinkRemoved = "link.removed"
+
+const (
+	RemovalUnsupported = "unsupported"
+	RemovalNotFound    = "not_found"
+	RemovalDeleted     = "deleted"
+	RemovalAccessLost  = "access_lost"
+)
+
+type LinkEvent struct {
+	Provider  string    `json:"provider"`
+	Type      string    `json:"type"`
+	ItemID    string    `json:"itemId"`
+	Title     string    `json:"title,omitempty"`
+	Author    string    `json:"author,omitempty"`
+	URL       string    `json:"url"`
+	Timestamp time.Time `json:"timestamp"`
+	Excerpt   string    `json:"excerpt,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+}
@@ -1,8 +1,21 @@
 package bot
 
 type LinkUpdate struct {
-	ID          int64   `json:"id" validate:"required"`
-	URL         string  `json:"url" validate:"required"`
-	Description string  `json:"description"`
-	TgChatIDs   []int64 `json:"tgChatIds" validate:"required"`
+	ID            int64       `json:"id" validate:"required"`
+	EventID       string      `json:"eventId,omitempty"`
+	URL           string      `json:"url" validate:"required"`
+	SchemaVersion int         `json:"schemaVersion,omitempty"`
+	Events        []LinkEvent `json:"events,omitempty"`
+	TgChatIDs     []int64     `json:"tgChatIds" validate:"required"`
+}
+
+// Removal returns the link.removed event if the update carries one.
+func (u *LinkUpdate) Removal() (LinkEvent, bool) {
+	for _, event := range u.Events {
+		if event.Type == EventLinkRemoved {
+			return event, true
+		}
+	}
+
+	return LinkEvent{}, false
 }
@@ -1,10 +1,11 @@
 package bot
 
 type UserState struct {
-	Step    string
-	Link    string
-	Tags    []string
-	Filters []string
+	Step    string   `json:"step"`
+	UserID  int64    `json:"user_id"`
+	Link    string   `json:"link,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Filters []string `json:"filters,omitempty"`
 }
 
 type UserData struct {
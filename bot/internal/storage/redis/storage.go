@@ -7,16 +7,42 @@ import (
 	"fmt"
 	"time"
 
+	"bot/internal/metrics"
 	"bot/internal/model/bot"
 	"github.com/gomodule/redigo/redis"
 )
 
+const (
+	settingsPrefix = "settings:"
+	inactivePrefix = "inactive:"
+	eventPrefix    = "event:"
+	statePrefix    = "state:"
+
+	// eventTTL bounds how long handled event ids are remembered; Kafka
+	// redeliveries and copies sent over another transport arrive within
+	// minutes, so a day is plenty.
+	eventTTL = 24 * time.Hour
+
+	// linksTTL makes cached links, and the health shown by /list, expire
+	// even when nothing in the chat changes.
+	linksTTL = 5 * time.Minute
+
+	// stateTTL forgets a /track dialog the user walked away from.
+	stateTTL = 30 * time.Minute
+
+	cacheLinks    = "links"
+	cacheSettings = "settings"
+	cacheState    = "state"
+)
+
 type Storage struct {
-	pool *redis.Pool
+	pool    *redis.Pool
+	metrics *metrics.MetricManager
 }
 
-func New(address string, maxIdle, maxActive int) *Storage {
+func New(address string, maxIdle, maxActive int, metrics *metrics.MetricManager) *Storage {
 	return &Storage{
+		metrics: metrics,
 		pool: &redis.Pool{
 			MaxIdle:     maxIdle,
 			MaxActive:   maxActive,
@@ -36,6 +62,18 @@ func (r *Storage) Close() error {
 	return r.pool.Close()
 }
 
+func (r *Storage) Ping(ctx context.Context) error {
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = redis.DoContext(conn, ctx, "PING")
+
+	return err
+}
+
 func (r *Storage) SetLinks(ctx context.Context, key string, links []bot.Link) error {
 	const op = "storage.redis.SetLinks"
 
@@ -50,7 +88,7 @@ func (r *Storage) SetLinks(ctx context.Context, key string, links []bot.Link) er
 	}
 	defer conn.Close()
 
-	if _, err = conn.Do("SET", key, data); err != nil {
+	if _, err = conn.Do("SET", key, data, "EX", int(linksTTL.Seconds())); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -67,6 +105,8 @@ func (r *Storage) GetLinks(ctx context.Context, key string) ([]bot.Link, error)
 	defer conn.Close()
 
 	raw, err := redis.Bytes(conn.Do("GET", key))
+	r.countLookup(cacheLinks, err)
+
 	if err != nil {
 		if errors.Is(err, redis.ErrNil) {
 			return nil, redis.ErrNil
@@ -83,3 +123,204 @@ func (r *Storage) GetLinks(ctx context.Context, key string) ([]bot.Link, error)
 
 	return links, nil
 }
+
+func (r *Storage) DeleteLinks(ctx context.Context, key string) error {
+	const op = "storage.redis.DeleteLinks"
+
+	if err := r.del(ctx, key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Storage) SetSettings(ctx context.Context, key string, settings *bot.ChatSettings) error {
+	const op = "storage.redis.SetSettings"
+
+	if err := r.setJSON(ctx, settingsPrefix+key, settings, 0); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Storage) GetSettings(ctx context.Context, key string) (*bot.ChatSettings, error) {
+	const op = "storage.redis.GetSettings"
+
+	var settings bot.ChatSettings
+
+	if err := r.getJSON(ctx, cacheSettings, settingsPrefix+key, &settings); err != nil {
+		if errors.Is(err, redis.ErrNil) {
+			return nil, redis.ErrNil
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &settings, nil
+}
+
+func (r *Storage) DeleteSettings(ctx context.Context, key string) error {
+	const op = "storage.redis.DeleteSettings"
+
+	if err := r.del(ctx, settingsPrefix+key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Storage) SetInactiveChat(ctx context.Context, key string, chat *bot.InactiveChat) error {
+	const op = "storage.redis.SetInactiveChat"
+
+	if err := r.setJSON(ctx, inactivePrefix+key, chat, 0); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Storage) DeleteInactiveChat(ctx context.Context, key string) error {
+	const op = "storage.redis.DeleteInactiveChat"
+
+	if err := r.del(ctx, inactivePrefix+key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetState saves the /track dialog of a chat, so that any replica can take
+// the next message of it. The dialog expires after stateTTL.
+func (r *Storage) SetState(ctx context.Context, key string, state *bot.UserState) error {
+	const op = "storage.redis.SetState"
+
+	if err := r.setJSON(ctx, statePrefix+key, state, stateTTL); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Storage) GetState(ctx context.Context, key string) (*bot.UserState, error) {
+	const op = "storage.redis.GetState"
+
+	var state bot.UserState
+
+	if err := r.getJSON(ctx, cacheState, statePrefix+key, &state); err != nil {
+		if errors.Is(err, redis.ErrNil) {
+			return nil, redis.ErrNil
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &state, nil
+}
+
+func (r *Storage) DeleteState(ctx context.Context, key string) error {
+	const op = "storage.redis.DeleteState"
+
+	if err := r.del(ctx, statePrefix+key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// setJSON stores value under key; a positive ttl makes it expire.
+func (r *Storage) setJSON(ctx context.Context, key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if ttl > 0 {
+		_, err = conn.Do("SET", key, data, "EX", int(ttl.Seconds()))
+	} else {
+		_, err = conn.Do("SET", key, data)
+	}
+
+	return err
+}
+
+func (r *Storage) getJSON(ctx context.Context, cache, key string, value any) error {
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	raw, err := redis.Bytes(conn.Do("GET", key))
+	r.countLookup(cache, err)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, value)
+}
+
+// countLookup counts a GET as a hit or a miss; failed lookups are neither.
+func (r *Storage) countLookup(cache string, err error) {
+	switch {
+	case err == nil:
+		r.metrics.IncCache(cache, metrics.CacheHit)
+	case errors.Is(err, redis.ErrNil):
+		r.metrics.IncCache(cache, metrics.CacheMiss)
+	}
+}
+
+func (r *Storage) del(ctx context.Context, key string) error {
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Do("DEL", key)
+
+	return err
+}
+
+// ClaimEvent remembers eventID for eventTTL and reports whether it was new.
+// The check and the write are a single SET NX, so of two replicas handling
+// the same event at once only one claims it.
+func (r *Storage) ClaimEvent(ctx context.Context, eventID string) (bool, error) {
+	const op = "storage.redis.ClaimEvent"
+
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.Close()
+
+	_, err = redis.String(conn.Do("SET", eventPrefix+eventID, 1, "NX", "EX", int(eventTTL.Seconds())))
+
+	switch {
+	case errors.Is(err, redis.ErrNil):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("%s: %w", op, err)
+	default:
+		return true, nil
+	}
+}
+
+// ReleaseEvent forgets a claimed eventID, so that the event can be handled
+// again, e.g. after its delivery failed.
+func (r *Storage) ReleaseEvent(ctx context.Context, eventID string) error {
+	const op = "storage.redis.ReleaseEvent"
+
+	if err := r.del(ctx, eventPrefix+eventID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
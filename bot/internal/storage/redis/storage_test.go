@@ -42,7 +42,7 @@ func TestRedisStorage(t *testing.T) {
 
 	address := fmt.Sprintf("%s:%s", host, port.Port())
 
-	store := redisStorage.New(address, 5, 1)
+	store := redisStorage.New(address, 5, 1, nil)
 	defer store.Close()
 
 	t.Run("Set and Get Links", func(t *testing.T) {
@@ -73,6 +73,73 @@ func TestRedisStorage(t *testing.T) {
 		require.Equal(t, want, got)
 	})
 
+	t.Run("Set, Get and Delete Settings", func(t *testing.T) {
+		key := fmt.Sprintf("chat-%d", time.Now().UnixNano())
+		want := &bot.ChatSettings{MembersCanManage: true}
+
+		err := store.SetSettings(ctx, key, want)
+
+		require.NoError(t, err)
+
+		got, err := store.GetSettings(ctx, key)
+
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+
+		err = store.DeleteSettings(ctx, key)
+
+		require.NoError(t, err)
+
+		_, err = store.GetSettings(ctx, key)
+
+		require.ErrorIs(t, err, redis.ErrNil)
+	})
+
+	t.Run("Set, Get and Delete State", func(t *testing.T) {
+		key := fmt.Sprintf("chat-%d", time.Now().UnixNano())
+		want := &bot.UserState{Step: "waiting_for_tags", UserID: 7, Link: "https://github.com/a/b"}
+
+		err := store.SetState(ctx, key, want)
+
+		require.NoError(t, err)
+
+		got, err := store.GetState(ctx, key)
+
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+
+		err = store.DeleteState(ctx, key)
+
+		require.NoError(t, err)
+
+		_, err = store.GetState(ctx, key)
+
+		require.ErrorIs(t, err, redis.ErrNil)
+	})
+
+	t.Run("Claim and release events", func(t *testing.T) {
+		eventID := fmt.Sprintf("event-%d", time.Now().UnixNano())
+
+		claimed, err := store.ClaimEvent(ctx, eventID)
+
+		require.NoError(t, err)
+		require.True(t, claimed)
+
+		claimed, err = store.ClaimEvent(ctx, eventID)
+
+		require.NoError(t, err)
+		require.False(t, claimed)
+
+		err = store.ReleaseEvent(ctx, eventID)
+
+		require.NoError(t, err)
+
+		claimed, err = store.ClaimEvent(ctx, eventID)
+
+		require.NoError(t, err)
+		require.True(t, claimed)
+	})
+
 	t.Run("GetLinks on missing key returns ErrNil", func(t *testing.T) {
 		key := "nonexistent-key"
 		got, err := store.GetLinks(ctx, key)
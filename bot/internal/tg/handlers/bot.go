@@ -1,23 +1,79 @@
 package handlers
 
 import (
+	"bot/internal/metrics"
 	"bot/internal/model/bot"
+	"pkg/render"
 
 	"gopkg.in/telebot.v3"
 
 	"context"
 	"log/slog"
+	"strings"
 )
 
 type UseCase interface {
 	AddLink(ctx context.Context, link bot.AddLinkRequest, id int64) (*bot.Link, error)
 	GetLinks(ctx context.Context, userID int64) (*bot.ListLinkResponse, error)
+	RefreshLinks(ctx context.Context, userID int64) (*bot.ListLinkResponse, error)
 	DeleteLink(ctx context.Context, link bot.RemoveLinkRequest, id int64) (*bot.Link, error)
 	RegisterChat(ctx context.Context, id int64) error
+	DeleteChat(ctx context.Context, id int64) error
+	MigrateChat(ctx context.Context, from, to int64) error
+	GetSettings(ctx context.Context, id int64) (*bot.ChatSettings, error)
+	SetSettings(ctx context.Context, id int64, settings *bot.ChatSettings) error
+	IssueToken(ctx context.Context, id int64, scopes []string) (*bot.IssueTokenResponse, error)
+	RevokeTokens(ctx context.Context, id int64) (int64, error)
+	GetHistory(ctx context.Context, id int64, link string, limit int) ([]bot.HistoryEntry, error)
+	GetTrackState(ctx context.Context, id int64) (*bot.UserState, error)
+	SetTrackState(ctx context.Context, id int64, state *bot.UserState) error
+	ClearTrackState(ctx context.Context, id int64) error
+}
+
+type Sender interface {
+	Send(ctx context.Context, chatID int64, text string, opts ...interface{}) error
+}
+
+type Renderer interface {
+	Render(digest render.Digest) (string, error)
+	Format() render.Format
 }
 
 type Bot struct {
-	Handler *telebot.Bot
-	Logger  *slog.Logger
-	States  map[int64]*bot.UserState
+	Handler       *telebot.Bot
+	Sender        Sender
+	Renderer      Renderer
+	Logger        *slog.Logger
+	MetricManager *metrics.MetricManager
+}
+
+// Handle registers h for the endpoint like telebot.Bot.Handle and counts the
+// uses of commands.
+func (bot *Bot) Handle(endpoint string, h telebot.HandlerFunc, m ...telebot.MiddlewareFunc) {
+	if strings.HasPrefix(endpoint, "/") {
+		m = append([]telebot.MiddlewareFunc{bot.countCommand(endpoint)}, m...)
+	}
+
+	bot.Handler.Handle(endpoint, h, m...)
+}
+
+func (bot *Bot) countCommand(command string) telebot.MiddlewareFunc {
+	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(c telebot.Context) error {
+			bot.MetricManager.IncCommand(command)
+			return next(c)
+		}
+	}
+}
+
+func senderID(c telebot.Context) int64 {
+	if c.Sender() == nil {
+		return 0
+	}
+
+	return c.Sender().ID
+}
+
+func isGroup(chat *telebot.Chat) bool {
+	return chat != nil && (chat.Type == telebot.ChatGroup || chat.Type == telebot.ChatSuperGroup)
 }
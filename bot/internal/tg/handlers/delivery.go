@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DeliveryError lists the chats an update could not be delivered to, so that
+// a retry can skip the chats that already got it.
+type DeliveryError struct {
+	ChatIDs []int64
+	Errs    []error
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("failed to deliver to %d chats: %v", len(e.ChatIDs), errors.Join(e.Errs...))
+}
+
+func (e *DeliveryError) Unwrap() []error {
+	return e.Errs
+}
+
+func (e *DeliveryError) FailedChats() []int64 {
+	return e.ChatIDs
+}
+
+func (e *DeliveryError) add(chatID int64, err error) {
+	e.ChatIDs = append(e.ChatIDs, chatID)
+	e.Errs = append(e.Errs, fmt.Errorf("chat %d: %w", chatID, err))
+}
+
+func (e *DeliveryError) merge(other *DeliveryError) {
+	e.ChatIDs = append(e.ChatIDs, other.ChatIDs...)
+	e.Errs = append(e.Errs, other.Errs...)
+}
+
+func (e *DeliveryError) orNil() error {
+	if len(e.ChatIDs) == 0 {
+		return nil
+	}
+
+	return e
+}
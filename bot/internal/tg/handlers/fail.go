@@ -1,21 +1,30 @@
 package handlers
 
 import (
-	"bot/internal/model/bot"
-	"fmt"
+	botmodel "bot/internal/model/bot"
+	"pkg/i18n"
 
-	"gopkg.in/telebot.v3"
+	"context"
 )
 
-func (bot *Bot) FailHandler(info bot.LinkUpdate) error {
-	message := fmt.Sprintf("URL: %s\n недоступен. Удаляю из списка отслеживаемых\n", info.URL)
+var removalReasons = map[string]string{
+	botmodel.RemovalUnsupported: i18n.KeyRemovalUnsupported,
+	botmodel.RemovalNotFound:    i18n.KeyRemovalNotFound,
+	botmodel.RemovalDeleted:     i18n.KeyRemovalDeleted,
+	botmodel.RemovalAccessLost:  i18n.KeyRemovalAccessLost,
+}
+
+// FailHandler tells the chats that the link is no longer tracked and why.
+func (bot *Bot) FailHandler(ctx context.Context, info botmodel.LinkUpdate,
+	langs map[int64]i18n.Lang) ([]botmodel.InactiveChat, error) {
+	removal, _ := info.Removal()
+	reasonKey, known := removalReasons[removal.Reason]
 
-	for _, chatID := range info.TgChatIDs {
-		_, err := bot.Handler.Send(telebot.ChatID(chatID), message)
-		if err != nil {
-			return err
+	return bot.broadcastLocalized(ctx, info.TgChatIDs, langs, func(lang i18n.Lang) (string, []interface{}) {
+		if !known {
+			return i18n.T(lang, i18n.KeyLinkUnavailable, info.URL), nil
 		}
-	}
 
-	return nil
+		return i18n.T(lang, i18n.KeyLinkRemoved, info.URL, i18n.T(lang, reasonKey)), nil
+	})
 }
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"pkg/i18n"
+
+	"gopkg.in/telebot.v3"
+
+	"context"
+	"log/slog"
+)
+
+func (bot *Bot) AdminOnly(ctx context.Context, uc UseCase) telebot.MiddlewareFunc {
+	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(c telebot.Context) error {
+			if !isGroup(c.Chat()) {
+				return next(c)
+			}
+
+			settings, err := uc.GetSettings(ctx, c.Chat().ID)
+			if err == nil && settings.MembersCanManage {
+				return next(c)
+			}
+
+			isAdmin, err := bot.isAdmin(c)
+			if err != nil {
+				bot.Logger.Error("failed to check chat member", slog.String("error", err.Error()))
+				return err
+			}
+
+			if !isAdmin {
+				return c.Send(tr(c, i18n.KeyAdminOnly))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func (bot *Bot) isAdmin(c telebot.Context) (bool, error) {
+	// Anonymous group admins post on behalf of the group itself.
+	if msg := c.Message(); msg != nil && msg.SenderChat != nil && msg.SenderChat.ID == c.Chat().ID {
+		return true, nil
+	}
+
+	if c.Sender() == nil {
+		return false, nil
+	}
+
+	member, err := bot.Handler.ChatMemberOf(c.Chat(), c.Sender())
+	if err != nil {
+		return false, err
+	}
+
+	return member.Role == telebot.Creator || member.Role == telebot.Administrator, nil
+}
+
+func (bot *Bot) ChatMemberHandler(ctx context.Context, uc UseCase) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		upd := c.ChatMember()
+		if upd == nil || upd.Chat == nil || upd.NewChatMember == nil {
+			return nil
+		}
+
+		log := bot.Logger.With(
+			slog.Int64("chat_id", upd.Chat.ID),
+			slog.String("status", string(upd.NewChatMember.Role)),
+		)
+
+		if isLeft(upd.NewChatMember) {
+			log.Info("bot removed from chat")
+
+			return uc.DeleteChat(ctx, upd.Chat.ID)
+		}
+
+		if upd.Chat.Type != telebot.ChatPrivate && (upd.OldChatMember == nil || isLeft(upd.OldChatMember)) {
+			log.Info("bot added to chat")
+
+			return uc.RegisterChat(ctx, upd.Chat.ID)
+		}
+
+		return nil
+	}
+}
+
+func (bot *Bot) MigrationHandler(ctx context.Context, uc UseCase) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		from, to := c.Migration()
+
+		bot.Logger.Info("chat migrated to supergroup", slog.Int64("from", from), slog.Int64("to", to))
+
+		return uc.MigrateChat(ctx, from, to)
+	}
+}
+
+// ChannelPostHandler feeds channel posts back through the regular message
+// routing, since telebot only dispatches commands for ordinary messages.
+func (bot *Bot) ChannelPostHandler(c telebot.Context) error {
+	post := c.Message()
+	if post == nil || post.Text == "" {
+		return nil
+	}
+
+	bot.Handler.ProcessUpdate(telebot.Update{ID: c.Update().ID, Message: post})
+
+	return nil
+}
+
+func isLeft(member *telebot.ChatMember) bool {
+	return member.Role == telebot.Left || member.Role == telebot.Kicked
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	botmodel "bot/internal/model/bot"
+	"bot/utils"
+	"pkg/i18n"
+	"pkg/render"
+
+	"gopkg.in/telebot.v3"
+
+	"context"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultHistorySize = 10
+	maxHistorySize     = 50
+)
+
+// HistoryHandler shows the last updates sent about a tracked link:
+// "/history <link>" shows ten of them, "/history <link> <n>" up to fifty.
+func (bot *Bot) HistoryHandler(ctx context.Context, uc UseCase) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		args := c.Args()
+		if len(args) == 0 || len(args) > 2 {
+			return c.Send(tr(c, i18n.KeyHistoryUsage))
+		}
+
+		link, isValid := utils.ValidateLink(args[0])
+		if !isValid {
+			return c.Send(tr(c, i18n.KeyTrackInvalidLink))
+		}
+
+		size := defaultHistorySize
+
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return c.Send(tr(c, i18n.KeyHistoryUsage))
+			}
+
+			size = min(n, maxHistorySize)
+		}
+
+		entries, err := uc.GetHistory(ctx, c.Chat().ID, link, size)
+		if err != nil {
+			return c.Send(tr(c, i18n.KeyHistoryFailed))
+		}
+
+		if len(entries) == 0 {
+			return c.Send(tr(c, i18n.KeyHistoryEmpty))
+		}
+
+		return c.Send(historyMessage(c, link, entries), telebot.NoPreview)
+	}
+}
+
+func historyMessage(c telebot.Context, link string, entries []botmodel.HistoryEntry) string {
+	var msg strings.Builder
+
+	msg.WriteString(tr(c, i18n.KeyHistoryHeader, link))
+
+	layout := tr(c, i18n.KeyTimeDateLayout) + " 15:04"
+
+	for _, entry := range entries {
+		item := strings.TrimSpace(historyLabel(c, entry.Type) + " " + entry.Title)
+		msg.WriteString(tr(c, i18n.KeyHistoryItem, entry.Timestamp.UTC().Format(layout), item, entry.ItemURL))
+	}
+
+	return msg.String()
+}
+
+func historyLabel(c telebot.Context, eventType string) string {
+	switch eventType {
+	case render.EventGitHubPullRequest:
+		return tr(c, i18n.KeyRenderPullRequest)
+	case render.EventGitHubIssue:
+		return tr(c, i18n.KeyRenderIssue)
+	case render.EventStackOverflowAnswer:
+		return tr(c, i18n.KeyRenderAnswer)
+	case render.EventStackOverflowComment:
+		return tr(c, i18n.KeyRenderComment)
+	case botmodel.EventLinkRemoved:
+		return tr(c, i18n.KeyHistoryRemoved)
+	default:
+		return tr(c, i18n.KeyRenderUpdate)
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"bot/internal/model/bot"
+
+	"gopkg.in/telebot.v3"
+
+	"errors"
+	"time"
+)
+
+const (
+	ReasonBlocked     = "blocked"
+	ReasonDeactivated = "deactivated"
+	ReasonKicked      = "kicked"
+	ReasonNotFound    = "chat_not_found"
+	ReasonNotStarted  = "not_started"
+)
+
+var inactiveChatErrors = []struct {
+	err    error
+	reason string
+}{
+	{telebot.ErrBlockedByUser, ReasonBlocked},
+	{telebot.ErrUserIsDeactivated, ReasonDeactivated},
+	{telebot.ErrKickedFromGroup, ReasonKicked},
+	{telebot.ErrKickedFromSuperGroup, ReasonKicked},
+	{telebot.ErrKickedFromChannel, ReasonKicked},
+	{telebot.ErrNotChannelMember, ReasonKicked},
+	{telebot.ErrChatNotFound, ReasonNotFound},
+	{telebot.ErrNotStartedByUser, ReasonNotStarted},
+}
+
+// asInactiveChat reports whether a send error means the chat can never be
+// delivered to again, so its subscriptions should be dropped.
+func asInactiveChat(chatID int64, err error) (bot.InactiveChat, bool) {
+	for _, item := range inactiveChatErrors {
+		if errors.Is(err, item.err) {
+			return bot.InactiveChat{ChatID: chatID, Reason: item.reason, DetectedAt: time.Now()}, true
+		}
+	}
+
+	return bot.InactiveChat{}, false
+}
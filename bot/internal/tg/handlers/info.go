@@ -1,21 +1,144 @@
 package handlers
 
 import (
-	"bot/internal/model/bot"
-	"fmt"
+	"bot/internal/metrics"
+	botmodel "bot/internal/model/bot"
+	"bot/internal/tracing"
+	"context"
+	"log/slog"
+	"pkg/i18n"
+	"pkg/render"
+	"strings"
+	"sync"
 
 	"gopkg.in/telebot.v3"
 )
 
-func (bot *Bot) InfoHandler(info bot.LinkUpdate) error {
-	message := fmt.Sprintf("Произошло обновление по URL: %s\n Описание: %s\n", info.URL, info.Description)
+// InfoHandler sends the update to every chat in the chat's language; langs
+// holds the languages chosen with /lang, other chats get the default one.
+func (bot *Bot) InfoHandler(ctx context.Context, info botmodel.LinkUpdate,
+	langs map[int64]i18n.Lang) ([]botmodel.InactiveChat, error) {
+	ctx, span := tracing.Start(ctx, "Bot.InfoHandler", tracing.EventID(info.EventID))
 
-	for _, chatID := range info.TgChatIDs {
-		_, err := bot.Handler.Send(telebot.ChatID(chatID), message)
-		if err != nil {
-			return err
+	inactive, err := bot.broadcastLocalized(ctx, info.TgChatIDs, langs, func(lang i18n.Lang) (string, []interface{}) {
+		return bot.updateMessage(info, lang)
+	})
+	tracing.End(span, err)
+
+	return inactive, err
+}
+
+func (bot *Bot) updateMessage(info botmodel.LinkUpdate, lang i18n.Lang) (string, []interface{}) {
+	if bot.Renderer != nil && len(info.Events) > 0 && info.SchemaVersion <= botmodel.LinkUpdateSchemaVersion {
+		digest := digestOf(info)
+		digest.Lang = lang
+
+		message, err := bot.Renderer.Render(digest)
+		if err == nil {
+			return message, []interface{}{telebot.ParseMode(bot.Renderer.Format()), telebot.NoPreview}
+		}
+
+		bot.Logger.Warn("failed to render events, falling back to the link",
+			slog.Int64("id", info.ID), slog.String("error", err.Error()))
+	}
+
+	// Events of a newer schema or that fail to render still tell the chat
+	// which link changed.
+	return i18n.T(lang, i18n.KeyUpdateFallback, info.URL), nil
+}
+
+func digestOf(info botmodel.LinkUpdate) render.Digest {
+	digest := render.Digest{
+		Provider: info.Events[0].Provider,
+		Title:    info.URL,
+		URL:      info.URL,
+		Items:    make([]render.Item, 0, len(info.Events)),
+	}
+
+	if _, path, ok := strings.Cut(strings.TrimPrefix(info.URL, "https://"), "/"); ok {
+		digest.Title = path
+	}
+
+	for _, event := range info.Events {
+		digest.Items = append(digest.Items, render.Item{
+			ID:        event.ItemID,
+			Type:      event.Type,
+			Title:     event.Title,
+			URL:       event.URL,
+			Author:    event.Author,
+			UpdatedAt: event.Timestamp,
+			Body:      event.Excerpt,
+		})
+	}
+
+	return digest
+}
+
+func (bot *Bot) broadcastLocalized(ctx context.Context, chatIDs []int64, langs map[int64]i18n.Lang,
+	message func(lang i18n.Lang) (string, []interface{})) ([]botmodel.InactiveChat, error) {
+	byLang := make(map[i18n.Lang][]int64)
+
+	for _, chatID := range chatIDs {
+		lang, ok := langs[chatID]
+		if !ok {
+			lang = i18n.Default
 		}
+
+		byLang[lang] = append(byLang[lang], chatID)
+	}
+
+	var (
+		inactive []botmodel.InactiveChat
+		failed   DeliveryError
+	)
+
+	for lang, ids := range byLang {
+		text, opts := message(lang)
+
+		chats, err := bot.broadcast(ctx, ids, text, opts...)
+		inactive = append(inactive, chats...)
+		failed.merge(err)
+	}
+
+	return inactive, failed.orNil()
+}
+
+func (bot *Bot) broadcast(ctx context.Context, chatIDs []int64, message string, opts ...interface{}) ([]botmodel.InactiveChat,
+	*DeliveryError) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		inactive []botmodel.InactiveChat
+		failed   DeliveryError
+	)
+
+	for _, chatID := range chatIDs {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := bot.Sender.Send(ctx, chatID, message, opts...)
+			if err == nil {
+				bot.MetricManager.IncNotification(metrics.NotificationDelivered)
+				return
+			}
+
+			bot.MetricManager.IncNotification(metrics.NotificationFailed)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if chat, ok := asInactiveChat(chatID, err); ok {
+				inactive = append(inactive, chat)
+				return
+			}
+
+			failed.add(chatID, err)
+		}()
 	}
 
-	return nil
+	wg.Wait()
+
+	return inactive, &failed
 }
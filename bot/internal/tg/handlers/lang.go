@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"pkg/i18n"
+
+	"gopkg.in/telebot.v3"
+
+	"context"
+	"log/slog"
+	"strings"
+)
+
+const langKey = "lang"
+
+// Localize resolves the language of the chat once per update: the one chosen
+// with /lang if any, otherwise the Telegram client language of the sender.
+func (bot *Bot) Localize(ctx context.Context, uc UseCase) telebot.MiddlewareFunc {
+	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(c telebot.Context) error {
+			if chat := c.Chat(); chat != nil {
+				settings, err := uc.GetSettings(ctx, chat.ID)
+				if err != nil {
+					bot.Logger.Warn("failed to load chat language", slog.String("error", err.Error()))
+				} else if settings.Language != "" {
+					c.Set(langKey, i18n.Lang(settings.Language))
+					return next(c)
+				}
+			}
+
+			c.Set(langKey, senderLang(c))
+
+			return next(c)
+		}
+	}
+}
+
+func (bot *Bot) LangHandler(ctx context.Context, uc UseCase) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		args := c.Args()
+		if len(args) == 0 {
+			return c.Send(tr(c, i18n.KeyLangCurrent, langOf(c)) + "\n" + tr(c, i18n.KeyLangUsage, supportedLangs()))
+		}
+
+		lang, ok := i18n.Parse(args[0])
+		if !ok || len(args) != 1 {
+			return c.Send(tr(c, i18n.KeyLangUnsupported, args[0]) + "\n" + tr(c, i18n.KeyLangUsage, supportedLangs()))
+		}
+
+		settings, err := uc.GetSettings(ctx, c.Chat().ID)
+		if err != nil {
+			return c.Send(tr(c, i18n.KeySettingsLoadErr))
+		}
+
+		settings.Language = string(lang)
+
+		if err = uc.SetSettings(ctx, c.Chat().ID, settings); err != nil {
+			return c.Send(tr(c, i18n.KeySettingsSaveErr))
+		}
+
+		c.Set(langKey, lang)
+
+		return c.Send(tr(c, i18n.KeyLangChanged))
+	}
+}
+
+func senderLang(c telebot.Context) i18n.Lang {
+	if c.Sender() == nil {
+		return i18n.Default
+	}
+
+	lang, _ := i18n.Parse(c.Sender().LanguageCode)
+
+	return lang
+}
+
+func langOf(c telebot.Context) i18n.Lang {
+	if lang, ok := c.Get(langKey).(i18n.Lang); ok {
+		return lang
+	}
+
+	return senderLang(c)
+}
+
+func tr(c telebot.Context, key string, args ...any) string {
+	return i18n.T(langOf(c), key, args...)
+}
+
+func supportedLangs() string {
+	langs := make([]string, 0, len(i18n.Supported()))
+	for _, lang := range i18n.Supported() {
+		langs = append(langs, string(lang))
+	}
+
+	return strings.Join(langs, "|")
+}
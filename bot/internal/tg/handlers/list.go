@@ -2,27 +2,32 @@ package handlers
 
 import (
 	"context"
-	"fmt"
 	"gopkg.in/telebot.v3"
+	"pkg/i18n"
 )
 
 func (bot *Bot) ListHandler(ctx context.Context, uc UseCase) telebot.HandlerFunc {
 	return func(c telebot.Context) error {
-		userID := c.Sender().ID
+		chatID := c.Chat().ID
 
-		links, err := uc.GetLinks(ctx, userID)
+		links, err := uc.GetLinks(ctx, chatID)
 
 		if err != nil {
-			return c.Send("Для начала зарегестрируйся через /start")
+			return c.Send(tr(c, i18n.KeyListNotRegistered))
 		}
 
 		if len(links.Links) == 0 {
-			return c.Send("У вас пока нет отслеживаемых ссылок.")
+			return c.Send(tr(c, i18n.KeyListEmpty))
 		}
 
 		var response string
 		for _, link := range links.Links {
-			response += fmt.Sprintf("%s (Теги: %v)\n", link.URL, link.Tags)
+			if !link.Healthy() {
+				response += tr(c, i18n.KeyListItemFailing, link.URL, link.Tags)
+				continue
+			}
+
+			response += tr(c, i18n.KeyListItem, link.URL, link.Tags)
 		}
 
 		return c.Send(response)
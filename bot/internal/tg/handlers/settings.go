@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"bot/internal/model/bot"
+	"pkg/i18n"
+
+	"gopkg.in/telebot.v3"
+
+	"context"
+)
+
+func (bot *Bot) SettingsHandler(ctx context.Context, uc UseCase) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		chat := c.Chat()
+		if !isGroup(chat) {
+			return c.Send(tr(c, i18n.KeySettingsGroupOnly))
+		}
+
+		settings, err := uc.GetSettings(ctx, chat.ID)
+		if err != nil {
+			return c.Send(tr(c, i18n.KeySettingsLoadErr))
+		}
+
+		args := c.Args()
+		if len(args) == 0 {
+			return c.Send(describeSettings(c, settings))
+		}
+
+		isAdmin, err := bot.isAdmin(c)
+		if err != nil {
+			return err
+		}
+
+		if !isAdmin {
+			return c.Send(tr(c, i18n.KeySettingsAdminOnly))
+		}
+
+		if len(args) != 2 || args[0] != "members" {
+			return c.Send(tr(c, i18n.KeySettingsUsage))
+		}
+
+		switch args[1] {
+		case "on":
+			settings.MembersCanManage = true
+		case "off":
+			settings.MembersCanManage = false
+		default:
+			return c.Send(tr(c, i18n.KeySettingsUsage))
+		}
+
+		if err = uc.SetSettings(ctx, chat.ID, settings); err != nil {
+			return c.Send(tr(c, i18n.KeySettingsSaveErr))
+		}
+
+		return c.Send(describeSettings(c, settings))
+	}
+}
+
+func describeSettings(c telebot.Context, settings *bot.ChatSettings) string {
+	members := tr(c, i18n.KeySettingsAdmins)
+	if settings.MembersCanManage {
+		members = tr(c, i18n.KeySettingsEveryone)
+	}
+
+	return tr(c, i18n.KeySettingsSummary, members, tr(c, i18n.KeySettingsUsage))
+}
@@ -3,16 +3,49 @@ package handlers
 import (
 	"context"
 	"gopkg.in/telebot.v3"
+	"log/slog"
+	"pkg/i18n"
 )
 
 func (bot *Bot) StartHandler(ctx context.Context, uc UseCase) telebot.HandlerFunc {
 	return func(c telebot.Context) error {
-		err := uc.RegisterChat(ctx, c.Sender().ID)
+		err := uc.RegisterChat(ctx, c.Chat().ID)
+
+		bot.rememberLang(ctx, uc, c)
 
 		if err != nil {
-			return c.Send("Вы уже зарегестрированы. Пиши /help")
+			return c.Send(tr(c, i18n.KeyStartAlready))
 		}
 
-		return c.Send("Зарегестрирован. Пиши /help")
+		return c.Send(tr(c, i18n.KeyStartRegistered))
+	}
+}
+
+// rememberLang saves the Telegram client language of the sender as the
+// language of the chat, so that notifications, which have no sender to take
+// it from, speak it too. A language chosen with /lang is kept.
+func (bot *Bot) rememberLang(ctx context.Context, uc UseCase, c telebot.Context) {
+	if c.Sender() == nil {
+		return
+	}
+
+	lang, ok := i18n.Parse(c.Sender().LanguageCode)
+	if !ok {
+		return
 	}
+
+	settings, err := uc.GetSettings(ctx, c.Chat().ID)
+	if err != nil || settings.Language != "" {
+		return
+	}
+
+	settings.Language = string(lang)
+
+	if err = uc.SetSettings(ctx, c.Chat().ID, settings); err != nil {
+		bot.Logger.Warn("failed to save chat language", slog.Int64("chat_id", c.Chat().ID),
+			slog.String("error", err.Error()))
+		return
+	}
+
+	c.Set(langKey, lang)
 }
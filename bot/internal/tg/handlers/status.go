@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	botmodel "bot/internal/model/bot"
+	"bot/utils"
+	"context"
+	"pkg/i18n"
+	"strconv"
+
+	"gopkg.in/telebot.v3"
+)
+
+// StatusHandler shows how polling a tracked link went lately. The links are
+// fetched past the cache so that the health is current.
+func (bot *Bot) StatusHandler(ctx context.Context, uc UseCase) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		args := c.Args()
+		if len(args) == 0 {
+			return c.Send(tr(c, i18n.KeyStatusUsage))
+		}
+
+		link, isValid := utils.ValidateLink(args[0])
+		if !isValid {
+			return c.Send(tr(c, i18n.KeyTrackInvalidLink))
+		}
+
+		links, err := uc.RefreshLinks(ctx, c.Chat().ID)
+		if err != nil {
+			return c.Send(tr(c, i18n.KeyListNotRegistered))
+		}
+
+		for _, tracked := range links.Links {
+			if tracked.URL == link {
+				return c.Send(statusMessage(c, tracked), telebot.NoPreview)
+			}
+		}
+
+		return c.Send(tr(c, i18n.KeyStatusNotFound))
+	}
+}
+
+func statusMessage(c telebot.Context, link botmodel.Link) string {
+	health := botmodel.LinkHealth{}
+	if link.Health != nil {
+		health = *link.Health
+	}
+
+	state := tr(c, i18n.KeyStatusOK)
+
+	switch {
+	case health.Broken:
+		state = tr(c, i18n.KeyStatusBroken)
+	case health.Failures > 0:
+		state = tr(c, i18n.KeyStatusFailing)
+	}
+
+	lastSuccess := tr(c, i18n.KeyStatusNever)
+	if health.LastSuccess != nil {
+		lastSuccess = health.LastSuccess.UTC().Format(tr(c, i18n.KeyTimeDateLayout) + " 15:04 MST")
+	}
+
+	lastStatus := tr(c, i18n.KeyStatusUnknown)
+	if health.LastStatus != 0 {
+		lastStatus = strconv.Itoa(health.LastStatus)
+	}
+
+	lastError := tr(c, i18n.KeyStatusNoError)
+	if health.LastError != "" {
+		lastError = health.LastError
+	}
+
+	return tr(c, i18n.KeyStatusSummary, link.URL, state, lastSuccess, health.Failures, lastStatus, lastError)
+}
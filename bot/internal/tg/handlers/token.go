@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	botmodel "bot/internal/model/bot"
+	"pkg/i18n"
+
+	"gopkg.in/telebot.v3"
+
+	"context"
+	"strings"
+)
+
+const tokenReadOnly = "read"
+
+// TokenHandler issues a token for the public API. "/token" gives read and
+// write access to the chat's links, "/token read" only read access. Tokens
+// are only issued in private chats, the message can't be forwarded.
+func (bot *Bot) TokenHandler(ctx context.Context, uc UseCase) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		if c.Chat().Type != telebot.ChatPrivate {
+			return c.Send(tr(c, i18n.KeyTokenPrivateOnly))
+		}
+
+		var scopes []string
+
+		switch args := c.Args(); {
+		case len(args) == 0:
+		case len(args) == 1 && args[0] == tokenReadOnly:
+			scopes = []string{botmodel.ScopeLinksRead}
+		default:
+			return c.Send(tr(c, i18n.KeyTokenUsage))
+		}
+
+		issued, err := uc.IssueToken(ctx, c.Chat().ID, scopes)
+		if err != nil {
+			return c.Send(tr(c, i18n.KeyTokenFailed))
+		}
+
+		return c.Send(tr(c, i18n.KeyTokenIssued, issued.Token, strings.Join(issued.Scopes, ", ")), telebot.Protected,
+			telebot.NoPreview)
+	}
+}
+
+// RevokeHandler revokes every API token of the chat.
+func (bot *Bot) RevokeHandler(ctx context.Context, uc UseCase) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		revoked, err := uc.RevokeTokens(ctx, c.Chat().ID)
+		if err != nil {
+			return c.Send(tr(c, i18n.KeyRevokeFailed))
+		}
+
+		return c.Send(tr(c, i18n.KeyRevokeDone, revoked))
+	}
+}
@@ -2,11 +2,19 @@ package handlers
 
 import (
 	botModel "bot/internal/model/bot"
+	"context"
 	"gopkg.in/telebot.v3"
+	"pkg/i18n"
 )
 
-func (bot *Bot) TrackHandler(c telebot.Context) error {
-	bot.States[c.Sender().ID] = &botModel.UserState{Step: "waiting_for_link"}
+func (bot *Bot) TrackHandler(ctx context.Context, uc UseCase) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		state := &botModel.UserState{Step: "waiting_for_link", UserID: senderID(c)}
 
-	return c.Send("Отправьте ссылку для отслеживания\nПоддерживается:\nРепозитории GitHub\nВопрос из StackOverFlow")
+		if err := uc.SetTrackState(ctx, c.Chat().ID, state); err != nil {
+			return c.Send(tr(c, i18n.KeyTrackFailed))
+		}
+
+		return c.Send(tr(c, i18n.KeyTrackPrompt))
+	}
 }
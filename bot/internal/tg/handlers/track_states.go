@@ -3,23 +3,28 @@ package handlers
 import (
 	"bot/utils"
 	"context"
-	"fmt"
+	"log/slog"
 	"strings"
 
 	botmodel "bot/internal/model/bot"
 	"gopkg.in/telebot.v3"
+	"pkg/i18n"
 )
 
 func (bot *Bot) StatesHandler(ctx context.Context, uc UseCase) telebot.HandlerFunc {
 	return func(c telebot.Context) error {
-		userID := c.Sender().ID
-		state, exists := bot.States[userID]
+		chatID := c.Chat().ID
 
 		if c.Text() != "" && c.Text()[0] == '/' {
-			return c.Send("Неизвестная команда")
+			return c.Send(tr(c, i18n.KeyUnknownCommand))
 		}
 
-		if !exists {
+		state, err := uc.GetTrackState(ctx, chatID)
+		if err != nil {
+			return c.Send(tr(c, i18n.KeyTrackFailed))
+		}
+
+		if state == nil || state.UserID != senderID(c) {
 			return nil
 		}
 
@@ -27,25 +32,33 @@ func (bot *Bot) StatesHandler(ctx context.Context, uc UseCase) telebot.HandlerFu
 		case "waiting_for_link":
 			link, isValid := utils.ValidateLink(c.Text())
 			if !isValid {
-				return c.Send("Неверный формат ссылки")
+				return c.Send(tr(c, i18n.KeyTrackInvalidLink))
 			}
 
 			state.Link = link
 			state.Step = "waiting_for_tags"
 
-			return c.Send("Введите теги (через пробел) или отправьте 'пропустить'.")
+			if err = uc.SetTrackState(ctx, chatID, state); err != nil {
+				return c.Send(tr(c, i18n.KeyTrackFailed))
+			}
+
+			return c.Send(tr(c, i18n.KeyTrackTagsPrompt))
 
 		case "waiting_for_tags":
-			if c.Text() != "пропустить" {
+			if !strings.EqualFold(c.Text(), tr(c, i18n.KeyTrackSkipWord)) {
 				state.Tags = strings.Fields(c.Text())
 			}
 
 			state.Step = "waiting_for_filters"
 
-			return c.Send("Настройте фильтры (опционально) или отправьте 'пропустить'.")
+			if err = uc.SetTrackState(ctx, chatID, state); err != nil {
+				return c.Send(tr(c, i18n.KeyTrackFailed))
+			}
+
+			return c.Send(tr(c, i18n.KeyTrackFilterPrompt))
 
 		case "waiting_for_filters":
-			if c.Text() != "пропустить" {
+			if !strings.EqualFold(c.Text(), tr(c, i18n.KeyTrackSkipWord)) {
 				state.Filters = strings.Fields(c.Text())
 			}
 
@@ -55,14 +68,17 @@ func (bot *Bot) StatesHandler(ctx context.Context, uc UseCase) telebot.HandlerFu
 				Filters: state.Filters,
 			}
 
-			link, err := uc.AddLink(ctx, req, userID)
+			link, err := uc.AddLink(ctx, req, chatID)
 			if err != nil {
-				return c.Send("Ссылка уже была добавлена или вы забыли про /start")
+				return c.Send(tr(c, i18n.KeyTrackFailed))
 			}
 
-			delete(bot.States, userID)
+			if err = uc.ClearTrackState(ctx, chatID); err != nil {
+				bot.Logger.Warn("failed to clear track state", slog.Int64("chat_id", chatID),
+					slog.String("error", err.Error()))
+			}
 
-			return c.Send(fmt.Sprintf("Ссылка %s добавлена с тегами: %v", link.URL, link.Tags))
+			return c.Send(tr(c, i18n.KeyTrackAdded, link.URL, link.Tags))
 		}
 
 		return nil
@@ -4,37 +4,37 @@ import (
 	botmodel "bot/internal/model/bot"
 	"bot/utils"
 	"context"
-	"fmt"
+	"pkg/i18n"
 
 	"gopkg.in/telebot.v3"
 )
 
 func (bot *Bot) UntrackHandler(ctx context.Context, uc UseCase) telebot.HandlerFunc {
 	return func(c telebot.Context) error {
-		userID := c.Sender().ID
+		chatID := c.Chat().ID
 
 		args := c.Args()
 		if len(args) == 0 {
-			return c.Send("Использование: /untrack <ссылка>")
+			return c.Send(tr(c, i18n.KeyUntrackUsage))
 		}
 
 		link := args[0]
 
 		link, isValid := utils.ValidateLink(link)
 		if !isValid {
-			return c.Send("Неверный формат ссылки")
+			return c.Send(tr(c, i18n.KeyTrackInvalidLink))
 		}
 
 		req := botmodel.RemoveLinkRequest{
 			Link: link,
 		}
 
-		deletedLink, err := uc.DeleteLink(ctx, req, userID)
+		deletedLink, err := uc.DeleteLink(ctx, req, chatID)
 
 		if err != nil {
-			return c.Send("Ссылка не найдена в списке отслеживаемых.")
+			return c.Send(tr(c, i18n.KeyUntrackNotFound))
 		}
 
-		return c.Send(fmt.Sprintf("Ссылка %s удалена из отслеживания\n", deletedLink.URL))
+		return c.Send(tr(c, i18n.KeyUntrackRemoved, deletedLink.URL))
 	}
 }
@@ -0,0 +1,268 @@
+package queue
+
+import (
+	"gopkg.in/telebot.v3"
+
+	"strings"
+)
+
+// tag is formatting that is open at some point of a text: open starts it
+// and close ends it.
+type tag struct {
+	name  string
+	open  string
+	close string
+}
+
+func closeTags(open []tag) string {
+	var builder strings.Builder
+
+	for i := len(open) - 1; i >= 0; i-- {
+		builder.WriteString(open[i].close)
+	}
+
+	return builder.String()
+}
+
+func reopenTags(open []tag) string {
+	var builder strings.Builder
+
+	for _, t := range open {
+		builder.WriteString(t.open)
+	}
+
+	return builder.String()
+}
+
+// markup knows where a text of some parse mode may be cut.
+type markup interface {
+	// scan walks text up to end and returns the last offset before it that
+	// is not inside a tag, an entity or an escape, the formatting open
+	// there and whether any visible text precedes it.
+	scan(text string, end int) (at int, open []tag, visible bool)
+}
+
+func markupOf(mode telebot.ParseMode) markup {
+	switch mode {
+	case telebot.ModeHTML:
+		return htmlMarkup{}
+	case telebot.ModeMarkdownV2:
+		return markdownMarkup{}
+	default:
+		return plainMarkup{}
+	}
+}
+
+func parseModeOf(opts []interface{}) telebot.ParseMode {
+	for _, opt := range opts {
+		if mode, ok := opt.(telebot.ParseMode); ok {
+			return mode
+		}
+	}
+
+	return telebot.ModeDefault
+}
+
+type plainMarkup struct{}
+
+func (plainMarkup) scan(_ string, end int) (int, []tag, bool) {
+	return end, nil, end > 0
+}
+
+// See https://core.telegram.org/bots/api#html-style.
+type htmlMarkup struct{}
+
+func (htmlMarkup) scan(text string, end int) (int, []tag, bool) {
+	var (
+		open    []tag
+		visible bool
+	)
+
+	for i := 0; i < end; {
+		switch text[i] {
+		case '<':
+			j := strings.IndexByte(text[i:], '>')
+			if j < 0 {
+				return i, open, visible
+			}
+
+			if j += i + 1; j > end {
+				return i, open, visible
+			}
+
+			open = htmlTag(open, text[i:j])
+			i = j
+		case '&':
+			j := strings.IndexByte(text[i:], ';')
+			if j < 0 {
+				i++
+				visible = true
+
+				continue
+			}
+
+			if j += i + 1; j > end {
+				return i, open, visible
+			}
+
+			i = j
+			visible = true
+		default:
+			i++
+			visible = true
+		}
+	}
+
+	return end, open, visible
+}
+
+func htmlTag(open []tag, raw string) []tag {
+	inner := strings.Trim(raw, "<>")
+
+	if name, ok := strings.CutPrefix(inner, "/"); ok {
+		return closeTag(open, strings.ToLower(strings.TrimSpace(name)))
+	}
+
+	fields := strings.Fields(inner)
+	if len(fields) == 0 {
+		return open
+	}
+
+	name := strings.ToLower(fields[0])
+
+	return append(open, tag{name: name, open: raw, close: "</" + name + ">"})
+}
+
+// See https://core.telegram.org/bots/api#markdownv2-style.
+type markdownMarkup struct{}
+
+func (markdownMarkup) scan(text string, end int) (int, []tag, bool) {
+	var (
+		open    []tag
+		visible bool
+	)
+
+	for i := 0; i < end; {
+		if text[i] == '\\' {
+			if i+2 > end {
+				return i, open, visible
+			}
+
+			i += 2
+			visible = true
+
+			continue
+		}
+
+		marker := markdownMarker(text[i:])
+
+		// Code keeps everything but escapes and its own end as is.
+		if len(open) > 0 && strings.HasPrefix(open[len(open)-1].name, "`") && marker != open[len(open)-1].name {
+			i++
+			visible = true
+
+			continue
+		}
+
+		switch {
+		case marker == "[":
+			url, ok := markdownLinkURL(text[i:])
+			if !ok {
+				i++
+				visible = true
+
+				continue
+			}
+
+			open = append(open, tag{name: "[", open: "[", close: "](" + url + ")"})
+			i++
+		case marker == "]" && len(open) > 0 && open[len(open)-1].name == "[" &&
+			strings.HasPrefix(text[i:], open[len(open)-1].close):
+			j := i + len(open[len(open)-1].close)
+			if j > end {
+				return i, open, visible
+			}
+
+			open = open[:len(open)-1]
+			i = j
+		case marker != "" && marker != "]":
+			if i+len(marker) > end {
+				return i, open, visible
+			}
+
+			if isOpen(open, marker) {
+				open = closeTag(open, marker)
+			} else {
+				open = append(open, tag{name: marker, open: marker, close: marker})
+			}
+
+			i += len(marker)
+		default:
+			i++
+			visible = true
+		}
+	}
+
+	return end, open, visible
+}
+
+func markdownMarker(text string) string {
+	for _, marker := range []string{"```", "`", "__", "||", "*", "_", "~", "[", "]"} {
+		if strings.HasPrefix(text, marker) {
+			return marker
+		}
+	}
+
+	return ""
+}
+
+// markdownLinkURL returns the URL of the link text starts with.
+func markdownLinkURL(text string) (string, bool) {
+	closing := unescapedIndex(text, ']')
+	if closing < 0 || !strings.HasPrefix(text[closing:], "](") {
+		return "", false
+	}
+
+	start := closing + len("](")
+
+	n := unescapedIndex(text[start:], ')')
+	if n < 0 {
+		return "", false
+	}
+
+	return text[start : start+n], true
+}
+
+func unescapedIndex(text string, c byte) int {
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case c:
+			return i
+		}
+	}
+
+	return -1
+}
+
+func isOpen(open []tag, name string) bool {
+	for _, t := range open {
+		if t.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// closeTag drops the innermost formatting named name.
+func closeTag(open []tag, name string) []tag {
+	for i := len(open) - 1; i >= 0; i-- {
+		if open[i].name == name {
+			return append(open[:i:i], open[i+1:]...)
+		}
+	}
+
+	return open
+}
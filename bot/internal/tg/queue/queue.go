@@ -0,0 +1,238 @@
+package queue
+
+import (
+	"bot/internal/config"
+
+	"golang.org/x/time/rate"
+	"gopkg.in/telebot.v3"
+
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const maxTrackedChats = 10000
+
+var ErrClosed = errors.New("send queue is closed")
+
+type API interface {
+	Send(to telebot.Recipient, what interface{}, opts ...interface{}) (*telebot.Message, error)
+}
+
+type job struct {
+	ctx    context.Context
+	chatID int64
+	parts  []string
+	opts   []interface{}
+	result chan error
+}
+
+// Queue serializes outbound notifications so that Telegram limits are never
+// exceeded: a global limiter caps the bot-wide rate, per-chat limiters cap
+// each recipient, and flood-wait responses pause sending for all workers.
+// Jobs are sharded by chat, which keeps message parts of one chat in order.
+type Queue struct {
+	api     API
+	log     *slog.Logger
+	cfg     config.SenderConfig
+	global  *rate.Limiter
+	workers []chan *job
+	done    chan struct{}
+
+	mu          sync.Mutex
+	chats       map[int64]*rate.Limiter
+	pausedUntil time.Time
+}
+
+func New(log *slog.Logger, api API, cfg config.SenderConfig) *Queue {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+
+	workers := make([]chan *job, cfg.Workers)
+	for i := range workers {
+		workers[i] = make(chan *job, cfg.QueueSize)
+	}
+
+	return &Queue{
+		api:     api,
+		log:     log,
+		cfg:     cfg,
+		global:  rate.NewLimiter(limitOf(cfg.GlobalRate), 1),
+		workers: workers,
+		done:    make(chan struct{}),
+		chats:   make(map[int64]*rate.Limiter),
+	}
+}
+
+func (q *Queue) Start(ctx context.Context) {
+	for _, jobs := range q.workers {
+		go q.run(ctx, jobs)
+	}
+
+	go func() {
+		<-ctx.Done()
+		close(q.done)
+	}()
+}
+
+// Send splits text into Telegram-sized parts, enqueues them and waits until
+// they are delivered or the first part fails.
+func (q *Queue) Send(ctx context.Context, chatID int64, text string, opts ...interface{}) error {
+	j := &job{
+		ctx:    ctx,
+		chatID: chatID,
+		parts:  Split(text, MessageLimit, q.cfg.MaxParts, parseModeOf(opts)),
+		opts:   opts,
+		result: make(chan error, 1),
+	}
+
+	select {
+	case q.workers[q.shard(chatID)] <- j:
+	case <-q.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-j.result:
+		return err
+	case <-q.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) shard(chatID int64) int {
+	if chatID < 0 {
+		chatID = -chatID
+	}
+
+	return int(chatID % int64(len(q.workers)))
+}
+
+func (q *Queue) run(ctx context.Context, jobs chan *job) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-jobs:
+			j.result <- q.deliver(j)
+		}
+	}
+}
+
+func (q *Queue) deliver(j *job) error {
+	for _, part := range j.parts {
+		if err := q.sendPart(j, part); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (q *Queue) sendPart(j *job, part string) error {
+	const op = "queue.sendPart"
+
+	for attempt := 0; ; attempt++ {
+		if err := q.wait(j.ctx, j.chatID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err := q.api.Send(telebot.ChatID(j.chatID), part, j.opts...)
+		if err == nil {
+			return nil
+		}
+
+		var flood telebot.FloodError
+		if !errors.As(err, &flood) || attempt >= q.cfg.MaxRetries {
+			return err
+		}
+
+		retryAfter := time.Duration(flood.RetryAfter) * time.Second
+
+		q.log.Warn("telegram flood wait",
+			slog.String("op", op),
+			slog.Int64("chat_id", j.chatID),
+			slog.Duration("retry_after", retryAfter),
+		)
+
+		q.pause(retryAfter)
+	}
+}
+
+func (q *Queue) wait(ctx context.Context, chatID int64) error {
+	if pause := q.pauseLeft(); pause > 0 {
+		select {
+		case <-time.After(pause):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := q.global.Wait(ctx); err != nil {
+		return err
+	}
+
+	return q.chatLimiter(chatID).Wait(ctx)
+}
+
+func (q *Queue) pause(d time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(q.pausedUntil) {
+		q.pausedUntil = until
+	}
+}
+
+func (q *Queue) pauseLeft() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return time.Until(q.pausedUntil)
+}
+
+func (q *Queue) chatLimiter(chatID int64) *rate.Limiter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if lim, ok := q.chats[chatID]; ok {
+		return lim
+	}
+
+	// Drop limiters of idle chats so the map does not grow forever.
+	if len(q.chats) >= maxTrackedChats {
+		for id, lim := range q.chats {
+			if lim.Tokens() >= 1 {
+				delete(q.chats, id)
+			}
+		}
+	}
+
+	limit := q.cfg.ChatRate
+	if chatID < 0 {
+		limit = q.cfg.GroupRate
+	}
+
+	lim := rate.NewLimiter(limitOf(limit), 1)
+	q.chats[chatID] = lim
+
+	return lim
+}
+
+// limitOf converts a messages-per-second setting into a limiter rate,
+// treating a non-positive value as "no limit".
+func limitOf(perSecond float64) rate.Limit {
+	if perSecond <= 0 {
+		return rate.Inf
+	}
+
+	return rate.Limit(perSecond)
+}
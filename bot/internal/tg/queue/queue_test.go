@@ -0,0 +1,140 @@
+package queue_test
+
+import (
+	"bot/internal/config"
+	"bot/internal/tg/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v3"
+
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeAPI struct {
+	mu      sync.Mutex
+	sent    map[string][]string
+	floods  int
+	failErr error
+}
+
+func (f *fakeAPI) Send(to telebot.Recipient, what interface{}, _ ...interface{}) (*telebot.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.floods > 0 {
+		f.floods--
+		return nil, telebot.FloodError{RetryAfter: 1}
+	}
+
+	if f.failErr != nil {
+		return nil, f.failErr
+	}
+
+	if f.sent == nil {
+		f.sent = make(map[string][]string)
+	}
+
+	f.sent[to.Recipient()] = append(f.sent[to.Recipient()], what.(string))
+
+	return &telebot.Message{}, nil
+}
+
+func newQueue(t *testing.T, api queue.API, cfg config.SenderConfig) *queue.Queue {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	q := queue.New(slog.New(slog.NewJSONHandler(io.Discard, nil)), api, cfg)
+	q.Start(ctx)
+
+	return q
+}
+
+func TestQueue_SendSplitsLongMessage(t *testing.T) {
+	api := &fakeAPI{}
+	q := newQueue(t, api, config.SenderConfig{Workers: 2, QueueSize: 1, MaxParts: 3})
+
+	text := strings.Repeat("word ", 4000)
+
+	err := q.Send(context.Background(), 42, text)
+
+	require.NoError(t, err)
+	require.Len(t, api.sent["42"], 3)
+	assert.True(t, strings.HasSuffix(api.sent["42"][2], "…"))
+}
+
+func TestQueue_HonorsFloodWait(t *testing.T) {
+	api := &fakeAPI{floods: 1}
+	q := newQueue(t, api, config.SenderConfig{Workers: 1, QueueSize: 1, MaxRetries: 2})
+
+	start := time.Now()
+
+	err := q.Send(context.Background(), 42, "hello")
+
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second, "should wait retry_after before resending")
+	assert.Equal(t, []string{"hello"}, api.sent["42"])
+}
+
+func TestQueue_GivesUpAfterMaxRetries(t *testing.T) {
+	api := &fakeAPI{floods: 2}
+	q := newQueue(t, api, config.SenderConfig{Workers: 1, QueueSize: 1, MaxRetries: 0})
+
+	err := q.Send(context.Background(), 42, "hello")
+
+	var flood telebot.FloodError
+
+	require.ErrorAs(t, err, &flood)
+	assert.Empty(t, api.sent["42"])
+}
+
+func TestQueue_ReturnsSendError(t *testing.T) {
+	api := &fakeAPI{failErr: telebot.ErrBlockedByUser}
+	q := newQueue(t, api, config.SenderConfig{Workers: 1, QueueSize: 1})
+
+	err := q.Send(context.Background(), 42, "hello")
+
+	require.ErrorIs(t, err, telebot.ErrBlockedByUser)
+}
+
+func TestQueue_PerChatRateLimit(t *testing.T) {
+	api := &fakeAPI{}
+	q := newQueue(t, api, config.SenderConfig{Workers: 4, QueueSize: 10, ChatRate: 10})
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			assert.NoError(t, q.Send(context.Background(), 7, "msg"))
+		}()
+	}
+
+	wg.Wait()
+
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	assert.Len(t, api.sent["7"], 3)
+}
+
+func TestQueue_Closed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := queue.New(slog.New(slog.NewJSONHandler(io.Discard, nil)), &fakeAPI{}, config.SenderConfig{Workers: 1})
+	q.Start(ctx)
+
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+
+	err := q.Send(context.Background(), 1, "hello")
+
+	require.ErrorIs(t, err, queue.ErrClosed)
+}
@@ -0,0 +1,147 @@
+package queue
+
+import (
+	"gopkg.in/telebot.v3"
+
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	MessageLimit = 4096
+	ellipsis     = "…"
+)
+
+// Split breaks text into parts that fit into a single Telegram message.
+// Telegram counts the limit in UTF-16 code units, so do we. Parts are cut on
+// paragraph, line or word boundaries when possible and never inside a rune.
+// For HTML and MarkdownV2 text a part is never cut inside a tag, an entity or
+// an escape, and the formatting open at a cut is closed at the end of the
+// part and reopened at the start of the next one.
+// When maxParts is positive the tail beyond it is truncated with an ellipsis.
+func Split(text string, limit, maxParts int, mode telebot.ParseMode) []string {
+	if limit <= 0 {
+		limit = MessageLimit
+	}
+
+	m := markupOf(mode)
+
+	var parts []string
+
+	for text != "" {
+		if maxParts > 0 && len(parts) == maxParts-1 && utf16Len(text) > limit {
+			parts = append(parts, truncate(text, limit, m))
+			break
+		}
+
+		part, rest := cut(text, limit, m)
+		parts = append(parts, part)
+		text = rest
+	}
+
+	return parts
+}
+
+func truncate(text string, limit int, m markup) string {
+	part, _ := cut(text, limit-utf16Len(ellipsis), m)
+
+	return part + ellipsis
+}
+
+func cut(text string, limit int, m markup) (part, rest string) {
+	end := prefixEnd(text, limit)
+	if end == len(text) {
+		return text, ""
+	}
+
+	// Closing the open formatting takes room, so the part is cut shorter
+	// until the closing tags fit too.
+	for budget := limit; budget > 0; {
+		head, tail, open := cutAt(text, prefixEnd(text, budget), m)
+		if head == "" {
+			break
+		}
+
+		closing := closeTags(open)
+		if over := utf16Len(head) + utf16Len(closing) - limit; over > 0 {
+			budget -= over
+			continue
+		}
+
+		return head + closing, reopenTags(open) + tail
+	}
+
+	// A single tag or link does not fit into a message, it can only be cut
+	// as plain text.
+	return text[:end], text[end:]
+}
+
+// cutAt cuts text at a boundary before end that is outside the markup and
+// returns the formatting open there. head is empty when there is no text
+// before end to cut off.
+func cutAt(text string, end int, m markup) (head, tail string, open []tag) {
+	end, open, visible := m.scan(text, end)
+	if !visible {
+		return "", "", nil
+	}
+
+	head = text[:end]
+
+	for _, sep := range []string{"\n\n", "\n", " "} {
+		for k := len(head); ; {
+			i := strings.LastIndex(head[:k], sep)
+			if i <= 0 {
+				break
+			}
+
+			k = i
+
+			at, tags, visible := m.scan(text, i)
+			if at != i || !visible {
+				continue
+			}
+
+			if part := strings.TrimRight(head[:i], " \n"); part != "" {
+				return part, strings.TrimLeft(text[i+len(sep):], " \n"), tags
+			}
+		}
+	}
+
+	return head, text[end:], open
+}
+
+// prefixEnd returns the byte offset of the longest prefix of text that fits
+// into limit UTF-16 code units.
+func prefixEnd(text string, limit int) int {
+	units := 0
+
+	for i, r := range text {
+		n := utf16.RuneLen(r)
+		if n < 0 {
+			n = 1
+		}
+
+		if units+n > limit {
+			return i
+		}
+
+		units += n
+	}
+
+	return len(text)
+}
+
+func utf16Len(text string) int {
+	units := 0
+
+	for _, r := range text {
+		n := utf16.RuneLen(r)
+		if n < 0 {
+			n = 1
+		}
+
+		units += n
+	}
+
+	return units
+}
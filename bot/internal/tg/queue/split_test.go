@@ -0,0 +1,187 @@
+package queue_test
+
+import (
+	"bot/internal/tg/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v3"
+
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		limit    int
+		maxParts int
+		want     []string
+	}{
+		{
+			name:  "fits into one message",
+			text:  "short update",
+			limit: 100,
+			want:  []string{"short update"},
+		},
+		{
+			name:  "splits on paragraph",
+			text:  "first paragraph\n\nsecond paragraph",
+			limit: 20,
+			want:  []string{"first paragraph", "second paragraph"},
+		},
+		{
+			name:  "splits on line",
+			text:  "line one\nline two\nline three",
+			limit: 18,
+			want:  []string{"line one\nline two", "line three"},
+		},
+		{
+			name:  "splits on word",
+			text:  "Изменение в PR: большой заголовок",
+			limit: 20,
+			want:  []string{"Изменение в PR:", "большой заголовок"},
+		},
+		{
+			name:  "hard cut without boundaries keeps runes intact",
+			text:  "ааааабббббввввв",
+			limit: 5,
+			want:  []string{"ааааа", "ббббб", "ввввв"},
+		},
+		{
+			name:  "counts utf16 code units",
+			text:  "😀😀😀",
+			limit: 4,
+			want:  []string{"😀😀", "😀"},
+		},
+		{
+			name:     "truncates beyond max parts",
+			text:     "one two three four five six",
+			limit:    8,
+			maxParts: 2,
+			want:     []string{"one two", "three…"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := queue.Split(tt.text, tt.limit, tt.maxParts, telebot.ModeDefault)
+
+			assert.Equal(t, tt.want, got)
+
+			for _, part := range got {
+				assert.True(t, utf8.ValidString(part))
+				assert.LessOrEqual(t, utf16Len(part), tt.limit)
+			}
+		})
+	}
+}
+
+func TestSplit_LongDescription(t *testing.T) {
+	lines := []string{"Изменение в Issue: заголовок", "Пользователем: user", "C описанием: текст задачи", "------------"}
+
+	var builder strings.Builder
+
+	for i := 0; i < 200; i++ {
+		builder.WriteString(strings.Join(lines, "\n") + "\n")
+	}
+
+	parts := queue.Split(builder.String(), queue.MessageLimit, 0, telebot.ModeDefault)
+
+	require.Greater(t, len(parts), 1)
+
+	for _, part := range parts {
+		require.LessOrEqual(t, utf16Len(part), queue.MessageLimit)
+
+		for _, line := range strings.Split(strings.TrimRight(part, "\n"), "\n") {
+			require.Contains(t, lines, line, "parts should be cut on line boundaries")
+		}
+	}
+}
+
+func TestSplit_Markup(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  telebot.ParseMode
+		text  string
+		limit int
+		want  []string
+	}{
+		{
+			name:  "html closes and reopens tags",
+			mode:  telebot.ModeHTML,
+			text:  "<b>Header</b>\n<i>one two three four</i>",
+			limit: 24,
+			want:  []string{"<b>Header</b>", "<i>one two three</i>", "<i>four</i>"},
+		},
+		{
+			name:  "html keeps links and entities whole",
+			mode:  telebot.ModeHTML,
+			text:  `<a href="https://github.com/a/b">Fix &amp; test</a>`,
+			limit: 48,
+			want: []string{
+				`<a href="https://github.com/a/b">Fix &amp;</a>`,
+				`<a href="https://github.com/a/b">test</a>`,
+			},
+		},
+		{
+			name:  "html does not cut inside an entity",
+			mode:  telebot.ModeHTML,
+			text:  "&amp;&amp;&amp;",
+			limit: 7,
+			want:  []string{"&amp;", "&amp;", "&amp;"},
+		},
+		{
+			name:  "html does not cut inside a tag",
+			mode:  telebot.ModeHTML,
+			text:  `text <a href="https://x.io/a b">link</a>`,
+			limit: 36,
+			want:  []string{"text", `<a href="https://x.io/a b">link</a>`},
+		},
+		{
+			name:  "markdown closes and reopens entities",
+			mode:  telebot.ModeMarkdownV2,
+			text:  "*Header*\n_one two three\\._",
+			limit: 16,
+			want:  []string{"*Header*", "_one two_", "_three\\._"},
+		},
+		{
+			name:  "markdown repeats the link url",
+			mode:  telebot.ModeMarkdownV2,
+			text:  "[first second](https://so.com/a)",
+			limit: 26,
+			want:  []string{"[first](https://so.com/a)", "[second](https://so.com/a)"},
+		},
+		{
+			name:  "plain text ignores markup",
+			mode:  telebot.ModeDefault,
+			text:  "<b>one two</b>",
+			limit: 8,
+			want:  []string{"<b>one", "two</b>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := queue.Split(tt.text, tt.limit, 0, tt.mode)
+
+			assert.Equal(t, tt.want, got)
+
+			for _, part := range got {
+				assert.LessOrEqual(t, utf16Len(part), tt.limit)
+			}
+		})
+	}
+}
+
+func TestSplit_MarkupTruncates(t *testing.T) {
+	got := queue.Split("<i>one two three four five six</i>", 16, 2, telebot.ModeHTML)
+
+	require.Equal(t, []string{"<i>one two</i>", "<i>three</i>…"}, got)
+}
@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"bot/internal/config"
+
+	"gopkg.in/telebot.v3"
+
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// SecretHeader carries the secret_token passed to setWebhook on every update
+// Telegram delivers.
+const SecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+var (
+	ErrNoPublicURL = errors.New("webhook public url is empty")
+	ErrNoSecret    = errors.New("webhook secret token is empty")
+)
+
+// Poller is a telebot.Poller that receives updates through an http.Handler
+// mounted on the bot's own router instead of a listener of its own, so
+// several replicas can share one public URL behind a load balancer.
+type Poller struct {
+	log *slog.Logger
+	cfg config.WebhookConfig
+
+	mu   sync.RWMutex
+	dest chan<- telebot.Update
+}
+
+func New(log *slog.Logger, cfg config.WebhookConfig) (*Poller, error) {
+	if cfg.PublicURL == "" {
+		return nil, ErrNoPublicURL
+	}
+
+	if cfg.SecretToken == "" {
+		return nil, ErrNoSecret
+	}
+
+	return &Poller{log: log, cfg: cfg}, nil
+}
+
+// Poll registers the webhook and hands incoming updates to the bot until it
+// is stopped.
+func (p *Poller) Poll(b *telebot.Bot, dest chan telebot.Update, stop chan struct{}) {
+	const op = "webhook.Poll"
+
+	log := p.log.With(slog.String("op", op))
+
+	err := b.SetWebhook(&telebot.Webhook{
+		MaxConnections: p.cfg.MaxConnections,
+		DropUpdates:    p.cfg.DropPending,
+		SecretToken:    p.cfg.SecretToken,
+		Endpoint:       &telebot.WebhookEndpoint{PublicURL: p.cfg.PublicURL},
+	})
+	if err != nil {
+		log.Error("failed to set webhook", slog.String("error", err.Error()))
+		return
+	}
+
+	log.Info("webhook set", slog.String("url", p.cfg.PublicURL))
+
+	p.mu.Lock()
+	p.dest = dest
+	p.mu.Unlock()
+
+	<-stop
+
+	p.mu.Lock()
+	p.dest = nil
+	p.mu.Unlock()
+
+	if !p.cfg.DeleteOnStop {
+		return
+	}
+
+	if err = b.RemoveWebhook(); err != nil {
+		log.Error("failed to delete webhook", slog.String("error", err.Error()))
+		return
+	}
+
+	log.Info("webhook deleted")
+}
+
+func (p *Poller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	secret := r.Header.Get(SecretHeader)
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(p.cfg.SecretToken)) != 1 {
+		p.log.Warn("webhook request with invalid secret token", slog.String("remote", r.RemoteAddr))
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	var update telebot.Update
+
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	p.mu.RLock()
+	dest := p.dest
+	p.mu.RUnlock()
+
+	// Telegram redelivers updates that were not acknowledged with 2xx.
+	if dest == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	select {
+	case dest <- update:
+		w.WriteHeader(http.StatusOK)
+	case <-r.Context().Done():
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
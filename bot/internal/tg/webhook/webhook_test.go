@@ -0,0 +1,171 @@
+package webhook_test
+
+import (
+	"bot/internal/config"
+	"bot/internal/tg/webhook"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v3"
+
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	secret    = "s3cr3t"
+	publicURL = "https://bot.example.com/telegram/webhook"
+)
+
+type fakeTelegram struct {
+	mu    sync.Mutex
+	calls map[string]map[string]any
+}
+
+func (f *fakeTelegram) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	var params map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&params)
+
+	f.mu.Lock()
+	f.calls[method] = params
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"ok":true,"result":true}`))
+}
+
+func (f *fakeTelegram) call(method string) (map[string]any, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	params, ok := f.calls[method]
+
+	return params, ok
+}
+
+func newPoller(t *testing.T, deleteOnStop bool) *webhook.Poller {
+	t.Helper()
+
+	poller, err := webhook.New(slog.New(slog.NewJSONHandler(io.Discard, nil)), config.WebhookConfig{
+		PublicURL:    publicURL,
+		SecretToken:  secret,
+		DeleteOnStop: deleteOnStop,
+	})
+	require.NoError(t, err)
+
+	return poller
+}
+
+func startBot(t *testing.T, telegram *fakeTelegram, poller *webhook.Poller) *telebot.Bot {
+	t.Helper()
+
+	api := httptest.NewServer(telegram)
+	t.Cleanup(api.Close)
+
+	bot, err := telebot.NewBot(telebot.Settings{
+		URL:     api.URL,
+		Token:   "test",
+		Offline: true,
+		Poller:  poller,
+	})
+	require.NoError(t, err)
+
+	go bot.Start()
+
+	require.Eventually(t, func() bool {
+		_, ok := telegram.call("setWebhook")
+		return ok
+	}, time.Second, 10*time.Millisecond)
+
+	return bot
+}
+
+func postUpdate(t *testing.T, handler http.Handler, token, body string) int {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/telegram/webhook", strings.NewReader(body))
+	if token != "" {
+		req.Header.Set(webhook.SecretHeader, token)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func TestPoller_DeliversUpdates(t *testing.T) {
+	telegram := &fakeTelegram{calls: make(map[string]map[string]any)}
+	poller := newPoller(t, true)
+	bot := startBot(t, telegram, poller)
+
+	params, _ := telegram.call("setWebhook")
+	require.Equal(t, publicURL, params["url"])
+	require.Equal(t, secret, params["secret_token"])
+
+	received := make(chan string, 1)
+
+	bot.Handle("/ping", func(c telebot.Context) error {
+		received <- c.Text()
+		return nil
+	})
+
+	update := `{"update_id":1,"message":{"message_id":1,"text":"/ping","chat":{"id":42,"type":"private"}}}`
+
+	require.Equal(t, http.StatusOK, postUpdate(t, poller, secret, update))
+
+	select {
+	case text := <-received:
+		require.Equal(t, "/ping", text)
+	case <-time.After(time.Second):
+		t.Fatal("update was not dispatched")
+	}
+
+	bot.Stop()
+
+	_, deleted := telegram.call("deleteWebhook")
+	require.True(t, deleted, "webhook should be deleted on stop")
+
+	require.Equal(t, http.StatusServiceUnavailable, postUpdate(t, poller, secret, update))
+}
+
+func TestPoller_RejectsInvalidSecret(t *testing.T) {
+	telegram := &fakeTelegram{calls: make(map[string]map[string]any)}
+	poller := newPoller(t, false)
+	bot := startBot(t, telegram, poller)
+
+	defer bot.Stop()
+
+	update := `{"update_id":1}`
+
+	require.Equal(t, http.StatusUnauthorized, postUpdate(t, poller, "", update))
+	require.Equal(t, http.StatusUnauthorized, postUpdate(t, poller, "wrong", update))
+	require.Equal(t, http.StatusBadRequest, postUpdate(t, poller, secret, "{"))
+}
+
+func TestPoller_KeepsWebhookWhenShared(t *testing.T) {
+	telegram := &fakeTelegram{calls: make(map[string]map[string]any)}
+	bot := startBot(t, telegram, newPoller(t, false))
+
+	bot.Stop()
+
+	_, deleted := telegram.call("deleteWebhook")
+	require.False(t, deleted)
+}
+
+func TestNew_RequiresSecretAndURL(t *testing.T) {
+	log := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	_, err := webhook.New(log, config.WebhookConfig{SecretToken: secret})
+	require.ErrorIs(t, err, webhook.ErrNoPublicURL)
+
+	_, err = webhook.New(log, config.WebhookConfig{PublicURL: publicURL})
+	require.ErrorIs(t, err, webhook.ErrNoSecret)
+}
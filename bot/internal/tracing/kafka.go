@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"github.com/Shopify/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"context"
+)
+
+// headersCarrier lets the propagator read trace context from the headers of
+// a Kafka message.
+type headersCarrier struct {
+	msg *sarama.ConsumerMessage
+}
+
+func (c headersCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+
+	return ""
+}
+
+// Set is not used for consumed messages.
+func (c headersCarrier) Set(string, string) {}
+
+func (c headersCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+
+	for _, h := range c.msg.Headers {
+		if h != nil {
+			keys = append(keys, string(h.Key))
+		}
+	}
+
+	return keys
+}
+
+// ExtractKafka returns ctx carrying the trace context the scraper put into
+// the headers of msg.
+func ExtractKafka(ctx context.Context, msg *sarama.ConsumerMessage) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, headersCarrier{msg: msg})
+}
+
+// StartConsumer opens a consumer span for msg that continues the scraper's
+// trace.
+func StartConsumer(ctx context.Context, msg *sarama.ConsumerMessage) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ExtractKafka(ctx, msg), "kafka.consume "+msg.Topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.Int64("messaging.kafka.offset", msg.Offset),
+		))
+}
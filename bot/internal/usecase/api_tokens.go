@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"bot/internal/model/bot"
+
+	"context"
+	"log/slog"
+)
+
+// IssueToken asks the scraper for a public API token of the chat. The token
+// can't be fetched again, so it is not cached.
+func (a *UseCase) IssueToken(ctx context.Context, id int64, scopes []string) (*bot.IssueTokenResponse, error) {
+	const op = "bot.IssueToken"
+
+	log := a.l.With(
+		slog.String("op", op),
+	)
+
+	log.Info("attempting to issue token")
+
+	token, err := a.ScraperClient.IssueToken(ctx, id, scopes)
+	if err != nil {
+		log.Error("failed to issue token", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (a *UseCase) RevokeTokens(ctx context.Context, id int64) (int64, error) {
+	const op = "bot.RevokeTokens"
+
+	log := a.l.With(
+		slog.String("op", op),
+	)
+
+	log.Info("attempting to revoke tokens")
+
+	revoked, err := a.ScraperClient.RevokeTokens(ctx, id)
+	if err != nil {
+		log.Error("failed to revoke tokens", slog.String("error", err.Error()))
+		return 0, err
+	}
+
+	return revoked, nil
+}
@@ -0,0 +1,40 @@
+package usecase_test
+
+import (
+	"bot/internal/model/bot"
+	botUC "bot/internal/usecase"
+	"github.com/stretchr/testify/require"
+
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestUseCase_Tokens(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	client := &chatScraperClient{links: map[int64][]bot.Link{1: {}}}
+	uc := botUC.New(logger, nil, client, nil)
+
+	issued, err := uc.IssueToken(ctx, 1, nil)
+	require.NoError(t, err)
+	require.Equal(t, "lt_1", issued.Token)
+	require.Equal(t, []string{bot.ScopeLinksRead, bot.ScopeLinksWrite}, issued.Scopes)
+
+	issued, err = uc.IssueToken(ctx, 1, []string{bot.ScopeLinksRead})
+	require.NoError(t, err)
+	require.Equal(t, []string{bot.ScopeLinksRead}, issued.Scopes)
+
+	_, err = uc.IssueToken(ctx, 2, nil)
+	require.Error(t, err)
+
+	revoked, err := uc.RevokeTokens(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), revoked)
+
+	revoked, err = uc.RevokeTokens(ctx, 1)
+	require.NoError(t, err)
+	require.Zero(t, revoked)
+}
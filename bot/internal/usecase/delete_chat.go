@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+)
+
+func (a *UseCase) DeleteChat(ctx context.Context, id int64) error {
+	const op = "bot.DeleteChat"
+
+	log := a.l.With(
+		slog.String("op", op),
+		slog.Int64("chat_id", id),
+	)
+
+	log.Info("attempting to delete chat")
+
+	err := a.ScraperClient.DeleteChat(ctx, id)
+	if err != nil {
+		log.Error(err.Error())
+		return err
+	}
+
+	key := strconv.FormatInt(id, 10)
+
+	if err = a.Storage.DeleteLinks(ctx, key); err != nil {
+		log.Error("failed to delete links from cache", slog.String("error", err.Error()))
+	}
+
+	if err = a.Storage.DeleteSettings(ctx, key); err != nil {
+		log.Error("failed to delete chat settings", slog.String("error", err.Error()))
+	}
+
+	if err = a.Storage.DeleteState(ctx, key); err != nil {
+		log.Error("failed to delete track state", slog.String("error", err.Error()))
+	}
+
+	return nil
+}
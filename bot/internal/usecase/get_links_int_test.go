@@ -47,6 +47,21 @@ func (f *fakeScraperClient) GetLinks(_ context.Context, _ int64) (*bot.ListLinkR
 	return f.resp, f.err
 }
 
+func (f *fakeScraperClient) IssueToken(_ context.Context, _ int64, _ []string) (*bot.IssueTokenResponse, error) {
+	f.called = true
+	return nil, f.err
+}
+
+func (f *fakeScraperClient) RevokeTokens(_ context.Context, _ int64) (int64, error) {
+	f.called = true
+	return 0, f.err
+}
+
+func (f *fakeScraperClient) GetHistory(_ context.Context, _ int64, _ string, _ int) ([]bot.HistoryEntry, error) {
+	f.called = true
+	return nil, f.err
+}
+
 func setupRedis(t *testing.T) (ctx context.Context, store *redisStorage.Storage, cleanup func()) {
 	ctx = context.Background()
 
@@ -71,7 +86,7 @@ func setupRedis(t *testing.T) (ctx context.Context, store *redisStorage.Storage,
 	require.NoError(t, err)
 
 	address := fmt.Sprintf("%s:%s", host, port.Port())
-	store = redisStorage.New(address, 5, 1)
+	store = redisStorage.New(address, 5, 1, nil)
 
 	cleanup = func() {
 		_ = store.Close()
@@ -14,8 +14,13 @@ import (
 
 type fakeStorage struct {
 	links     []bot.Link
+	settings  map[string]*bot.ChatSettings
 	calledGet bool
 	calledSet bool
+	deleted   []string
+	inactive  map[string]*bot.InactiveChat
+	states    map[string]*bot.UserState
+	events    map[string]bool
 }
 
 func (f *fakeStorage) GetLinks(_ context.Context, _ string) ([]bot.Link, error) {
@@ -32,6 +37,33 @@ func (f *fakeStorage) SetLinks(_ context.Context, _ string, links []bot.Link) er
 	return nil
 }
 
+func (f *fakeStorage) DeleteLinks(_ context.Context, key string) error {
+	f.deleted = append(f.deleted, key)
+	f.links = nil
+	return nil
+}
+
+func (f *fakeStorage) SetSettings(_ context.Context, key string, settings *bot.ChatSettings) error {
+	if f.settings == nil {
+		f.settings = make(map[string]*bot.ChatSettings)
+	}
+	f.settings[key] = settings
+	return nil
+}
+
+func (f *fakeStorage) GetSettings(_ context.Context, key string) (*bot.ChatSettings, error) {
+	settings, ok := f.settings[key]
+	if !ok {
+		return nil, redis.ErrNil
+	}
+	return settings, nil
+}
+
+func (f *fakeStorage) DeleteSettings(_ context.Context, key string) error {
+	delete(f.settings, key)
+	return nil
+}
+
 func TestUseCase_GetLinks(t *testing.T) {
 	userID := int64(50)
 	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
@@ -73,3 +105,73 @@ func TestUseCase_GetLinks(t *testing.T) {
 		require.Equal(t, len(expectedLinks), resp.Size)
 	})
 }
+
+func TestUseCase_RefreshLinks(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	cached := []bot.Link{{ID: 1, URL: "someURL"}}
+	fresh := []bot.Link{{ID: 1, URL: "someURL", Health: &bot.LinkHealth{Failures: 2, LastStatus: 502}}}
+	fakeClient := &fakeScraperClient{resp: &bot.ListLinkResponse{Links: fresh, Size: len(fresh)}}
+	storage := &fakeStorage{links: cached}
+
+	uc := botUC.New(logger, nil, fakeClient, storage)
+
+	resp, err := uc.RefreshLinks(context.Background(), 50)
+
+	require.NoError(t, err)
+	require.True(t, fakeClient.called, "scraper should be called past the cache")
+	require.False(t, storage.calledGet, "cache should not be read")
+	require.Equal(t, fresh, resp.Links)
+	require.Equal(t, fresh, storage.links, "cache should be refreshed")
+	require.False(t, resp.Links[0].Healthy())
+}
+
+func (f *fakeStorage) SetInactiveChat(_ context.Context, key string, chat *bot.InactiveChat) error {
+	if f.inactive == nil {
+		f.inactive = make(map[string]*bot.InactiveChat)
+	}
+	f.inactive[key] = chat
+	return nil
+}
+
+func (f *fakeStorage) DeleteInactiveChat(_ context.Context, key string) error {
+	delete(f.inactive, key)
+	return nil
+}
+
+func (f *fakeStorage) SetState(_ context.Context, key string, state *bot.UserState) error {
+	if f.states == nil {
+		f.states = make(map[string]*bot.UserState)
+	}
+	f.states[key] = state
+	return nil
+}
+
+func (f *fakeStorage) GetState(_ context.Context, key string) (*bot.UserState, error) {
+	state, ok := f.states[key]
+	if !ok {
+		return nil, redis.ErrNil
+	}
+	return state, nil
+}
+
+func (f *fakeStorage) DeleteState(_ context.Context, key string) error {
+	delete(f.states, key)
+	return nil
+}
+
+func (f *fakeStorage) ClaimEvent(_ context.Context, eventID string) (bool, error) {
+	if f.events == nil {
+		f.events = make(map[string]bool)
+	}
+	if f.events[eventID] {
+		return false, nil
+	}
+	f.events[eventID] = true
+	return true, nil
+}
+
+func (f *fakeStorage) ReleaseEvent(_ context.Context, eventID string) error {
+	delete(f.events, eventID)
+	return nil
+}
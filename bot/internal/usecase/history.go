@@ -0,0 +1,28 @@
+package usecase
+
+import (
+	"bot/internal/model/bot"
+
+	"context"
+	"log/slog"
+)
+
+// GetHistory returns the last limit updates sent to the chat about the link,
+// latest first.
+func (a *UseCase) GetHistory(ctx context.Context, id int64, link string, limit int) ([]bot.HistoryEntry, error) {
+	const op = "bot.GetHistory"
+
+	log := a.l.With(
+		slog.String("op", op),
+	)
+
+	log.Info("attempting to get history")
+
+	entries, err := a.ScraperClient.GetHistory(ctx, id, link, limit)
+	if err != nil {
+		log.Error("failed to get history", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"bot/internal/model/bot"
+
+	"context"
+	"log/slog"
+	"strconv"
+)
+
+func (a *UseCase) removeInactiveChats(ctx context.Context, chats []bot.InactiveChat) {
+	const op = "bot.removeInactiveChats"
+
+	for _, chat := range chats {
+		log := a.l.With(
+			slog.String("op", op),
+			slog.Int64("chat_id", chat.ChatID),
+			slog.String("reason", chat.Reason),
+		)
+
+		log.Warn("chat is unreachable, removing its subscriptions")
+
+		if err := a.Storage.SetInactiveChat(ctx, strconv.FormatInt(chat.ChatID, 10), &chat); err != nil {
+			log.Error("failed to record inactive chat", slog.String("error", err.Error()))
+		}
+
+		if err := a.DeleteChat(ctx, chat.ChatID); err != nil {
+			log.Error("failed to delete inactive chat", slog.String("error", err.Error()))
+		}
+	}
+}
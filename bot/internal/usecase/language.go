@@ -0,0 +1,29 @@
+package usecase
+
+import (
+	"pkg/i18n"
+
+	"context"
+	"log/slog"
+)
+
+// chatLanguages returns the languages of chats: picked with /lang or taken
+// from the Telegram client on /start. Chats without one are left out and get
+// the default language.
+func (a *UseCase) chatLanguages(ctx context.Context, chatIDs []int64) map[int64]i18n.Lang {
+	langs := make(map[int64]i18n.Lang, len(chatIDs))
+
+	for _, chatID := range chatIDs {
+		settings, err := a.GetSettings(ctx, chatID)
+		if err != nil {
+			a.l.Warn("failed to load chat language", slog.Int64("chat_id", chatID), slog.String("error", err.Error()))
+			continue
+		}
+
+		if settings.Language != "" {
+			langs[chatID] = i18n.Lang(settings.Language)
+		}
+	}
+
+	return langs
+}
@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"bot/internal/model/bot"
+
+	"context"
+	"log/slog"
+)
+
+func (a *UseCase) MigrateChat(ctx context.Context, from, to int64) error {
+	const op = "bot.MigrateChat"
+
+	log := a.l.With(
+		slog.String("op", op),
+		slog.Int64("from", from),
+		slog.Int64("to", to),
+	)
+
+	log.Info("attempting to migrate chat")
+
+	links, err := a.ScraperClient.GetLinks(ctx, from)
+	if err != nil {
+		log.Error(err.Error())
+		return err
+	}
+
+	err = a.ScraperClient.RegisterChat(ctx, to)
+	if err != nil {
+		log.Warn("failed to register new chat, assuming it already exists", slog.String("error", err.Error()))
+	}
+
+	for _, link := range links.Links {
+		req := bot.AddLinkRequest{
+			Link:    link.URL,
+			Tags:    link.Tags,
+			Filters: link.Filters,
+		}
+
+		if _, err = a.ScraperClient.AddLink(ctx, req, to); err != nil {
+			log.Error("failed to migrate link", slog.String("url", link.URL), slog.String("error", err.Error()))
+		}
+	}
+
+	settings, err := a.GetSettings(ctx, from)
+	if err == nil {
+		if err = a.SetSettings(ctx, to, settings); err != nil {
+			log.Error("failed to migrate chat settings")
+		}
+	}
+
+	state, err := a.GetTrackState(ctx, from)
+	if err == nil && state != nil {
+		if err = a.SetTrackState(ctx, to, state); err != nil {
+			log.Error("failed to migrate track state")
+		}
+	}
+
+	err = a.DeleteChat(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	err = a.invalidateCache(ctx, to)
+	if err != nil {
+		log.Error("failed to invalidate cache")
+		return err
+	}
+
+	return nil
+}
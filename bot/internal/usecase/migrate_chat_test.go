@@ -0,0 +1,150 @@
+package usecase_test
+
+import (
+	"bot/internal/model/bot"
+	botUC "bot/internal/usecase"
+	"github.com/stretchr/testify/require"
+
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"testing"
+)
+
+type chatScraperClient struct {
+	links      map[int64][]bot.Link
+	registered []int64
+	deleted    []int64
+	tokens     map[int64]int64
+}
+
+func (f *chatScraperClient) RegisterChat(_ context.Context, id int64) error {
+	f.registered = append(f.registered, id)
+	return nil
+}
+
+func (f *chatScraperClient) DeleteChat(_ context.Context, id int64) error {
+	f.deleted = append(f.deleted, id)
+	delete(f.links, id)
+
+	return nil
+}
+
+func (f *chatScraperClient) AddLink(_ context.Context, req bot.AddLinkRequest, id int64) (*bot.Link, error) {
+	link := bot.Link{ID: int64(len(f.links[id]) + 1), URL: req.Link, Tags: req.Tags, Filters: req.Filters}
+	f.links[id] = append(f.links[id], link)
+
+	return &link, nil
+}
+
+func (f *chatScraperClient) DeleteLink(_ context.Context, _ bot.RemoveLinkRequest, _ int64) (*bot.Link, error) {
+	return nil, nil
+}
+
+func (f *chatScraperClient) GetLinks(_ context.Context, id int64) (*bot.ListLinkResponse, error) {
+	return &bot.ListLinkResponse{Links: f.links[id], Size: len(f.links[id])}, nil
+}
+
+func (f *chatScraperClient) IssueToken(_ context.Context, id int64, scopes []string) (*bot.IssueTokenResponse,
+	error) {
+	if _, ok := f.links[id]; !ok {
+		return nil, errors.New("chat is not registered")
+	}
+
+	if f.tokens == nil {
+		f.tokens = map[int64]int64{}
+	}
+
+	f.tokens[id]++
+
+	if len(scopes) == 0 {
+		scopes = []string{bot.ScopeLinksRead, bot.ScopeLinksWrite}
+	}
+
+	return &bot.IssueTokenResponse{Token: "lt_" + strconv.FormatInt(f.tokens[id], 10), Scopes: scopes}, nil
+}
+
+func (f *chatScraperClient) RevokeTokens(_ context.Context, id int64) (int64, error) {
+	revoked := f.tokens[id]
+	delete(f.tokens, id)
+
+	return revoked, nil
+}
+
+func (f *chatScraperClient) GetHistory(_ context.Context, _ int64, _ string, _ int) ([]bot.HistoryEntry, error) {
+	return nil, nil
+}
+
+func TestUseCase_MigrateChat(t *testing.T) {
+	const (
+		from = int64(-100)
+		to   = int64(-1001234567890)
+	)
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	client := &chatScraperClient{links: map[int64][]bot.Link{
+		from: {
+			{ID: 1, URL: "https://github.com/user/repo", Tags: []string{"work"}, Filters: []string{}},
+			{ID: 2, URL: "https://stackoverflow.com/questions/1", Tags: []string{}, Filters: []string{"f"}},
+		},
+	}}
+	storage := &fakeStorage{
+		settings: map[string]*bot.ChatSettings{"-100": {MembersCanManage: true}},
+		states:   map[string]*bot.UserState{"-100": {Step: "waiting_for_tags", UserID: 7}},
+	}
+
+	uc := botUC.New(logger, nil, client, storage)
+
+	err := uc.MigrateChat(ctx, from, to)
+
+	require.NoError(t, err)
+	require.Equal(t, []int64{to}, client.registered)
+	require.Equal(t, []int64{from}, client.deleted)
+	require.Len(t, client.links[to], 2)
+	require.Equal(t, "https://github.com/user/repo", client.links[to][0].URL)
+	require.Equal(t, []string{"work"}, client.links[to][0].Tags)
+	require.Equal(t, client.links[to], storage.links, "cache should hold links of the new chat")
+
+	settings, err := uc.GetSettings(ctx, to)
+
+	require.NoError(t, err)
+	require.True(t, settings.MembersCanManage)
+
+	settings, err = uc.GetSettings(ctx, from)
+
+	require.NoError(t, err)
+	require.False(t, settings.MembersCanManage, "old chat settings should be removed")
+
+	state, err := uc.GetTrackState(ctx, to)
+
+	require.NoError(t, err)
+	require.Equal(t, "waiting_for_tags", state.Step, "an open /track dialog should move along")
+
+	state, err = uc.GetTrackState(ctx, from)
+
+	require.NoError(t, err)
+	require.Nil(t, state)
+}
+
+func TestUseCase_DeleteChat(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	client := &chatScraperClient{links: map[int64][]bot.Link{
+		42: {{ID: 1, URL: "https://github.com/user/repo"}},
+	}}
+	storage := &fakeStorage{links: client.links[42]}
+
+	uc := botUC.New(logger, nil, client, storage)
+
+	err := uc.DeleteChat(ctx, 42)
+
+	require.NoError(t, err)
+	require.Equal(t, []int64{42}, client.deleted)
+	require.Equal(t, []string{"42"}, storage.deleted)
+	require.Empty(t, storage.links)
+}
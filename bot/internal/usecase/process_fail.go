@@ -2,10 +2,13 @@ package usecase
 
 import (
 	"bot/internal/model/bot"
+
+	"context"
 	"log/slog"
+	"strconv"
 )
 
-func (a *UseCase) ProcessFail(model *bot.LinkUpdate) error {
+func (a *UseCase) ProcessFail(ctx context.Context, model *bot.LinkUpdate) error {
 	const op = "bot.ProcessFail"
 
 	log := a.l.With(
@@ -14,9 +17,19 @@ func (a *UseCase) ProcessFail(model *bot.LinkUpdate) error {
 
 	log.Info("attempting to send info about fail")
 
-	err := a.Bot.FailHandler(*model)
+	// The scraper has already dropped the link, so cached lists are stale.
+	for _, chatID := range model.TgChatIDs {
+		if err := a.Storage.DeleteLinks(ctx, strconv.FormatInt(chatID, 10)); err != nil {
+			log.Error("failed to delete links from cache", slog.String("error", err.Error()))
+		}
+	}
+
+	inactive, err := a.Bot.FailHandler(ctx, *model, a.chatLanguages(ctx, model.TgChatIDs))
+	a.removeInactiveChats(ctx, inactive)
+
 	if err != nil {
 		log.Error(err.Error())
+		return err
 	}
 
 	return nil
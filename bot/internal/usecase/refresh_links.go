@@ -0,0 +1,32 @@
+package usecase
+
+import (
+	"bot/internal/model/bot"
+
+	"context"
+	"log/slog"
+	"strconv"
+)
+
+// RefreshLinks fetches the chat's links from the scraper past the cache and
+// caches the fresh list, so that their health is up to date.
+func (a *UseCase) RefreshLinks(ctx context.Context, userID int64) (*bot.ListLinkResponse, error) {
+	const op = "bot.RefreshLinks"
+
+	log := a.l.With(
+		slog.String("op", op),
+	)
+
+	clientData, err := a.ScraperClient.GetLinks(ctx, userID)
+	if err != nil {
+		log.Error(err.Error())
+
+		return nil, err
+	}
+
+	if err = a.Storage.SetLinks(ctx, strconv.FormatInt(userID, 10), clientData.Links); err != nil {
+		log.Error("failed to set links to cache")
+	}
+
+	return clientData, nil
+}
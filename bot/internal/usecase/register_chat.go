@@ -3,21 +3,26 @@ package usecase
 import (
 	"context"
 	"log/slog"
+	"strconv"
 )
 
 func (a *UseCase) RegisterChat(ctx context.Context, id int64) error {
-	const op = "bot.GetLinks"
+	const op = "bot.RegisterChat"
 
 	log := a.l.With(
 		slog.String("op", op),
 	)
 
-	log.Info("attempting to get links")
+	log.Info("attempting to register chat")
 
 	err := a.ScraperClient.RegisterChat(ctx, id)
 	if err != nil {
 		return err
 	}
 
+	if err = a.Storage.DeleteInactiveChat(ctx, strconv.FormatInt(id, 10)); err != nil {
+		log.Error("failed to clear inactive chat record", slog.String("error", err.Error()))
+	}
+
 	return nil
 }
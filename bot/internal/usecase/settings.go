@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"bot/internal/model/bot"
+	"github.com/gomodule/redigo/redis"
+
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+)
+
+func (a *UseCase) GetSettings(ctx context.Context, id int64) (*bot.ChatSettings, error) {
+	const op = "bot.GetSettings"
+
+	log := a.l.With(
+		slog.String("op", op),
+	)
+
+	settings, err := a.Storage.GetSettings(ctx, strconv.FormatInt(id, 10))
+	if err != nil {
+		if errors.Is(err, redis.ErrNil) {
+			return &bot.ChatSettings{}, nil
+		}
+
+		log.Error(err.Error())
+
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (a *UseCase) SetSettings(ctx context.Context, id int64, settings *bot.ChatSettings) error {
+	const op = "bot.SetSettings"
+
+	log := a.l.With(
+		slog.String("op", op),
+	)
+
+	log.Info("attempting to save chat settings")
+
+	err := a.Storage.SetSettings(ctx, strconv.FormatInt(id, 10), settings)
+	if err != nil {
+		log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"bot/internal/model/bot"
+	"github.com/gomodule/redigo/redis"
+
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+)
+
+// GetTrackState returns the /track dialog of the chat, nil when there is
+// none or it expired.
+func (a *UseCase) GetTrackState(ctx context.Context, id int64) (*bot.UserState, error) {
+	const op = "bot.GetTrackState"
+
+	log := a.l.With(
+		slog.String("op", op),
+	)
+
+	state, err := a.Storage.GetState(ctx, strconv.FormatInt(id, 10))
+	if err != nil {
+		if errors.Is(err, redis.ErrNil) {
+			return nil, nil
+		}
+
+		log.Error(err.Error())
+
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func (a *UseCase) SetTrackState(ctx context.Context, id int64, state *bot.UserState) error {
+	const op = "bot.SetTrackState"
+
+	log := a.l.With(
+		slog.String("op", op),
+	)
+
+	if err := a.Storage.SetState(ctx, strconv.FormatInt(id, 10), state); err != nil {
+		log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (a *UseCase) ClearTrackState(ctx context.Context, id int64) error {
+	const op = "bot.ClearTrackState"
+
+	log := a.l.With(
+		slog.String("op", op),
+	)
+
+	if err := a.Storage.DeleteState(ctx, strconv.FormatInt(id, 10)); err != nil {
+		log.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -2,21 +2,61 @@ package usecase
 
 import (
 	botModel "bot/internal/model/bot"
+
+	"context"
 	"log/slog"
 )
 
-func (a *UseCase) Update(model *botModel.LinkUpdate) error {
+// Update delivers an update that came over any transport: HTTP, gRPC or
+// Kafka. An event is delivered once however many times and ways it arrives;
+// a failed delivery releases it for the retry.
+func (a *UseCase) Update(ctx context.Context, model *botModel.LinkUpdate) error {
+	const op = "bot.Update"
+
+	log := a.l.With(
+		slog.String("op", op),
+		slog.String("event_id", model.EventID),
+	)
+
+	if model.EventID != "" {
+		claimed, err := a.Storage.ClaimEvent(ctx, model.EventID)
+		if err != nil {
+			log.Warn("failed to claim event, delivering it anyway", slog.String("error", err.Error()))
+		} else if !claimed {
+			log.Info("skipping duplicate event")
+			return nil
+		}
+	}
+
+	err := a.deliver(ctx, model)
+	if err != nil && model.EventID != "" {
+		if releaseErr := a.Storage.ReleaseEvent(ctx, model.EventID); releaseErr != nil {
+			log.Warn("failed to release event", slog.String("error", releaseErr.Error()))
+		}
+	}
+
+	return err
+}
+
+func (a *UseCase) deliver(ctx context.Context, model *botModel.LinkUpdate) error {
 	const op = "bot.Update"
 
+	if _, removed := model.Removal(); removed {
+		return a.ProcessFail(ctx, model)
+	}
+
 	log := a.l.With(
 		slog.String("op", op),
 	)
 
 	log.Info("attempting to send updates")
 
-	err := a.Bot.InfoHandler(*model)
+	inactive, err := a.Bot.InfoHandler(ctx, *model, a.chatLanguages(ctx, model.TgChatIDs))
+	a.removeInactiveChats(ctx, inactive)
+
 	if err != nil {
 		log.Error(err.Error())
+		return err
 	}
 
 	return nil
@@ -0,0 +1,280 @@
+package usecase_test
+
+import (
+	"bot/internal/config"
+	"bot/internal/model/bot"
+	bothandlers "bot/internal/tg/handlers"
+	"bot/internal/tg/queue"
+	botUC "bot/internal/usecase"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/telebot.v3"
+	"pkg/i18n"
+	"pkg/render"
+
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	blockedChatID = "1"
+	brokenChatID  = "5"
+)
+
+type fakeTelegram struct {
+	mu       sync.Mutex
+	sent     []string
+	messages []map[string]string
+}
+
+func (f *fakeTelegram) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var params map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	chatID := params["chat_id"]
+
+	f.mu.Lock()
+	f.sent = append(f.sent, chatID)
+	f.messages = append(f.messages, params)
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if chatID == blockedChatID {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`))
+
+		return
+	}
+
+	if chatID == brokenChatID {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"ok":false,"error_code":500,"description":"Internal Server Error"}`))
+
+		return
+	}
+
+	_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":1,"chat":{"id":` + chatID + `,"type":"private"}}}`))
+}
+
+func newTestBot(t *testing.T, handler http.Handler) *bothandlers.Bot {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	tgBot, err := telebot.NewBot(telebot.Settings{
+		URL:     server.URL,
+		Token:   "test",
+		Offline: true,
+	})
+
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	sendQueue := queue.New(slog.New(slog.NewJSONHandler(io.Discard, nil)), tgBot, config.SenderConfig{
+		Workers:   2,
+		QueueSize: 10,
+	})
+	sendQueue.Start(ctx)
+
+	renderer, err := render.New(render.FormatHTML, "", time.Now)
+	require.NoError(t, err)
+
+	return &bothandlers.Bot{
+		Handler:  tgBot,
+		Sender:   sendQueue,
+		Renderer: renderer,
+		Logger:   slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+}
+
+func TestUseCase_Update_RemovesBlockedChat(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	telegram := &fakeTelegram{}
+	tgBot := newTestBot(t, telegram)
+
+	client := &chatScraperClient{links: map[int64][]bot.Link{}}
+	storage := &fakeStorage{}
+
+	uc := botUC.New(logger, tgBot, client, storage)
+
+	err := uc.Update(context.Background(), &bot.LinkUpdate{
+		ID:        1,
+		URL:       "https://github.com/user/repo",
+		TgChatIDs: []int64{1, 2},
+	})
+
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"1", "2"}, telegram.sent, "all chats should be attempted")
+	require.Equal(t, []int64{1}, client.deleted, "only the blocked chat should be deleted")
+	require.Contains(t, storage.inactive, "1")
+	require.Equal(t, bothandlers.ReasonBlocked, storage.inactive["1"].Reason)
+	require.Equal(t, []string{"1"}, storage.deleted, "cache of the blocked chat should be cleared")
+}
+
+func TestUseCase_Update_RendersEvents(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	telegram := &fakeTelegram{}
+	uc := botUC.New(logger, newTestBot(t, telegram), &chatScraperClient{}, &fakeStorage{})
+
+	err := uc.Update(context.Background(), &bot.LinkUpdate{
+		ID:            1,
+		URL:           "https://github.com/user/repo",
+		SchemaVersion: bot.LinkUpdateSchemaVersion,
+		Events: []bot.LinkEvent{{
+			Provider:  render.ProviderGitHub,
+			Type:      render.EventGitHubIssue,
+			ItemID:    "7",
+			Title:     "Crash <on> start",
+			Author:    "user",
+			URL:       "https://github.com/user/repo/issues/7",
+			Timestamp: time.Now(),
+		}},
+		TgChatIDs: []int64{2},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, telegram.messages, 1)
+	require.Equal(t, "HTML", telegram.messages[0]["parse_mode"])
+	require.Contains(t, telegram.messages[0]["text"], "user/repo")
+	require.Contains(t, telegram.messages[0]["text"], "Crash &lt;on&gt; start")
+}
+
+func TestUseCase_Update_FallsBackToLink(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	telegram := &fakeTelegram{}
+	uc := botUC.New(logger, newTestBot(t, telegram), &chatScraperClient{}, &fakeStorage{})
+
+	err := uc.Update(context.Background(), &bot.LinkUpdate{
+		ID:            1,
+		URL:           "https://github.com/user/repo",
+		SchemaVersion: bot.LinkUpdateSchemaVersion + 1,
+		Events:        []bot.LinkEvent{{Provider: render.ProviderGitHub, Type: "github.unknown"}},
+		TgChatIDs:     []int64{2},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, telegram.messages, 1)
+	require.Equal(t, i18n.T(i18n.Default, i18n.KeyUpdateFallback, "https://github.com/user/repo"),
+		telegram.messages[0]["text"])
+}
+
+func TestUseCase_Update_UsesChatLanguage(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	telegram := &fakeTelegram{}
+	storage := &fakeStorage{settings: map[string]*bot.ChatSettings{"3": {Language: "en"}}}
+	uc := botUC.New(logger, newTestBot(t, telegram), &chatScraperClient{}, storage)
+
+	err := uc.Update(context.Background(), &bot.LinkUpdate{
+		ID:            1,
+		URL:           "https://github.com/user/repo",
+		SchemaVersion: bot.LinkUpdateSchemaVersion,
+		Events: []bot.LinkEvent{{
+			Provider:  render.ProviderGitHub,
+			Type:      render.EventGitHubIssue,
+			Title:     "Crash",
+			URL:       "https://github.com/user/repo/issues/7",
+			Timestamp: time.Now(),
+		}},
+		TgChatIDs: []int64{2, 3},
+	})
+
+	require.NoError(t, err)
+
+	texts := make(map[string]string)
+	for _, msg := range telegram.messages {
+		texts[msg["chat_id"]] = msg["text"]
+	}
+
+	require.Contains(t, texts["2"], "Новые изменения на GitHub")
+	require.Contains(t, texts["3"], "New changes on GitHub")
+}
+
+func TestUseCase_Update_ReportsFailedChats(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	telegram := &fakeTelegram{}
+	client := &chatScraperClient{links: map[int64][]bot.Link{}}
+	uc := botUC.New(logger, newTestBot(t, telegram), client, &fakeStorage{})
+
+	err := uc.Update(context.Background(), &bot.LinkUpdate{
+		ID:        1,
+		URL:       "https://github.com/user/repo",
+		TgChatIDs: []int64{1, 2, 5},
+	})
+
+	var delivery *bothandlers.DeliveryError
+
+	require.True(t, errors.As(err, &delivery), "transient failures must be returned for a retry")
+	require.Equal(t, []int64{5}, delivery.FailedChats())
+	require.Equal(t, []int64{1}, client.deleted, "blocked chat is still cleaned up")
+}
+
+func TestUseCase_Update_RemovedLink(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	telegram := &fakeTelegram{}
+	storage := &fakeStorage{settings: map[string]*bot.ChatSettings{"3": {Language: "en"}}}
+	uc := botUC.New(logger, newTestBot(t, telegram), &chatScraperClient{}, storage)
+
+	err := uc.Update(context.Background(), &bot.LinkUpdate{
+		ID:            1,
+		URL:           "https://example.com/page",
+		SchemaVersion: bot.LinkUpdateSchemaVersion,
+		Events: []bot.LinkEvent{{
+			Type:   bot.EventLinkRemoved,
+			URL:    "https://example.com/page",
+			Reason: bot.RemovalUnsupported,
+		}},
+		TgChatIDs: []int64{3},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, telegram.messages, 1)
+	require.Equal(t, "Link https://example.com/page is no longer tracked: the site is not supported\n",
+		telegram.messages[0]["text"])
+	require.Equal(t, []string{"3"}, storage.deleted, "cached links of the chat should be dropped")
+}
+
+func TestUseCase_Update_SkipsDuplicateEvents(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	telegram := &fakeTelegram{}
+	uc := botUC.New(logger, newTestBot(t, telegram), &chatScraperClient{}, &fakeStorage{})
+
+	upd := bot.LinkUpdate{ID: 1, EventID: "e1", URL: "https://github.com/user/repo",
+		TgChatIDs: []int64{2}}
+
+	// The same event over HTTP, gRPC and Kafka.
+	for range 3 {
+		copied := upd
+		require.NoError(t, uc.Update(context.Background(), &copied))
+	}
+
+	require.Len(t, telegram.messages, 1)
+}
+
+func TestUseCase_Update_ReleasesFailedEvents(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	telegram := &fakeTelegram{}
+	storage := &fakeStorage{}
+	uc := botUC.New(logger, newTestBot(t, telegram), &chatScraperClient{links: map[int64][]bot.Link{}}, storage)
+
+	err := uc.Update(context.Background(), &bot.LinkUpdate{ID: 1, EventID: "e1", URL: "https://github.com/user/repo",
+		TgChatIDs: []int64{5}})
+
+	require.Error(t, err)
+	require.False(t, storage.events["e1"], "a retry of the failed event must not be skipped")
+
+	require.NoError(t, uc.Update(context.Background(), &bot.LinkUpdate{ID: 1, EventID: "e1",
+		URL: "https://github.com/user/repo", TgChatIDs: []int64{2}}))
+	require.True(t, storage.events["e1"])
+}
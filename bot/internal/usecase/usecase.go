@@ -13,11 +13,25 @@ type ScraperClient interface {
 	DeleteChat(ctx context.Context, id int64) error
 	AddLink(ctx context.Context, link bot.AddLinkRequest, id int64) (*bot.Link, error)
 	DeleteLink(ctx context.Context, link bot.RemoveLinkRequest, id int64) (*bot.Link, error)
+	IssueToken(ctx context.Context, id int64, scopes []string) (*bot.IssueTokenResponse, error)
+	RevokeTokens(ctx context.Context, id int64) (int64, error)
+	GetHistory(ctx context.Context, id int64, link string, limit int) ([]bot.HistoryEntry, error)
 }
 
 type Storage interface {
 	SetLinks(ctx context.Context, key string, links []bot.Link) error
 	GetLinks(ctx context.Context, key string) ([]bot.Link, error)
+	DeleteLinks(ctx context.Context, key string) error
+	SetSettings(ctx context.Context, key string, settings *bot.ChatSettings) error
+	GetSettings(ctx context.Context, key string) (*bot.ChatSettings, error)
+	DeleteSettings(ctx context.Context, key string) error
+	SetInactiveChat(ctx context.Context, key string, chat *bot.InactiveChat) error
+	DeleteInactiveChat(ctx context.Context, key string) error
+	SetState(ctx context.Context, key string, state *bot.UserState) error
+	GetState(ctx context.Context, key string) (*bot.UserState, error)
+	DeleteState(ctx context.Context, key string) error
+	ClaimEvent(ctx context.Context, eventID string) (bool, error)
+	ReleaseEvent(ctx context.Context, eventID string) error
 }
 
 type UseCase struct {
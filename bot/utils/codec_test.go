@@ -19,10 +19,9 @@ func TestJSONCodec_Marshal(t *testing.T) {
 		{
 			name: "valid struct",
 			input: bot.LinkUpdate{
-				ID:          123,
-				URL:         "https://example.com",
-				Description: "desc",
-				TgChatIDs:   []int64{1, 2, 3},
+				ID:        123,
+				URL:       "https://example.com",
+				TgChatIDs: []int64{1, 2, 3},
 			},
 			wantErr: false,
 		},
@@ -54,14 +53,14 @@ func TestJSONCodec_Unmarshal(t *testing.T) {
 	}{
 		{
 			name:    "valid JSON",
-			data:    []byte(`{"id":123,"url":"https://example.com","description":"desc","tgChatIds":[1,2,3]}`),
-			want:    &bot.LinkUpdate{ID: 123, URL: "https://example.com", Description: "desc", TgChatIDs: []int64{1, 2, 3}},
+			data:    []byte(`{"id":123,"url":"https://example.com","tgChatIds":[1,2,3]}`),
+			want:    &bot.LinkUpdate{ID: 123, URL: "https://example.com", TgChatIDs: []int64{1, 2, 3}},
 			wantErr: false,
 		},
 		{
 			name:    "missing required field",
-			data:    []byte(`{"url":"no id","description":"desc","tgChatIds":[1]}`),
-			want:    &bot.LinkUpdate{URL: "no id", Description: "desc", TgChatIDs: []int64{1}},
+			data:    []byte(`{"url":"no id","tgChatIds":[1]}`),
+			want:    &bot.LinkUpdate{URL: "no id", TgChatIDs: []int64{1}},
 			wantErr: false,
 		},
 		{
@@ -0,0 +1,3 @@
+ALTER TABLE links ADD COLUMN IF NOT EXISTS last_success TIMESTAMP;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS last_error TEXT;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS last_status INT;
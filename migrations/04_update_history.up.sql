@@ -0,0 +1,18 @@
+
+CREATE TABLE IF NOT EXISTS update_history (
+    id BIGSERIAL PRIMARY KEY,
+    chatid bigint NOT NULL,
+    linkid bigint NOT NULL,
+    link VARCHAR(255) NOT NULL,
+    tags TEXT[],
+    provider VARCHAR(32) NOT NULL DEFAULT '',
+    event_type VARCHAR(64) NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    url TEXT NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (chatid) REFERENCES chats(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_update_history_chatid ON update_history(chatid, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_update_history_link ON update_history(chatid, link, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_update_history_occurred_at ON update_history(occurred_at);
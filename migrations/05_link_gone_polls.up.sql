@@ -0,0 +1 @@
+ALTER TABLE links ADD COLUMN IF NOT EXISTS gone_polls INT NOT NULL DEFAULT 0;
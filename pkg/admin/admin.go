@@ -0,0 +1,27 @@
+// Package admin guards the admin endpoints of the services.
+package admin
+
+import (
+	"pkg/respond"
+
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// New only lets through requests carrying "Authorization: Bearer <token>".
+func New(token string, respondWithError respond.Error) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				respondWithError(w, http.StatusUnauthorized, "admin token required", "Unauthorized",
+					"APIError", "missing or wrong admin token")
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
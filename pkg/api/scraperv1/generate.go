@@ -0,0 +1,6 @@
+// Package scraperv1 is generated from api/proto/scraper/v1/scraper.proto, the
+// contract between the bot and the scraper. Both services import this single
+// copy.
+package scraperv1
+
+//go:generate protoc -I ../../../api/proto --go_out=../.. --go_opt=module=pkg --go-grpc_out=../.. --go-grpc_opt=module=pkg scraper/v1/scraper.proto
@@ -0,0 +1,191 @@
+// Package auth signs and verifies requests between the bot and the scraper.
+// A signature covers the method, the target, the chat the request is made
+// for, the signing time, a random nonce and the body. Receivers remember the
+// nonces they accepted, so a captured request cannot be sent again.
+//
+// Both services share a list of keys. Requests are signed with the first key
+// and accepted when signed with any of them, so a key is rotated by putting
+// the new key in front on the receiving side first, then on the sending side,
+// and dropping the old key afterwards.
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderTimestamp = "X-Service-Timestamp"
+	HeaderNonce     = "X-Service-Nonce"
+	HeaderSignature = "X-Service-Signature"
+	HeaderChatID    = "Tg-Chat-Id"
+
+	// MaxSkew is how far the signing time may be from the receiver's clock.
+	MaxSkew = 5 * time.Minute
+	// NonceTTL is how long a receiver remembers a nonce. A signature passes
+	// Verify while its time is within MaxSkew either way, so a copy of a
+	// request is rejected for as long as it could otherwise be accepted.
+	NonceTTL = 2 * MaxSkew
+)
+
+var (
+	ErrNoKeys           = errors.New("no service keys configured")
+	ErrMissingSignature = errors.New("request is not signed")
+	ErrExpired          = errors.New("signature timestamp is out of range")
+	ErrBadSignature     = errors.New("signature does not match")
+	ErrReplayed         = errors.New("request was already accepted")
+)
+
+// Keys are the shared secrets, the first one signs.
+type Keys []string
+
+// Sign returns the hex HMAC-SHA256 of the request parts with the first key.
+func (k Keys) Sign(method, target, chatID, nonce string, timestamp int64, body []byte) (string, error) {
+	if len(k) == 0 || k[0] == "" {
+		return "", ErrNoKeys
+	}
+
+	return sign(k[0], method, target, chatID, nonce, timestamp, body), nil
+}
+
+// Verify checks the signature against every key and the timestamp against
+// now. Whether the nonce was seen before is up to the caller, see Nonces.
+func (k Keys) Verify(method, target, chatID, timestamp, nonce, signature string, body []byte, now time.Time) error {
+	if signature == "" || timestamp == "" || nonce == "" {
+		return ErrMissingSignature
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrMissingSignature, timestamp)
+	}
+
+	if d := now.Sub(time.Unix(ts, 0)); d > MaxSkew || d < -MaxSkew {
+		return ErrExpired
+	}
+
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrBadSignature
+	}
+
+	for _, key := range k {
+		if key == "" {
+			continue
+		}
+
+		want, _ := hex.DecodeString(sign(key, method, target, chatID, nonce, ts, body))
+		if hmac.Equal(got, want) {
+			return nil
+		}
+	}
+
+	return ErrBadSignature
+}
+
+// SignRequest sets the signature headers on req. The body is read and put
+// back so the request can still be sent and retried.
+func (k Keys) SignRequest(req *http.Request, now time.Time) error {
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+
+	nonce, err := NewNonce()
+	if err != nil {
+		return err
+	}
+
+	timestamp := now.Unix()
+
+	signature, err := k.Sign(req.Method, req.URL.RequestURI(), req.Header.Get(HeaderChatID), nonce, timestamp, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set(HeaderSignature, signature)
+
+	return nil
+}
+
+// VerifyRequest checks the signature headers of an incoming request.
+func (k Keys) VerifyRequest(req *http.Request, now time.Time) error {
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+
+	return k.Verify(req.Method, req.URL.RequestURI(), req.Header.Get(HeaderChatID), req.Header.Get(HeaderTimestamp),
+		req.Header.Get(HeaderNonce), req.Header.Get(HeaderSignature), body, now)
+}
+
+// NewNonce returns a random nonce for one request.
+func NewNonce() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("cannot generate nonce: %w", err)
+	}
+
+	return hex.EncodeToString(nonce), nil
+}
+
+// Transport signs every request before handing it to next.
+type Transport struct {
+	Keys Keys
+	Next http.RoundTripper
+}
+
+func NewTransport(keys Keys, next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Transport{Keys: keys, Next: next}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the caller's request.
+	signed := req.Clone(req.Context())
+
+	if err := t.Keys.SignRequest(signed, time.Now()); err != nil {
+		return nil, fmt.Errorf("cannot sign request: %w", err)
+	}
+
+	return t.Next.RoundTrip(signed)
+}
+
+func sign(key, method, target, chatID, nonce string, timestamp int64, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, []byte(key))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%d\n%s\n%x", method, target, chatID, timestamp, nonce, bodyHash)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read request body: %w", err)
+	}
+
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
@@ -0,0 +1,244 @@
+package auth_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"pkg/auth"
+
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const nonce = "3f1c0a9e5b7d4e2a"
+
+func TestKeys_Verify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"id":1}`)
+
+	signature, err := auth.Keys{"old"}.Sign(http.MethodPost, "/updates", "1", nonce, now.Unix(), body)
+	require.NoError(t, err)
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name      string
+		keys      auth.Keys
+		method    string
+		target    string
+		chatID    string
+		timestamp string
+		signature string
+		body      []byte
+		now       time.Time
+		err       error
+	}{
+		{"valid", auth.Keys{"old"}, http.MethodPost, "/updates", "1", timestamp, signature, body, now, nil},
+		{"rotated key still accepted", auth.Keys{"new", "old"}, http.MethodPost, "/updates", "1", timestamp, signature,
+			body, now, nil},
+		{"retired key rejected", auth.Keys{"new"}, http.MethodPost, "/updates", "1", timestamp, signature, body, now,
+			auth.ErrBadSignature},
+		{"tampered body", auth.Keys{"old"}, http.MethodPost, "/updates", "1", timestamp, signature, []byte(`{"id":2}`),
+			now, auth.ErrBadSignature},
+		{"tampered target", auth.Keys{"old"}, http.MethodPost, "/updates?x=1", "1", timestamp, signature, body, now,
+			auth.ErrBadSignature},
+		{"tampered method", auth.Keys{"old"}, http.MethodDelete, "/updates", "1", timestamp, signature, body, now,
+			auth.ErrBadSignature},
+		{"tampered chat id", auth.Keys{"old"}, http.MethodPost, "/updates", "2", timestamp, signature, body, now,
+			auth.ErrBadSignature},
+		{"tampered timestamp", auth.Keys{"old"}, http.MethodPost, "/updates", "1", strconv.FormatInt(now.Unix()+1, 10),
+			signature, body, now, auth.ErrBadSignature},
+		{"garbage signature", auth.Keys{"old"}, http.MethodPost, "/updates", "1", timestamp, "not-hex", body, now,
+			auth.ErrBadSignature},
+		{"missing signature", auth.Keys{"old"}, http.MethodPost, "/updates", "1", timestamp, "", body, now,
+			auth.ErrMissingSignature},
+		{"missing timestamp", auth.Keys{"old"}, http.MethodPost, "/updates", "1", "", signature, body, now,
+			auth.ErrMissingSignature},
+		{"replayed too late", auth.Keys{"old"}, http.MethodPost, "/updates", "1", timestamp, signature, body,
+			now.Add(auth.MaxSkew + time.Second), auth.ErrExpired},
+		{"signed in the future", auth.Keys{"old"}, http.MethodPost, "/updates", "1", timestamp, signature, body,
+			now.Add(-auth.MaxSkew - time.Second), auth.ErrExpired},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.keys.Verify(tc.method, tc.target, tc.chatID, tc.timestamp, nonce, tc.signature, tc.body, tc.now)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestKeys_VerifyNonce(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	signature, err := auth.Keys{"key"}.Sign(http.MethodPost, "/updates", "1", nonce, now.Unix(), nil)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, auth.Keys{"key"}.Verify(http.MethodPost, "/updates", "1", timestamp, "other", signature, nil,
+		now), auth.ErrBadSignature)
+	require.ErrorIs(t, auth.Keys{"key"}.Verify(http.MethodPost, "/updates", "1", timestamp, "", signature, nil, now),
+		auth.ErrMissingSignature)
+}
+
+func TestKeys_SignWithoutKeys(t *testing.T) {
+	_, err := auth.Keys{}.Sign(http.MethodGet, "/", "", nonce, 0, nil)
+	require.ErrorIs(t, err, auth.ErrNoKeys)
+}
+
+func TestTransport(t *testing.T) {
+	var received string
+
+	keys := auth.Keys{"new", "old"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := keys.VerifyRequest(r, time.Now()); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	send := func(client *http.Client, req *http.Request) int {
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	newRequest := func(body string) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/updates", strings.NewReader(body))
+		require.NoError(t, err)
+
+		return req
+	}
+
+	t.Run("signed with the current key", func(t *testing.T) {
+		client := &http.Client{Transport: auth.NewTransport(auth.Keys{"new"}, nil)}
+
+		require.Equal(t, http.StatusOK, send(client, newRequest(`{"id":1}`)))
+		require.Equal(t, `{"id":1}`, received)
+	})
+
+	t.Run("signed with the previous key", func(t *testing.T) {
+		client := &http.Client{Transport: auth.NewTransport(auth.Keys{"old"}, nil)}
+
+		require.Equal(t, http.StatusOK, send(client, newRequest(`{"id":2}`)))
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, send(http.DefaultClient, newRequest(`{"id":3}`)))
+	})
+
+	t.Run("signed with an unknown key", func(t *testing.T) {
+		client := &http.Client{Transport: auth.NewTransport(auth.Keys{"stolen"}, nil)}
+
+		require.Equal(t, http.StatusUnauthorized, send(client, newRequest(`{"id":4}`)))
+	})
+
+	t.Run("signature copied onto another body", func(t *testing.T) {
+		signed := newRequest(`{"id":5}`)
+		require.NoError(t, auth.Keys{"new"}.SignRequest(signed, time.Now()))
+
+		forged := newRequest(`{"id":6}`)
+		forged.Header = signed.Header.Clone()
+
+		require.Equal(t, http.StatusUnauthorized, send(http.DefaultClient, forged))
+	})
+
+	t.Run("signature copied onto another chat", func(t *testing.T) {
+		signed := newRequest(`{"id":7}`)
+		signed.Header.Set(auth.HeaderChatID, "1")
+		require.NoError(t, auth.Keys{"new"}.SignRequest(signed, time.Now()))
+
+		require.Equal(t, http.StatusOK, send(http.DefaultClient, signed))
+
+		forged := newRequest(`{"id":7}`)
+		forged.Header = signed.Header.Clone()
+		forged.Header.Set(auth.HeaderChatID, "2")
+
+		require.Equal(t, http.StatusUnauthorized, send(http.DefaultClient, forged))
+	})
+}
+
+func TestInterceptors(t *testing.T) {
+	const method = "/scraper.v1.ScraperService/ListLinks"
+
+	// call signs sent and hands received to the server, as if it was
+	// changed on the way.
+	call := func(clientKeys, serverKeys auth.Keys, sent, received proto.Message) error {
+		var outgoing metadata.MD
+
+		invoker := func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+			outgoing, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		}
+
+		if err := auth.UnaryClientInterceptor(clientKeys)(context.Background(), method, sent, nil, nil,
+			invoker); err != nil {
+			return err
+		}
+
+		ctx := metadata.NewIncomingContext(context.Background(), outgoing)
+
+		_, err := auth.UnaryServerInterceptor(serverKeys, auth.NewMemoryNonces())(ctx, received,
+			&grpc.UnaryServerInfo{FullMethod: method},
+			func(context.Context, any) (any, error) { return nil, nil })
+
+		return err
+	}
+
+	req := wrapperspb.Int64(1)
+
+	require.NoError(t, call(auth.Keys{"old"}, auth.Keys{"new", "old"}, req, wrapperspb.Int64(1)))
+	require.Equal(t, codes.Unauthenticated, status.Code(call(auth.Keys{"stolen"}, auth.Keys{"new", "old"}, req,
+		req)))
+	require.Equal(t, codes.Unauthenticated, status.Code(call(auth.Keys{"old"}, auth.Keys{"old"}, req,
+		wrapperspb.Int64(2))), "a request changed on the way must be rejected")
+
+	_, err := auth.UnaryServerInterceptor(auth.Keys{"new"}, auth.NewMemoryNonces())(context.Background(), nil,
+		&grpc.UnaryServerInfo{FullMethod: method}, func(context.Context, any) (any, error) { return nil, nil })
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestInterceptors_RejectReplay(t *testing.T) {
+	const method = "/scraper.v1.ScraperService/ListLinks"
+
+	var outgoing metadata.MD
+
+	invoker := func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+
+	req := wrapperspb.Int64(1)
+	require.NoError(t, auth.UnaryClientInterceptor(auth.Keys{"key"})(context.Background(), method, req, nil, nil,
+		invoker))
+
+	server := auth.UnaryServerInterceptor(auth.Keys{"key"}, auth.NewMemoryNonces())
+	serve := func() error {
+		_, err := server(metadata.NewIncomingContext(context.Background(), outgoing), req,
+			&grpc.UnaryServerInfo{FullMethod: method}, func(context.Context, any) (any, error) { return nil, nil })
+
+		return err
+	}
+
+	require.NoError(t, serve())
+	require.Equal(t, codes.Unauthenticated, status.Code(serve()), "a captured call must not be accepted twice")
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"pkg/respond"
+
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Middleware only lets through requests signed with one of keys by the other
+// service whose nonce was not accepted before.
+func Middleware(log *slog.Logger, keys Keys, nonces Nonces,
+	respondWithError respond.Error) func(next http.Handler) http.Handler {
+	reject := func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Warn("Rejected unauthenticated request",
+			slog.String("path", r.URL.Path),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("error", err.Error()),
+		)
+
+		respondWithError(w, http.StatusUnauthorized, "service signature required", "Unauthorized",
+			"APIError", err.Error())
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := keys.VerifyRequest(r, time.Now()); err != nil {
+				reject(w, r, err)
+				return
+			}
+
+			fresh, err := nonces.ClaimNonce(r.Context(), r.Header.Get(HeaderNonce), NonceTTL)
+			if err != nil {
+				log.Error("Failed to check request nonce", slog.String("path", r.URL.Path),
+					slog.String("error", err.Error()))
+
+				respondWithError(w, http.StatusServiceUnavailable, "cannot check request nonce",
+					"StatusServiceUnavailable", "APIError", err.Error())
+
+				return
+			}
+
+			if !fresh {
+				reject(w, r, ErrReplayed)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package auth_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"pkg/auth"
+
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failingNonces struct{}
+
+func (failingNonces) ClaimNonce(context.Context, string, time.Duration) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func newMiddleware(nonces auth.Nonces) http.Handler {
+	log := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	return auth.Middleware(log, auth.Keys{"key"}, nonces,
+		func(writer http.ResponseWriter, statusCode int, description, _, _, _ string) {
+			http.Error(writer, description, statusCode)
+		})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func signedRequest(t *testing.T) *http.Request {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/links", strings.NewReader(`{"link":"https://go.dev"}`))
+	req.Header.Set(auth.HeaderChatID, "1")
+	require.NoError(t, auth.Keys{"key"}.SignRequest(req, time.Now()))
+
+	return req
+}
+
+func serve(handler http.Handler, req *http.Request) int {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+// replay sends a copy of req, as an attacker who captured it would.
+func replay(req *http.Request) *http.Request {
+	body, _ := io.ReadAll(req.Body)
+	req.Body = io.NopCloser(strings.NewReader(string(body)))
+
+	copied := httptest.NewRequest(req.Method, req.URL.RequestURI(), strings.NewReader(string(body)))
+	copied.Header = req.Header.Clone()
+
+	return copied
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("signed", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, serve(newMiddleware(auth.NewMemoryNonces()), signedRequest(t)))
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/links", strings.NewReader(`{}`))
+
+		require.Equal(t, http.StatusUnauthorized, serve(newMiddleware(auth.NewMemoryNonces()), req))
+	})
+
+	t.Run("replayed", func(t *testing.T) {
+		handler := newMiddleware(auth.NewMemoryNonces())
+		req := signedRequest(t)
+		copied := replay(req)
+
+		require.Equal(t, http.StatusOK, serve(handler, req))
+		require.Equal(t, http.StatusUnauthorized, serve(handler, copied))
+	})
+
+	t.Run("nonce store down", func(t *testing.T) {
+		require.Equal(t, http.StatusServiceUnavailable, serve(newMiddleware(failingNonces{}), signedRequest(t)))
+	})
+}
+
+func TestMemoryNonces(t *testing.T) {
+	nonces := auth.NewMemoryNonces()
+
+	fresh, err := nonces.ClaimNonce(context.Background(), "a", time.Hour)
+	require.NoError(t, err)
+	require.True(t, fresh)
+
+	fresh, err = nonces.ClaimNonce(context.Background(), "a", time.Hour)
+	require.NoError(t, err)
+	require.False(t, fresh)
+
+	fresh, err = nonces.ClaimNonce(context.Background(), "b", -time.Second)
+	require.NoError(t, err)
+	require.True(t, fresh)
+
+	fresh, err = nonces.ClaimNonce(context.Background(), "b", time.Hour)
+	require.NoError(t, err)
+	require.True(t, fresh, "an expired nonce is forgotten")
+}
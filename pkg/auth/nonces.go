@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"github.com/gomodule/redigo/redis"
+
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const noncePrefix = "nonce:"
+
+// Nonces remembers the nonces of accepted requests.
+type Nonces interface {
+	// ClaimNonce remembers nonce for ttl and reports whether it was new.
+	ClaimNonce(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// RedisNonces keeps nonces in Redis, so that all replicas of a service
+// reject a request any of them accepted. The check and the write are a
+// single SET NX.
+type RedisNonces struct {
+	pool *redis.Pool
+}
+
+func NewRedisNonces(address string) *RedisNonces {
+	return &RedisNonces{
+		pool: &redis.Pool{
+			MaxIdle:     10,
+			IdleTimeout: 240 * time.Second,
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial("tcp", address)
+			},
+			TestOnBorrow: func(c redis.Conn, _ time.Time) error {
+				_, err := c.Do("PING")
+				return err
+			},
+		},
+	}
+}
+
+func (n *RedisNonces) ClaimNonce(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	const op = "auth.RedisNonces.ClaimNonce"
+
+	conn, err := n.pool.GetContext(ctx)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.Close()
+
+	_, err = redis.String(redis.DoContext(conn, ctx, "SET", noncePrefix+nonce, 1, "NX", "PX", ttl.Milliseconds()))
+
+	switch {
+	case errors.Is(err, redis.ErrNil):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("%s: %w", op, err)
+	default:
+		return true, nil
+	}
+}
+
+func (n *RedisNonces) Close() error {
+	return n.pool.Close()
+}
+
+// MemoryNonces keeps nonces in the process. It only protects a service that
+// runs as a single replica.
+type MemoryNonces struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+	now  func() time.Time
+}
+
+func NewMemoryNonces() *MemoryNonces {
+	return &MemoryNonces{seen: make(map[string]time.Time), now: time.Now}
+}
+
+func (n *MemoryNonces) ClaimNonce(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.now()
+
+	for seen, expires := range n.seen {
+		if !now.Before(expires) {
+			delete(n.seen, seen)
+		}
+	}
+
+	if _, ok := n.seen[nonce]; ok {
+		return false, nil
+	}
+
+	n.seen[nonce] = now.Add(ttl)
+
+	return true, nil
+}
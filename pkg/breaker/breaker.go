@@ -0,0 +1,273 @@
+// Package breaker wraps gobreaker circuit breakers so that their state is
+// logged, exported to Prometheus and can be viewed and reset by an admin.
+package breaker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sony/gobreaker"
+
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrNotFound = errors.New("circuit breaker not found")
+
+// Config describes a circuit breaker. It opens when at least
+// FailureRateThreshold percent of the calls of the last WindowSize failed,
+// given the window holds MinimumCalls calls. After Timeout it lets
+// HalfOpenCalls calls through and closes once all of them succeed.
+type Config struct {
+	WindowSize           time.Duration `yaml:"window_size" env-default:"1m"`
+	MinimumCalls         uint32        `yaml:"minimum_calls" env-default:"10"`
+	FailureRateThreshold float64       `yaml:"failure_rate_threshold" env-default:"50"`
+	HalfOpenCalls        uint32        `yaml:"half_open_calls" env-default:"3"`
+	Timeout              time.Duration `yaml:"timeout" env-default:"30s"`
+}
+
+var stateGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "myapp",
+		Name:      "circuit_breaker_state",
+		Help:      "State of the circuit breaker: 0 closed, 1 half-open, 2 open",
+	},
+	[]string{"name"},
+)
+
+func init() {
+	prometheus.MustRegister(stateGauge)
+}
+
+const (
+	defaultWindowSize           = time.Minute
+	defaultMinimumCalls         = 10
+	defaultFailureRateThreshold = 50
+	defaultHalfOpenCalls        = 3
+	defaultTimeout              = 30 * time.Second
+)
+
+// Breaker trips on the failure rate of a time-based sliding window. The
+// states, the open timeout and the half-open calls are left to a
+// gobreaker.CircuitBreaker, which Reset swaps for a new one as gobreaker has
+// no reset.
+type Breaker struct {
+	log          *slog.Logger
+	name         string
+	cfg          atomic.Pointer[Config]
+	isSuccessful func(err error) bool
+	window       *window
+	cb           atomic.Pointer[gobreaker.CircuitBreaker]
+}
+
+// New returns the breaker called name. isSuccessful tells which errors say
+// nothing about the endpoint's health; when nil only nil errors are
+// successes. Zero fields of cfg take the defaults of Config.
+func New(log *slog.Logger, name string, cfg Config, isSuccessful func(err error) bool) *Breaker {
+	cfg = withDefaults(cfg)
+
+	if isSuccessful == nil {
+		isSuccessful = func(err error) bool { return err == nil }
+	}
+
+	b := &Breaker{log: log, name: name, isSuccessful: isSuccessful, window: newWindow(cfg.WindowSize)}
+
+	b.cfg.Store(&cfg)
+	b.cb.Store(b.newCircuitBreaker())
+	stateGauge.WithLabelValues(name).Set(float64(gobreaker.StateClosed))
+
+	return b
+}
+
+func withDefaults(cfg Config) Config {
+	if cfg.WindowSize <= 0 {
+		cfg.WindowSize = defaultWindowSize
+	}
+
+	if cfg.MinimumCalls == 0 {
+		cfg.MinimumCalls = defaultMinimumCalls
+	}
+
+	if cfg.FailureRateThreshold <= 0 || cfg.FailureRateThreshold > 100 {
+		cfg.FailureRateThreshold = defaultFailureRateThreshold
+	}
+
+	if cfg.HalfOpenCalls == 0 {
+		cfg.HalfOpenCalls = defaultHalfOpenCalls
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	return cfg
+}
+
+func (b *Breaker) newCircuitBreaker() *gobreaker.CircuitBreaker {
+	cfg := b.cfg.Load()
+
+	return gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        b.name,
+		MaxRequests: cfg.HalfOpenCalls,
+		// Only clears the counts shown to admins, the window trips the breaker.
+		Interval:      cfg.WindowSize,
+		Timeout:       cfg.Timeout,
+		ReadyToTrip:   b.readyToTrip,
+		OnStateChange: b.changed,
+		IsSuccessful:  b.isSuccessful,
+	})
+}
+
+// readyToTrip is asked by gobreaker after every failure while closed.
+func (b *Breaker) readyToTrip(gobreaker.Counts) bool {
+	cfg := b.cfg.Load()
+	calls, failures := b.window.totals()
+
+	return calls >= cfg.MinimumCalls &&
+		float64(failures)*100 >= cfg.FailureRateThreshold*float64(calls)
+}
+
+func (b *Breaker) changed(name string, from, to gobreaker.State) {
+	stateGauge.WithLabelValues(name).Set(float64(to))
+
+	// Calls made before the breaker opened must not trip it again.
+	if to == gobreaker.StateClosed {
+		b.window.reset()
+	}
+
+	log := b.log.Info
+	if to == gobreaker.StateOpen {
+		log = b.log.Warn
+	}
+
+	log("circuit breaker state changed", slog.String("breaker", name),
+		slog.String("from", from.String()), slog.String("to", to.String()))
+}
+
+// Execute runs req unless the breaker is open or out of half-open calls, in
+// which case it returns gobreaker.ErrOpenState or gobreaker.ErrTooManyRequests.
+func (b *Breaker) Execute(req func() (any, error)) (any, error) {
+	return b.cb.Load().Execute(func() (any, error) {
+		result, err := req()
+		b.window.record(!b.isSuccessful(err))
+
+		return result, err
+	})
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+func (b *Breaker) State() gobreaker.State {
+	return b.cb.Load().State()
+}
+
+func (b *Breaker) Counts() gobreaker.Counts {
+	return b.cb.Load().Counts()
+}
+
+// Reset closes the breaker and clears its window.
+func (b *Breaker) Reset() {
+	from := b.cb.Swap(b.newCircuitBreaker()).State()
+	b.window.reset()
+
+	if from != gobreaker.StateClosed {
+		b.changed(b.name, from, gobreaker.StateClosed)
+	}
+}
+
+// Configure switches the breaker to cfg, e.g. on a config reload. A new
+// minimum or threshold applies to the next failure; a new window size, open
+// timeout or number of half-open calls starts the breaker over closed.
+func (b *Breaker) Configure(cfg Config) {
+	cfg = withDefaults(cfg)
+
+	old := b.cfg.Swap(&cfg)
+	if old.WindowSize == cfg.WindowSize && old.HalfOpenCalls == cfg.HalfOpenCalls && old.Timeout == cfg.Timeout {
+		return
+	}
+
+	b.window.resize(cfg.WindowSize)
+	b.Reset()
+}
+
+// Status is the view of a breaker served to admins.
+type Status struct {
+	Name                 string `json:"name"`
+	Breaker              string `json:"breaker"`
+	State                string `json:"state"`
+	Requests             uint32 `json:"requests"`
+	TotalFailures        uint32 `json:"totalFailures"`
+	ConsecutiveFailures  uint32 `json:"consecutiveFailures"`
+	ConsecutiveSuccesses uint32 `json:"consecutiveSuccesses"`
+	WindowCalls          uint32 `json:"windowCalls"`
+	WindowFailures       uint32 `json:"windowFailures"`
+}
+
+// Registry keeps the breakers of a service by short names like "github".
+type Registry struct {
+	mu       sync.RWMutex
+	breakers map[string]*Breaker
+}
+
+func NewRegistry() *Registry {
+	return &Registry{breakers: make(map[string]*Breaker)}
+}
+
+func (r *Registry) Add(name string, b *Breaker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.breakers[name] = b
+}
+
+// List returns the breakers sorted by name.
+func (r *Registry) List() []Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(r.breakers))
+
+	for name, b := range r.breakers {
+		statuses = append(statuses, statusOf(name, b))
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+
+	return statuses
+}
+
+func (r *Registry) Reset(name string) (Status, error) {
+	r.mu.RLock()
+	b, ok := r.breakers[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		return Status{}, ErrNotFound
+	}
+
+	b.Reset()
+	b.log.Info("circuit breaker reset by admin", slog.String("breaker", b.Name()))
+
+	return statusOf(name, b), nil
+}
+
+func statusOf(name string, b *Breaker) Status {
+	counts := b.Counts()
+	calls, failures := b.window.totals()
+
+	return Status{
+		Name:                 name,
+		Breaker:              b.Name(),
+		State:                b.State().String(),
+		Requests:             counts.Requests,
+		TotalFailures:        counts.TotalFailures,
+		ConsecutiveFailures:  counts.ConsecutiveFailures,
+		ConsecutiveSuccesses: counts.ConsecutiveSuccesses,
+		WindowCalls:          calls,
+		WindowFailures:       failures,
+	}
+}
@@ -0,0 +1,265 @@
+package breaker
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/require"
+
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+var errBoom = errors.New("boom")
+
+func newBreaker(name string, cfg Config) *Breaker {
+	return New(slog.New(slog.NewJSONHandler(io.Discard, nil)), name, cfg, nil)
+}
+
+func fail(b *Breaker) error {
+	_, err := b.Execute(func() (any, error) { return nil, errBoom })
+	return err
+}
+
+func succeed(b *Breaker) error {
+	_, err := b.Execute(func() (any, error) { return nil, nil })
+	return err
+}
+
+// clock is a time source for the window that only moves when told to.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestBreaker_ExportsState(t *testing.T) {
+	b := newBreaker("test-exports", Config{MinimumCalls: 1})
+	gauge := stateGauge.WithLabelValues("test-exports")
+
+	require.InDelta(t, float64(gobreaker.StateClosed), testutil.ToFloat64(gauge), 0)
+
+	require.ErrorIs(t, fail(b), errBoom)
+
+	require.Equal(t, gobreaker.StateOpen, b.State())
+	require.InDelta(t, float64(gobreaker.StateOpen), testutil.ToFloat64(gauge), 0)
+
+	b.Reset()
+
+	require.Equal(t, gobreaker.StateClosed, b.State())
+	require.Zero(t, b.Counts().Requests)
+	require.InDelta(t, float64(gobreaker.StateClosed), testutil.ToFloat64(gauge), 0)
+}
+
+func TestBreaker_TripsOnFailureRate(t *testing.T) {
+	b := newBreaker("test-rate", Config{MinimumCalls: 4, FailureRateThreshold: 50})
+
+	require.NoError(t, succeed(b))
+	require.NoError(t, succeed(b))
+	require.Error(t, fail(b))
+	require.Equal(t, gobreaker.StateClosed, b.State(), "3 calls are below the minimum")
+
+	require.Error(t, fail(b))
+	require.Equal(t, gobreaker.StateOpen, b.State(), "2 of 4 calls failed")
+	require.ErrorIs(t, succeed(b), gobreaker.ErrOpenState)
+}
+
+func TestBreaker_StaysClosedBelowThreshold(t *testing.T) {
+	b := newBreaker("test-below", Config{MinimumCalls: 4, FailureRateThreshold: 50})
+
+	for range 10 {
+		require.NoError(t, succeed(b))
+		require.NoError(t, succeed(b))
+		require.Error(t, fail(b))
+	}
+
+	require.Equal(t, gobreaker.StateClosed, b.State(), "a third of the calls failed")
+}
+
+func TestBreaker_WindowSlides(t *testing.T) {
+	c := &clock{now: time.Unix(1_700_000_000, 0)}
+
+	b := newBreaker("test-slides", Config{WindowSize: 10 * time.Second, MinimumCalls: 2})
+	b.window.now = c.Now
+
+	require.Error(t, fail(b))
+
+	c.Add(11 * time.Second)
+
+	require.Error(t, fail(b))
+	require.Equal(t, gobreaker.StateClosed, b.State(), "the first failure slid out of the window")
+
+	c.Add(5 * time.Second)
+
+	require.Error(t, fail(b))
+	require.Equal(t, gobreaker.StateOpen, b.State())
+}
+
+func TestBreaker_RecoversAfterTimeout(t *testing.T) {
+	b := newBreaker("test-recovers", Config{MinimumCalls: 2, HalfOpenCalls: 2, Timeout: 50 * time.Millisecond})
+
+	require.Error(t, fail(b))
+	require.Error(t, fail(b))
+
+	opened := time.Now()
+
+	require.Equal(t, gobreaker.StateOpen, b.State())
+	require.ErrorIs(t, succeed(b), gobreaker.ErrOpenState)
+
+	require.Eventually(t, func() bool { return b.State() == gobreaker.StateHalfOpen },
+		time.Second, 5*time.Millisecond)
+	require.GreaterOrEqual(t, time.Since(opened), 50*time.Millisecond, "the breaker must stay open for Timeout")
+
+	require.NoError(t, succeed(b))
+	require.Equal(t, gobreaker.StateHalfOpen, b.State(), "one of two half-open calls succeeded")
+
+	require.NoError(t, succeed(b))
+	require.Equal(t, gobreaker.StateClosed, b.State())
+
+	require.Error(t, fail(b))
+	require.Equal(t, gobreaker.StateClosed, b.State(), "the window starts over once closed")
+}
+
+func TestBreaker_ReopensOnHalfOpenFailure(t *testing.T) {
+	b := newBreaker("test-reopens", Config{MinimumCalls: 1, HalfOpenCalls: 2, Timeout: 50 * time.Millisecond})
+
+	require.Error(t, fail(b))
+	require.Eventually(t, func() bool { return b.State() == gobreaker.StateHalfOpen },
+		time.Second, 5*time.Millisecond)
+
+	require.ErrorIs(t, fail(b), errBoom)
+	require.Equal(t, gobreaker.StateOpen, b.State())
+
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, gobreaker.StateOpen, b.State(), "a new timeout starts on reopening")
+}
+
+func TestBreaker_LimitsHalfOpenCalls(t *testing.T) {
+	b := newBreaker("test-half-open", Config{MinimumCalls: 1, HalfOpenCalls: 1, Timeout: 10 * time.Millisecond})
+
+	require.Error(t, fail(b))
+	require.Eventually(t, func() bool { return b.State() == gobreaker.StateHalfOpen },
+		time.Second, time.Millisecond)
+
+	release := make(chan struct{})
+	done := make(chan error)
+
+	go func() {
+		_, err := b.Execute(func() (any, error) {
+			<-release
+			return nil, nil
+		})
+		done <- err
+	}()
+
+	require.Eventually(t, func() bool { return b.Counts().Requests == 1 }, time.Second, time.Millisecond)
+	require.ErrorIs(t, succeed(b), gobreaker.ErrTooManyRequests)
+
+	close(release)
+	require.NoError(t, <-done)
+	require.Equal(t, gobreaker.StateClosed, b.State())
+}
+
+func TestBreaker_IsSuccessful(t *testing.T) {
+	b := New(slog.New(slog.NewJSONHandler(io.Discard, nil)), "test-successful", Config{MinimumCalls: 1},
+		func(err error) bool { return err == nil || errors.Is(err, errBoom) })
+
+	for range 3 {
+		require.Error(t, fail(b))
+	}
+
+	require.Equal(t, gobreaker.StateClosed, b.State())
+}
+
+func TestBreaker_Configure(t *testing.T) {
+	b := newBreaker("test-configure", Config{MinimumCalls: 4, Timeout: time.Minute})
+
+	require.Error(t, fail(b))
+	require.Error(t, fail(b))
+	require.Equal(t, gobreaker.StateClosed, b.State())
+
+	b.Configure(Config{MinimumCalls: 3, Timeout: time.Minute})
+
+	require.Error(t, fail(b))
+	require.Equal(t, gobreaker.StateOpen, b.State(), "a lower minimum applies to the calls in the window")
+
+	b.Configure(Config{MinimumCalls: 3, Timeout: 20 * time.Millisecond})
+
+	require.Equal(t, gobreaker.StateClosed, b.State(), "a new timeout starts the breaker over")
+	calls, _ := b.window.totals()
+	require.Zero(t, calls)
+
+	for range 3 {
+		require.Error(t, fail(b))
+	}
+
+	require.Equal(t, gobreaker.StateOpen, b.State())
+	require.Eventually(t, func() bool { return b.State() == gobreaker.StateHalfOpen },
+		time.Second, 5*time.Millisecond)
+}
+
+func TestWindow(t *testing.T) {
+	c := &clock{now: time.Unix(1_700_000_000, 0)}
+
+	w := newWindow(10 * time.Second)
+	w.now = c.Now
+
+	w.record(true)
+	c.Add(4 * time.Second)
+	w.record(false)
+	w.record(false)
+
+	calls, failures := w.totals()
+	require.Equal(t, uint32(3), calls)
+	require.Equal(t, uint32(1), failures)
+
+	c.Add(7 * time.Second)
+
+	calls, failures = w.totals()
+	require.Equal(t, uint32(2), calls, "the first bucket slid out")
+	require.Zero(t, failures)
+
+	c.Add(time.Hour)
+	w.record(true)
+
+	calls, failures = w.totals()
+	require.Equal(t, uint32(1), calls, "a reused bucket must not keep old calls")
+	require.Equal(t, uint32(1), failures)
+
+	w.reset()
+
+	calls, _ = w.totals()
+	require.Zero(t, calls)
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	registry.Add("stackoverflow", newBreaker("test-stackoverflow", Config{MinimumCalls: 1}))
+	registry.Add("github", newBreaker("test-github", Config{MinimumCalls: 1}))
+
+	require.Error(t, fail(registry.breakers["github"]))
+
+	list := registry.List()
+	require.Len(t, list, 2)
+	require.Equal(t, "github", list[0].Name)
+	require.Equal(t, "open", list[0].State)
+	require.Equal(t, uint32(1), list[0].WindowFailures)
+	require.Equal(t, "stackoverflow", list[1].Name)
+	require.Equal(t, "closed", list[1].State)
+
+	status, err := registry.Reset("github")
+	require.NoError(t, err)
+	require.Equal(t, "closed", status.State)
+	require.Zero(t, status.WindowCalls)
+
+	_, err = registry.Reset("unknown")
+	require.ErrorIs(t, err, ErrNotFound)
+}
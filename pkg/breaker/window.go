@@ -0,0 +1,85 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// windowBuckets is how many buckets a window is kept in: calls older than
+// the window leave it a bucket at a time.
+const windowBuckets = 10
+
+// window counts the calls and failures of the last size.
+type window struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	size    time.Duration
+	width   time.Duration
+	buckets [windowBuckets]bucket
+}
+
+type bucket struct {
+	start    time.Time
+	calls    uint32
+	failures uint32
+}
+
+func newWindow(size time.Duration) *window {
+	w := &window{now: time.Now}
+	w.resize(size)
+
+	return w
+}
+
+// resize empties the window and makes it size long.
+func (w *window) resize(size time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.size = size
+	w.width = max(size/windowBuckets, 1)
+	w.buckets = [windowBuckets]bucket{}
+}
+
+func (w *window) record(failed bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	start := now.Truncate(w.width)
+	b := &w.buckets[start.UnixNano()/int64(w.width)%windowBuckets]
+
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+
+	b.calls++
+
+	if failed {
+		b.failures++
+	}
+}
+
+// totals sums the buckets that have not slid out of the window.
+func (w *window) totals() (calls, failures uint32) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+
+	for _, b := range w.buckets {
+		if b.calls > 0 && now.Sub(b.start) < w.size {
+			calls += b.calls
+			failures += b.failures
+		}
+	}
+
+	return calls, failures
+}
+
+func (w *window) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buckets = [windowBuckets]bucket{}
+}
@@ -0,0 +1,59 @@
+// Package breakers serves the admin view of the circuit breakers in a
+// breaker.Registry.
+package breakers
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"pkg/breaker"
+	"pkg/respond"
+
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+type Registry interface {
+	List() []breaker.Status
+	Reset(name string) (breaker.Status, error)
+}
+
+// List handles GET /admin/breakers.
+func List(registry Registry) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		render.JSON(writer, request, registry.List())
+	}
+}
+
+// Reset handles POST /admin/breakers/{name}/reset.
+func Reset(log *slog.Logger, registry Registry, respondWithError respond.Error) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const op = "handlers.breakers.reset"
+
+		log := log.With(slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(request.Context())))
+
+		name := chi.URLParam(request, "name")
+
+		status, err := registry.Reset(name)
+		if err != nil {
+			log.Error("failed to reset circuit breaker", slog.String("breaker", name),
+				slog.String("error", err.Error()))
+
+			if errors.Is(err, breaker.ErrNotFound) {
+				respondWithError(writer, http.StatusNotFound, "circuit breaker does not exist", "StatusNotFound",
+					"APIError", err.Error())
+
+				return
+			}
+
+			respondWithError(writer, http.StatusInternalServerError, "failed to reset circuit breaker",
+				"StatusInternalServerError", "APIError", err.Error())
+
+			return
+		}
+
+		render.JSON(writer, request, status)
+	}
+}
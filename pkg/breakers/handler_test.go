@@ -0,0 +1,68 @@
+package breakers_test
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/require"
+	"pkg/breaker"
+	"pkg/breakers"
+
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func respondWithError(writer http.ResponseWriter, statusCode int, description, _, _, _ string) {
+	http.Error(writer, description, statusCode)
+}
+
+func newRouter() (*chi.Mux, *breaker.Breaker) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	cb := breaker.New(logger, "handler-test", breaker.Config{MinimumCalls: 1}, nil)
+	registry := breaker.NewRegistry()
+	registry.Add("scraper", cb)
+
+	router := chi.NewRouter()
+	router.Get("/admin/breakers", breakers.List(registry))
+	router.Post("/admin/breakers/{name}/reset", breakers.Reset(logger, registry, respondWithError))
+
+	return router, cb
+}
+
+func TestBreakersHandler_ListAndReset(t *testing.T) {
+	router, cb := newRouter()
+
+	_, _ = cb.Execute(func() (any, error) { return nil, errors.New("boom") })
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/breakers", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var list []breaker.Status
+
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&list))
+	require.Len(t, list, 1)
+	require.Equal(t, "scraper", list[0].Name)
+	require.Equal(t, "open", list[0].State)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/breakers/scraper/reset", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, gobreaker.StateClosed, cb.State())
+}
+
+func TestBreakersHandler_ResetUnknown(t *testing.T) {
+	router, _ := newRouter()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/breakers/gitlab/reset", nil))
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
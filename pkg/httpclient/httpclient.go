@@ -0,0 +1,359 @@
+// Package httpclient is the base of the services' outbound HTTP clients. A
+// Client guards one endpoint with its own circuit breaker, retries temporary
+// failures with jittered backoff or as told by Retry-After, gives every
+// attempt its own deadline, and logs and measures each attempt.
+package httpclient
+
+import (
+	"github.com/avast/retry-go/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sony/gobreaker"
+	"pkg/breaker"
+
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultMaxDelay = 30 * time.Second
+
+var (
+	attemptDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "myapp",
+			Name:      "http_client_request_duration_seconds",
+			Help:      "Duration of outbound HTTP attempts by client and status code",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"client", "code"},
+	)
+
+	retriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "myapp",
+			Name:      "http_client_retries_total",
+			Help:      "Outbound HTTP attempts that were retried",
+		},
+		[]string{"client"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(attemptDuration)
+	prometheus.MustRegister(retriesTotal)
+}
+
+type Options struct {
+	// Name labels the metrics and logs of the client, e.g. "github".
+	Name string
+	// BreakerName is the name of the circuit breaker in its logs, metrics and
+	// /admin/breakers.
+	BreakerName string
+	Breaker     breaker.Config
+	// Timeout bounds every attempt; the caller's context bounds them all.
+	Timeout time.Duration
+	Retries uint
+	Backoff time.Duration
+	// MaxDelay caps the backoff and Retry-After, 30s when zero.
+	MaxDelay      time.Duration
+	Transport     http.RoundTripper
+	CheckRedirect func(req *http.Request, via []*http.Request) error
+	// CheckResponse may turn a response other than 2xx into an error that is
+	// returned as is and never retried. Returning nil leaves the response to
+	// the default classification.
+	CheckResponse func(resp *http.Response) error
+	// IsSuccessful tells the breaker which errors say nothing about the
+	// endpoint's health. Only nil by default.
+	IsSuccessful func(err error) bool
+}
+
+type Client struct {
+	name          string
+	log           *slog.Logger
+	client        *http.Client
+	breaker       *breaker.Breaker
+	checkResponse func(resp *http.Response) error
+
+	mu     sync.RWMutex
+	tuning tuning
+}
+
+// tuning is the part of Options that Configure may change.
+type tuning struct {
+	timeout  time.Duration
+	retries  uint
+	backoff  time.Duration
+	maxDelay time.Duration
+}
+
+func New(log *slog.Logger, opts Options) *Client {
+	return &Client{
+		name: opts.Name,
+		log:  log.With(slog.String("client", opts.Name)),
+		client: &http.Client{
+			Transport:     opts.Transport,
+			CheckRedirect: opts.CheckRedirect,
+		},
+		breaker:       breaker.New(log, opts.BreakerName, opts.Breaker, opts.IsSuccessful),
+		checkResponse: opts.CheckResponse,
+		tuning:        tuningOf(opts),
+	}
+}
+
+func tuningOf(opts Options) tuning {
+	retries := opts.Retries
+	if retries == 0 {
+		retries = 1
+	}
+
+	maxDelay := opts.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxDelay
+	}
+
+	return tuning{timeout: opts.Timeout, retries: retries, backoff: opts.Backoff, maxDelay: maxDelay}
+}
+
+// Configure applies the Breaker, Timeout, Retries, Backoff and MaxDelay of
+// opts to the requests that start from now on, e.g. on a config reload. The
+// rest of opts is fixed by New.
+func (c *Client) Configure(opts Options) {
+	c.mu.Lock()
+	c.tuning = tuningOf(opts)
+	c.mu.Unlock()
+
+	c.breaker.Configure(opts.Breaker)
+}
+
+func (c *Client) settings() tuning {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.tuning
+}
+
+// Breaker is the circuit breaker guarding the endpoint.
+func (c *Client) Breaker() *breaker.Breaker {
+	return c.breaker
+}
+
+type Request struct {
+	Method string
+	URL    string
+	Header http.Header
+	// Body is sent as JSON when set.
+	Body any
+}
+
+// Do sends req and decodes a JSON answer into result unless result is nil.
+// Network errors, 408, 429 and 5xx are retried; other statuses come back as
+// *StatusError right away. The breaker sees every attempt, and retries stop
+// once it opens.
+func (c *Client) Do(ctx context.Context, req Request, result any) error {
+	const op = "httpclient.Do"
+
+	var body []byte
+
+	if req.Body != nil {
+		var err error
+
+		if body, err = json.Marshal(req.Body); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	tuning := c.settings()
+
+	err := retry.Do(
+		func() error {
+			_, err := c.breaker.Execute(func() (any, error) {
+				return nil, c.attempt(ctx, req, body, result, tuning.timeout)
+			})
+			if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+				return retry.Unrecoverable(err)
+			}
+
+			return err
+		},
+		retry.Attempts(tuning.retries),
+		retry.Delay(tuning.backoff),
+		retry.MaxJitter(tuning.backoff/2+1),
+		retry.MaxDelay(tuning.maxDelay),
+		retry.DelayType(delay),
+		retry.OnRetry(func(n uint, err error) {
+			if n+1 >= tuning.retries {
+				return
+			}
+
+			retriesTotal.WithLabelValues(c.name).Inc()
+			c.log.Warn("Request failed, retrying", slog.String("method", req.Method),
+				slog.Uint64("attempt", uint64(n+1)), slog.String("error", err.Error()))
+		}),
+		retry.Context(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %s %s: %w", op, req.Method, redact(req.URL), err)
+	}
+
+	return nil
+}
+
+func (c *Client) attempt(ctx context.Context, req Request, body []byte, result any, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, bytes.NewReader(body))
+	if err != nil {
+		return retry.Unrecoverable(err)
+	}
+
+	for key, values := range req.Header {
+		httpReq.Header[key] = values
+	}
+
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	c.log.Debug("Sending request", slog.String("method", req.Method), slog.String("url", redact(req.URL)))
+
+	start := time.Now()
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		attemptDuration.WithLabelValues(c.name, "error").Observe(time.Since(start).Seconds())
+		return err
+	}
+	defer resp.Body.Close()
+
+	attemptDuration.WithLabelValues(c.name, strconv.Itoa(resp.StatusCode)).Observe(time.Since(start).Seconds())
+
+	if err = c.classify(resp); err != nil {
+		return err
+	}
+
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return retry.Unrecoverable(fmt.Errorf("failed to decode response: %w", err))
+	}
+
+	return nil
+}
+
+func (c *Client) classify(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	if c.checkResponse != nil {
+		if err := c.checkResponse(resp); err != nil {
+			return retry.Unrecoverable(err)
+		}
+	}
+
+	if !temporary(resp.StatusCode) {
+		return retry.Unrecoverable(NewStatusError(resp))
+	}
+
+	err := fmt.Errorf("temporary server error: %w", NewStatusError(resp))
+
+	if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+		return &retryAfterError{error: err, after: after}
+	}
+
+	return err
+}
+
+// StatusError is returned for responses that are not 2xx.
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+// NewStatusError builds the error for resp, e.g. for statuses a client maps
+// to its own errors in CheckResponse.
+func NewStatusError(resp *http.Response) *StatusError {
+	return &StatusError{Code: resp.StatusCode, Status: resp.Status}
+}
+
+func (e *StatusError) Error() string {
+	return "bad status: " + e.Status
+}
+
+// Code returns the HTTP status carried by err, or 0 when the request never
+// got a response.
+func Code(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code
+	}
+
+	return 0
+}
+
+// temporary reports whether a request answered with code is worth retrying.
+func temporary(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500
+}
+
+// retryAfterError is a temporary error whose response said when to retry.
+type retryAfterError struct {
+	error
+	after time.Duration
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.error
+}
+
+// retryAfter parses Retry-After, either seconds or an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+
+	return 0, false
+}
+
+// delay waits as long as the server asked to, otherwise backs off
+// exponentially with jitter. retry.MaxDelay caps both.
+func delay(n uint, err error, config *retry.Config) time.Duration {
+	var afterErr *retryAfterError
+	if errors.As(err, &afterErr) {
+		return afterErr.after
+	}
+
+	return retry.CombineDelay(retry.BackOffDelay, retry.RandomDelay)(n, err, config)
+}
+
+// redact drops the query, which may carry API keys, from a URL for logs and
+// errors.
+func redact(rawURL string) string {
+	path, _, _ := strings.Cut(rawURL, "?")
+	return path
+}
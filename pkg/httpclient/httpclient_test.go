@@ -0,0 +1,358 @@
+package httpclient
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/require"
+	"pkg/breaker"
+
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errGone = errors.New("gone")
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, configure func(*Options)) (*Client, string) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	opts := Options{
+		Name:        t.Name(),
+		BreakerName: t.Name(),
+		Breaker:     breaker.Config{MinimumCalls: 10, Timeout: time.Minute},
+		Timeout:     time.Second,
+		Retries:     3,
+		Backoff:     time.Millisecond,
+	}
+
+	if configure != nil {
+		configure(&opts)
+	}
+
+	return New(slog.New(slog.NewJSONHandler(io.Discard, nil)), opts), server.URL
+}
+
+// answer replies with the statuses in order, repeating the last one, and
+// counts the calls.
+func answer(calls *atomic.Int32, statuses ...int) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		n := int(calls.Add(1))
+		status := statuses[min(n, len(statuses))-1]
+
+		w.WriteHeader(status)
+
+		if status == http.StatusOK {
+			_, _ = w.Write([]byte(`{"value":"ok"}`))
+		}
+	}
+}
+
+func get(client *Client, url string, result any) error {
+	return client.Do(context.Background(), Request{Method: http.MethodGet, URL: url}, result)
+}
+
+func TestClient_Do_DecodesJSON(t *testing.T) {
+	client, url := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "token secret", r.Header.Get("Authorization"))
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var body map[string]int
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, map[string]int{"id": 1}, body)
+
+		_, _ = w.Write([]byte(`{"value":"ok"}`))
+	}, nil)
+
+	var result struct {
+		Value string `json:"value"`
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "token secret")
+
+	err := client.Do(context.Background(),
+		Request{Method: http.MethodPost, URL: url, Header: header, Body: map[string]int{"id": 1}}, &result)
+
+	require.NoError(t, err)
+	require.Equal(t, "ok", result.Value)
+}
+
+func TestClient_Do_Retries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		calls    int32
+		code     int
+	}{
+		{"server error then success", []int{http.StatusInternalServerError, http.StatusOK}, 2, 0},
+		{"too many requests", []int{http.StatusTooManyRequests}, 3, http.StatusTooManyRequests},
+		{"request timeout", []int{http.StatusRequestTimeout}, 3, http.StatusRequestTimeout},
+		{"bad gateway", []int{http.StatusBadGateway}, 3, http.StatusBadGateway},
+		{"not found is final", []int{http.StatusNotFound}, 1, http.StatusNotFound},
+		{"bad request is final", []int{http.StatusBadRequest}, 1, http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+
+			client, url := newTestClient(t, answer(&calls, tc.statuses...), nil)
+
+			err := get(client, url, nil)
+
+			require.Equal(t, tc.calls, calls.Load())
+			require.Equal(t, tc.code, Code(err))
+
+			if tc.code == 0 {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClient_Do_CountsRetries(t *testing.T) {
+	var calls atomic.Int32
+
+	client, url := newTestClient(t, answer(&calls, http.StatusServiceUnavailable), nil)
+
+	require.Error(t, get(client, url, nil))
+
+	require.InDelta(t, 2, testutil.ToFloat64(retriesTotal.WithLabelValues(t.Name())), 0)
+
+	var attempts dto.Metric
+
+	require.NoError(t, attemptDuration.WithLabelValues(t.Name(), "503").(prometheus.Metric).Write(&attempts))
+	require.Equal(t, uint64(3), attempts.GetHistogram().GetSampleCount())
+}
+
+func TestClient_Do_HonoursRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+
+	client, url := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}, func(opts *Options) {
+		opts.Backoff = time.Minute
+	})
+
+	start := time.Now()
+
+	require.NoError(t, get(client, url, nil))
+	require.Less(t, time.Since(start), time.Second, "Retry-After must replace the backoff")
+}
+
+func TestClient_Do_CapsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+
+	client, url := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, func(opts *Options) {
+		opts.Retries = 2
+		opts.MaxDelay = 10 * time.Millisecond
+	})
+
+	start := time.Now()
+
+	require.Error(t, get(client, url, nil))
+	require.Equal(t, int32(2), calls.Load())
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetryAfter(t *testing.T) {
+	after, ok := retryAfter("120")
+	require.True(t, ok)
+	require.Equal(t, 2*time.Minute, after)
+
+	after, ok = retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	require.True(t, ok)
+	require.InDelta(t, time.Hour, after, float64(2*time.Second))
+
+	after, ok = retryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	require.True(t, ok)
+	require.Zero(t, after)
+
+	_, ok = retryAfter("soon")
+	require.False(t, ok)
+
+	_, ok = retryAfter("")
+	require.False(t, ok)
+}
+
+func TestClient_Do_CheckResponse(t *testing.T) {
+	var calls atomic.Int32
+
+	client, url := newTestClient(t, answer(&calls, http.StatusGone), func(opts *Options) {
+		opts.CheckResponse = func(resp *http.Response) error {
+			if resp.StatusCode == http.StatusGone {
+				return errGone
+			}
+
+			return nil
+		}
+	})
+
+	require.ErrorIs(t, get(client, url, nil), errGone)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_Do_OpensBreaker(t *testing.T) {
+	var calls atomic.Int32
+
+	client, url := newTestClient(t, answer(&calls, http.StatusInternalServerError), func(opts *Options) {
+		opts.Retries = 1
+		opts.Breaker = breaker.Config{MinimumCalls: 3, Timeout: time.Minute}
+	})
+
+	for range 3 {
+		require.Error(t, get(client, url, nil))
+	}
+
+	require.Equal(t, gobreaker.StateOpen, client.Breaker().State())
+	require.ErrorIs(t, get(client, url, nil), gobreaker.ErrOpenState)
+	require.Equal(t, int32(3), calls.Load(), "an open breaker must fail fast")
+}
+
+func TestClient_Do_BreakerSeesEveryAttempt(t *testing.T) {
+	var calls atomic.Int32
+
+	client, url := newTestClient(t, answer(&calls, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK),
+		nil)
+
+	require.NoError(t, get(client, url, nil))
+
+	counts := client.Breaker().Counts()
+	require.Equal(t, uint32(3), counts.Requests)
+	require.Equal(t, uint32(2), counts.TotalFailures, "failed attempts must count even when a retry succeeds")
+}
+
+func TestClient_Do_StopsRetryingOnceOpen(t *testing.T) {
+	var calls atomic.Int32
+
+	client, url := newTestClient(t, answer(&calls, http.StatusInternalServerError), func(opts *Options) {
+		opts.Retries = 5
+		opts.Breaker = breaker.Config{MinimumCalls: 2, Timeout: time.Minute}
+	})
+
+	require.ErrorIs(t, get(client, url, nil), gobreaker.ErrOpenState)
+	require.Equal(t, int32(2), calls.Load())
+	require.Equal(t, gobreaker.StateOpen, client.Breaker().State())
+}
+
+func TestClient_Do_IsSuccessful(t *testing.T) {
+	var calls atomic.Int32
+
+	client, url := newTestClient(t, answer(&calls, http.StatusNotFound), func(opts *Options) {
+		opts.Breaker = breaker.Config{MinimumCalls: 1, Timeout: time.Minute}
+		opts.IsSuccessful = func(err error) bool {
+			return err == nil || Code(err) == http.StatusNotFound
+		}
+	})
+
+	for range 3 {
+		require.Equal(t, http.StatusNotFound, Code(get(client, url, nil)))
+	}
+
+	require.Equal(t, gobreaker.StateClosed, client.Breaker().State())
+}
+
+func TestClient_Configure(t *testing.T) {
+	var calls atomic.Int32
+
+	client, url := newTestClient(t, answer(&calls, http.StatusInternalServerError), nil)
+
+	require.Error(t, get(client, url, nil))
+	require.Equal(t, int32(3), calls.Load())
+
+	client.Configure(Options{
+		Breaker: breaker.Config{MinimumCalls: 8, Timeout: time.Minute},
+		Retries: 5,
+		Backoff: time.Millisecond,
+	})
+
+	require.Error(t, get(client, url, nil))
+	require.Equal(t, int32(8), calls.Load())
+	require.Equal(t, gobreaker.StateOpen, client.Breaker().State())
+}
+
+func TestClient_Do_AttemptDeadline(t *testing.T) {
+	var calls atomic.Int32
+
+	client, url := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}, func(opts *Options) {
+		opts.Timeout = 50 * time.Millisecond
+	})
+
+	require.NoError(t, get(client, url, nil), "a slow attempt must time out and be retried")
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestClient_Do_StopsWithContext(t *testing.T) {
+	var calls atomic.Int32
+
+	client, url := newTestClient(t, answer(&calls, http.StatusInternalServerError), func(opts *Options) {
+		opts.Retries = 10
+		opts.Backoff = time.Minute
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	require.ErrorIs(t, client.Do(ctx, Request{Method: http.MethodGet, URL: url}, nil), context.DeadlineExceeded)
+	require.Equal(t, int32(1), calls.Load())
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestClient_Do_ResendsBody(t *testing.T) {
+	var bodies []string
+
+	client, url := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}, nil)
+
+	require.NoError(t, client.Do(context.Background(), Request{Method: http.MethodPost, URL: url, Body: []int{1}}, nil))
+	require.Equal(t, []string{"[1]", "[1]"}, bodies)
+}
+
+func TestClient_Do_RedactsQuery(t *testing.T) {
+	var calls atomic.Int32
+
+	client, url := newTestClient(t, answer(&calls, http.StatusBadRequest), nil)
+
+	err := get(client, url+"/questions?key=secret", nil)
+
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "secret")
+}
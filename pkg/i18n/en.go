@@ -0,0 +1,89 @@
+package i18n
+
+var en = map[string]string{
+	KeyHelp: "/start - register\n" +
+		"/track - start tracking a link\n" +
+		"/untrack - stop tracking a link\n" +
+		"/list - show tracked links\n" +
+		"/status - health of a tracked link\n" +
+		"/history - recent updates of a tracked link\n" +
+		"/token - API token for scripts, /token read for a read-only one\n" +
+		"/revoke - revoke all API tokens\n" +
+		"/settings - group settings\n" +
+		"/lang - bot language\n" +
+		"/help - list of commands",
+	KeyUnknownCommand:    "Unknown command",
+	KeyStartRegistered:   "Registered. Send /help",
+	KeyStartAlready:      "You are already registered. Send /help",
+	KeyTrackPrompt:       "Send a link to track\nSupported:\nGitHub repositories\nStackOverflow questions",
+	KeyTrackInvalidLink:  "Invalid link format",
+	KeyTrackTagsPrompt:   "Enter tags (space separated) or send 'skip'.",
+	KeyTrackFilterPrompt: "Set up filters (optional) or send 'skip'.",
+	KeyTrackSkipWord:     "skip",
+	KeyTrackFailed:       "The link is already tracked or you forgot to /start",
+	KeyTrackAdded:        "Link %s added with tags: %v",
+	KeyUntrackUsage:      "Usage: /untrack <link>",
+	KeyUntrackNotFound:   "The link is not in your tracked list.",
+	KeyUntrackRemoved:    "Link %s is no longer tracked\n",
+	KeyListNotRegistered: "Register with /start first",
+	KeyListEmpty:         "You have no tracked links yet.",
+	KeyListItem:          "%s (Tags: %v)\n",
+	KeyListItemFailing:   "⚠️ %s (Tags: %v)\n",
+	KeyStatusUsage:       "Usage: /status <link>",
+	KeyStatusNotFound:    "The link is not in your tracked list.",
+	KeyStatusSummary:     "%s\nState: %s\nLast success: %s\nFailures in a row: %d\nLast HTTP status: %s\nLast error: %s",
+	KeyStatusOK:          "✅ ok",
+	KeyStatusFailing:     "⚠️ failing",
+	KeyStatusBroken:      "❌ no longer polled",
+	KeyStatusNever:       "never",
+	KeyStatusNoError:     "none",
+	KeyStatusUnknown:     "unknown",
+	KeyTokenUsage:        "Usage: /token or /token read",
+	KeyTokenPrivateOnly:  "API tokens are only issued in a private chat with the bot",
+	KeyTokenIssued: "Your API token, it is shown only once:\n%s\n\nScopes: %s\n" +
+		"Send it as \"Authorization: Bearer <token>\" to /api/v1/links and /api/v1/updates of the scraper. " +
+		"Revoke it with /revoke.",
+	KeyTokenFailed:       "Failed to issue a token. Register with /start first",
+	KeyRevokeDone:        "API tokens revoked: %d",
+	KeyRevokeFailed:      "Failed to revoke API tokens, try again later",
+	KeyHistoryUsage:      "Usage: /history <link> [count]",
+	KeyHistoryEmpty:      "No updates were sent about this link yet.",
+	KeyHistoryFailed:     "Failed to load the history, try again later",
+	KeyHistoryHeader:     "Recent updates of %s:\n",
+	KeyHistoryItem:       "\n%s %s\n%s\n",
+	KeyHistoryRemoved:    "Removed from tracking",
+	KeyAdminOnly:         "Only administrators can manage subscriptions in this group",
+	KeySettingsUsage:     "Usage: /settings members on|off",
+	KeySettingsGroupOnly: "Settings are available in groups only",
+	KeySettingsLoadErr:   "Failed to load settings",
+	KeySettingsSaveErr:   "Failed to save settings",
+	KeySettingsAdminOnly: "Only administrators can change group settings",
+	KeySettingsAdmins:    "administrators only",
+	KeySettingsEveryone:  "all members",
+	KeySettingsSummary:   "Group settings:\nSubscription management: %s\n\n%s",
+	KeyLangCurrent:       "Current language: %s",
+	KeyLangUsage:         "Usage: /lang %s",
+	KeyLangUnsupported:   "Language %s is not supported",
+	KeyLangChanged:       "Language switched to English",
+	KeyUpdateFallback:    "Update for URL: %s",
+	KeyLinkUnavailable:   "URL: %s\n is unavailable. Removing it from tracked links\n",
+	KeyLinkRemoved:       "Link %s is no longer tracked: %s\n",
+
+	KeyRemovalUnsupported: "the site is not supported",
+	KeyRemovalNotFound:    "the repository or question was not found",
+	KeyRemovalDeleted:     "the question was deleted",
+	KeyRemovalAccessLost:  "access to it was lost",
+
+	KeyRenderGitHubHeader: "New changes on GitHub",
+	KeyRenderStackHeader:  "New changes on StackOverflow",
+	KeyRenderUpdate:       "Update",
+	KeyRenderAnswer:       "New answer",
+	KeyRenderComment:      "New comment",
+	KeyRenderPullRequest:  "PR:",
+	KeyRenderIssue:        "Issue:",
+	KeyTimeJustNow:        "just now",
+	KeyTimeMinutesAgo:     "%d min ago",
+	KeyTimeHoursAgo:       "%d h ago",
+	KeyTimeDaysAgo:        "%d d ago",
+	KeyTimeDateLayout:     "2006-01-02",
+}
@@ -0,0 +1,57 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Lang string
+
+const (
+	LangRU Lang = "ru"
+	LangEN Lang = "en"
+
+	Default = LangRU
+)
+
+var catalogs = map[Lang]map[string]string{
+	LangRU: ru,
+	LangEN: en,
+}
+
+// Supported lists languages in the order they are offered to users.
+func Supported() []Lang {
+	return []Lang{LangRU, LangEN}
+}
+
+// Parse maps a language tag such as "en-US" onto a supported language.
+func Parse(code string) (Lang, bool) {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(code)), "-")
+
+	lang := Lang(base)
+	if _, ok := catalogs[lang]; !ok {
+		return Default, false
+	}
+
+	return lang, true
+}
+
+// T formats the message stored under key. Keys missing from the language fall
+// back to the default catalog and then to the key itself, so a gap in a
+// translation never produces an empty reply.
+func T(lang Lang, key string, args ...any) string {
+	msg, ok := catalogs[lang][key]
+	if !ok {
+		msg, ok = catalogs[Default][key]
+	}
+
+	if !ok {
+		return key
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+
+	return fmt.Sprintf(msg, args...)
+}
@@ -0,0 +1,112 @@
+package i18n
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+var verbRegex = regexp.MustCompile(`%[-+# 0]*\d*(\.\d+)?[a-zA-Z]`)
+
+// declaredKeys collects every Key* constant from keys.go, so a key added
+// there without translations fails the test.
+func declaredKeys(t *testing.T) []string {
+	t.Helper()
+
+	file, err := parser.ParseFile(token.NewFileSet(), "keys.go", nil, 0)
+	require.NoError(t, err)
+
+	var keys []string
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+
+		for _, value := range spec.Values {
+			lit, ok := value.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				continue
+			}
+
+			key, err := strconv.Unquote(lit.Value)
+			require.NoError(t, err)
+
+			keys = append(keys, key)
+		}
+
+		return true
+	})
+
+	require.NotEmpty(t, keys)
+
+	return keys
+}
+
+func TestCatalogs_NoUntranslatedKeys(t *testing.T) {
+	keys := declaredKeys(t)
+
+	for _, lang := range Supported() {
+		catalog, ok := catalogs[lang]
+		require.True(t, ok, "no catalog for %s", lang)
+
+		for _, key := range keys {
+			msg, ok := catalog[key]
+			assert.True(t, ok && msg != "", "%s: key %q is not translated", lang, key)
+		}
+
+		assert.Len(t, catalog, len(keys), "%s: catalog has keys not declared in keys.go", lang)
+	}
+}
+
+func TestCatalogs_SameFormatVerbs(t *testing.T) {
+	for _, key := range declaredKeys(t) {
+		want := verbs(catalogs[Default][key])
+
+		for _, lang := range Supported() {
+			assert.Equal(t, want, verbs(catalogs[lang][key]), "%s: format verbs of %q differ", lang, key)
+		}
+	}
+}
+
+func verbs(msg string) []string {
+	found := verbRegex.FindAllString(msg, -1)
+	sort.Strings(found)
+
+	return found
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		code string
+		want Lang
+		ok   bool
+	}{
+		{"ru", LangRU, true},
+		{"en-US", LangEN, true},
+		{" EN ", LangEN, true},
+		{"de", Default, false},
+		{"", Default, false},
+	}
+
+	for _, tt := range tests {
+		lang, ok := Parse(tt.code)
+		assert.Equal(t, tt.want, lang, tt.code)
+		assert.Equal(t, tt.ok, ok, tt.code)
+	}
+}
+
+func TestT_FallsBack(t *testing.T) {
+	assert.Equal(t, "Unknown command", T(LangEN, KeyUnknownCommand))
+	assert.Equal(t, T(Default, KeyUnknownCommand), T("de", KeyUnknownCommand))
+	assert.Equal(t, "missing.key", T(LangEN, "missing.key"))
+	assert.Equal(t, "Link x added with tags: [a]", T(LangEN, KeyTrackAdded, "x", []string{"a"}))
+}
@@ -0,0 +1,77 @@
+package i18n
+
+const (
+	KeyHelp              = "help"
+	KeyUnknownCommand    = "unknown_command"
+	KeyStartRegistered   = "start.registered"
+	KeyStartAlready      = "start.already_registered"
+	KeyTrackPrompt       = "track.prompt"
+	KeyTrackInvalidLink  = "track.invalid_link"
+	KeyTrackTagsPrompt   = "track.tags_prompt"
+	KeyTrackFilterPrompt = "track.filters_prompt"
+	KeyTrackSkipWord     = "track.skip_word"
+	KeyTrackFailed       = "track.failed"
+	KeyTrackAdded        = "track.added"
+	KeyUntrackUsage      = "untrack.usage"
+	KeyUntrackNotFound   = "untrack.not_found"
+	KeyUntrackRemoved    = "untrack.removed"
+	KeyListNotRegistered = "list.not_registered"
+	KeyListEmpty         = "list.empty"
+	KeyListItem          = "list.item"
+	KeyListItemFailing   = "list.item_failing"
+	KeyStatusUsage       = "status.usage"
+	KeyStatusNotFound    = "status.not_found"
+	KeyStatusSummary     = "status.summary"
+	KeyStatusOK          = "status.ok"
+	KeyStatusFailing     = "status.failing"
+	KeyStatusBroken      = "status.broken"
+	KeyStatusNever       = "status.never"
+	KeyStatusNoError     = "status.no_error"
+	KeyStatusUnknown     = "status.unknown"
+	KeyTokenUsage        = "token.usage"
+	KeyTokenPrivateOnly  = "token.private_only"
+	KeyTokenIssued       = "token.issued"
+	KeyTokenFailed       = "token.failed"
+	KeyRevokeDone        = "revoke.done"
+	KeyRevokeFailed      = "revoke.failed"
+	KeyHistoryUsage      = "history.usage"
+	KeyHistoryEmpty      = "history.empty"
+	KeyHistoryFailed     = "history.failed"
+	KeyHistoryHeader     = "history.header"
+	KeyHistoryItem       = "history.item"
+	KeyHistoryRemoved    = "history.removed"
+	KeyAdminOnly         = "group.admin_only"
+	KeySettingsUsage     = "settings.usage"
+	KeySettingsGroupOnly = "settings.group_only"
+	KeySettingsLoadErr   = "settings.load_failed"
+	KeySettingsSaveErr   = "settings.save_failed"
+	KeySettingsAdminOnly = "settings.admin_only"
+	KeySettingsAdmins    = "settings.members_admins"
+	KeySettingsEveryone  = "settings.members_everyone"
+	KeySettingsSummary   = "settings.summary"
+	KeyLangCurrent       = "lang.current"
+	KeyLangUsage         = "lang.usage"
+	KeyLangUnsupported   = "lang.unsupported"
+	KeyLangChanged       = "lang.changed"
+	KeyUpdateFallback    = "update.fallback"
+	KeyLinkUnavailable   = "update.link_unavailable"
+	KeyLinkRemoved       = "update.link_removed"
+
+	KeyRemovalUnsupported = "removal.unsupported"
+	KeyRemovalNotFound    = "removal.not_found"
+	KeyRemovalDeleted     = "removal.deleted"
+	KeyRemovalAccessLost  = "removal.access_lost"
+
+	KeyRenderGitHubHeader = "render.github_header"
+	KeyRenderStackHeader  = "render.stackoverflow_header"
+	KeyRenderUpdate       = "render.update"
+	KeyRenderAnswer       = "render.answer"
+	KeyRenderComment      = "render.comment"
+	KeyRenderPullRequest  = "render.pull_request"
+	KeyRenderIssue        = "render.issue"
+	KeyTimeJustNow        = "time.just_now"
+	KeyTimeMinutesAgo     = "time.minutes_ago"
+	KeyTimeHoursAgo       = "time.hours_ago"
+	KeyTimeDaysAgo        = "time.days_ago"
+	KeyTimeDateLayout     = "time.date_layout"
+)
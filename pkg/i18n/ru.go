@@ -0,0 +1,89 @@
+package i18n
+
+var ru = map[string]string{
+	KeyHelp: "/start - регистрация\n" +
+		"/track - начать отслеживание ссылки\n" +
+		"/untrack - прекратить отслеживание ссылки\n" +
+		"/list - показать список отслеживаемых ссылок\n" +
+		"/status - состояние отслеживаемой ссылки\n" +
+		"/history - последние обновления отслеживаемой ссылки\n" +
+		"/token - API токен для скриптов, /token read - только для чтения\n" +
+		"/revoke - отозвать все API токены\n" +
+		"/settings - настройки группы\n" +
+		"/lang - язык бота\n" +
+		"/help - список команд",
+	KeyUnknownCommand:    "Неизвестная команда",
+	KeyStartRegistered:   "Зарегестрирован. Пиши /help",
+	KeyStartAlready:      "Вы уже зарегестрированы. Пиши /help",
+	KeyTrackPrompt:       "Отправьте ссылку для отслеживания\nПоддерживается:\nРепозитории GitHub\nВопрос из StackOverFlow",
+	KeyTrackInvalidLink:  "Неверный формат ссылки",
+	KeyTrackTagsPrompt:   "Введите теги (через пробел) или отправьте 'пропустить'.",
+	KeyTrackFilterPrompt: "Настройте фильтры (опционально) или отправьте 'пропустить'.",
+	KeyTrackSkipWord:     "пропустить",
+	KeyTrackFailed:       "Ссылка уже была добавлена или вы забыли про /start",
+	KeyTrackAdded:        "Ссылка %s добавлена с тегами: %v",
+	KeyUntrackUsage:      "Использование: /untrack <ссылка>",
+	KeyUntrackNotFound:   "Ссылка не найдена в списке отслеживаемых.",
+	KeyUntrackRemoved:    "Ссылка %s удалена из отслеживания\n",
+	KeyListNotRegistered: "Для начала зарегестрируйся через /start",
+	KeyListEmpty:         "У вас пока нет отслеживаемых ссылок.",
+	KeyListItem:          "%s (Теги: %v)\n",
+	KeyListItemFailing:   "⚠️ %s (Теги: %v)\n",
+	KeyStatusUsage:       "Использование: /status <ссылка>",
+	KeyStatusNotFound:    "Ссылка не найдена в списке отслеживаемых.",
+	KeyStatusSummary:     "%s\nСостояние: %s\nПоследний успех: %s\nОшибок подряд: %d\nПоследний HTTP статус: %s\nПоследняя ошибка: %s",
+	KeyStatusOK:          "✅ в порядке",
+	KeyStatusFailing:     "⚠️ ошибки при опросе",
+	KeyStatusBroken:      "❌ больше не опрашивается",
+	KeyStatusNever:       "никогда",
+	KeyStatusNoError:     "нет",
+	KeyStatusUnknown:     "неизвестен",
+	KeyTokenUsage:        "Использование: /token или /token read",
+	KeyTokenPrivateOnly:  "API токены выдаются только в личном чате с ботом",
+	KeyTokenIssued: "Ваш API токен, он показывается только один раз:\n%s\n\nПрава: %s\n" +
+		"Передавайте его как \"Authorization: Bearer <token>\" в /api/v1/links и /api/v1/updates скрапера. " +
+		"Отозвать токен можно командой /revoke.",
+	KeyTokenFailed:       "Не удалось выдать токен. Сначала зарегистрируйтесь через /start",
+	KeyRevokeDone:        "Отозвано API токенов: %d",
+	KeyRevokeFailed:      "Не удалось отозвать API токены, попробуйте позже",
+	KeyHistoryUsage:      "Использование: /history <ссылка> [количество]",
+	KeyHistoryEmpty:      "По этой ссылке еще не было обновлений.",
+	KeyHistoryFailed:     "Не удалось загрузить историю, попробуйте позже",
+	KeyHistoryHeader:     "Последние обновления %s:\n",
+	KeyHistoryItem:       "\n%s %s\n%s\n",
+	KeyHistoryRemoved:    "Удалена из отслеживаемых",
+	KeyAdminOnly:         "Управлять подписками в группе могут только администраторы",
+	KeySettingsUsage:     "Использование: /settings members on|off",
+	KeySettingsGroupOnly: "Настройки доступны только в группах",
+	KeySettingsLoadErr:   "Не удалось получить настройки",
+	KeySettingsSaveErr:   "Не удалось сохранить настройки",
+	KeySettingsAdminOnly: "Менять настройки группы могут только администраторы",
+	KeySettingsAdmins:    "только администраторы",
+	KeySettingsEveryone:  "все участники",
+	KeySettingsSummary:   "Настройки группы:\nУправление подписками: %s\n\n%s",
+	KeyLangCurrent:       "Текущий язык: %s",
+	KeyLangUsage:         "Использование: /lang %s",
+	KeyLangUnsupported:   "Язык %s не поддерживается",
+	KeyLangChanged:       "Язык изменён на русский",
+	KeyUpdateFallback:    "Произошло обновление по URL: %s",
+	KeyLinkUnavailable:   "URL: %s\n недоступен. Удаляю из списка отслеживаемых\n",
+	KeyLinkRemoved:       "Ссылка %s удалена из отслеживания: %s\n",
+
+	KeyRemovalUnsupported: "сайт не поддерживается",
+	KeyRemovalNotFound:    "репозиторий или вопрос не найден",
+	KeyRemovalDeleted:     "вопрос был удалён",
+	KeyRemovalAccessLost:  "доступ к ресурсу закрыт",
+
+	KeyRenderGitHubHeader: "Новые изменения на GitHub",
+	KeyRenderStackHeader:  "Новые изменения на StackOverflow",
+	KeyRenderUpdate:       "Обновление",
+	KeyRenderAnswer:       "Новый ответ",
+	KeyRenderComment:      "Новый комментарий",
+	KeyRenderPullRequest:  "PR:",
+	KeyRenderIssue:        "Issue:",
+	KeyTimeJustNow:        "только что",
+	KeyTimeMinutesAgo:     "%d мин. назад",
+	KeyTimeHoursAgo:       "%d ч. назад",
+	KeyTimeDaysAgo:        "%d дн. назад",
+	KeyTimeDateLayout:     "02.01.2006",
+}
@@ -0,0 +1,123 @@
+// Package openapi checks HTTP requests and responses of the services against
+// their OpenAPI documents.
+package openapi
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// Reject answers a request that breaks the contract. It gets the validation
+// error and is expected to write a 400.
+type Reject func(writer http.ResponseWriter, err error)
+
+// Validate hands requests to the routes of doc that break the contract to
+// reject. Responses are checked as well; one that breaks the contract is
+// logged and still sent. Routes missing from doc, like the Telegram webhook
+// or /metrics, are passed through. Mount it after authentication, so that an
+// unauthenticated caller gets a 401 rather than the details of the schema.
+func Validate(log *slog.Logger, doc *openapi3.T, reject Reject) (func(http.Handler) http.Handler, error) {
+	const op = "openapi.Validate"
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			log := log.With(slog.String("op", op),
+				slog.String("request_id", middleware.GetReqID(request.Context())))
+
+			route, params, err := router.FindRoute(request)
+			if err != nil {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    request,
+				PathParams: params,
+				Route:      route,
+				Options:    options(),
+			}
+
+			if err = openapi3filter.ValidateRequest(request.Context(), input); err != nil {
+				log.Warn("request does not match the API contract", slog.String("error", err.Error()))
+				reject(writer, err)
+
+				return
+			}
+
+			recorder := newRecorder()
+			next.ServeHTTP(recorder, request)
+
+			if err = ValidateResponse(input, recorder.status, recorder.header, recorder.body.Bytes()); err != nil {
+				log.Error("response does not match the API contract", slog.String("error", err.Error()))
+			}
+
+			recorder.flush(writer)
+		})
+	}, nil
+}
+
+// ValidateResponse checks a response to the request in input.
+func ValidateResponse(input *openapi3filter.RequestValidationInput, status int, header http.Header,
+	body []byte) error {
+	responseInput := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 status,
+		Header:                 header,
+		Options:                options(),
+	}
+
+	responseInput.SetBodyBytes(body)
+
+	return openapi3filter.ValidateResponse(input.Request.Context(), responseInput)
+}
+
+func options() *openapi3filter.Options {
+	return &openapi3filter.Options{
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		IncludeResponseStatus: true,
+	}
+}
+
+// recorder holds the response until it is validated.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newRecorder() *recorder {
+	return &recorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *recorder) Write(data []byte) (int, error) {
+	return r.body.Write(data)
+}
+
+func (r *recorder) flush(writer http.ResponseWriter) {
+	for key, values := range r.header {
+		writer.Header()[key] = values
+	}
+
+	writer.WriteHeader(r.status)
+	_, _ = writer.Write(r.body.Bytes())
+}
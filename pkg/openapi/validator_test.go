@@ -0,0 +1,104 @@
+package openapi_test
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/require"
+	"pkg/openapi"
+
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const spec = `
+openapi: 3.0.3
+info:
+  title: test
+  version: "1"
+paths:
+  /items:
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [id]
+              properties:
+                id:
+                  type: integer
+      responses:
+        '200':
+          description: Created
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [id]
+                properties:
+                  id:
+                    type: integer
+`
+
+func newHandler(t *testing.T, response string) http.Handler {
+	t.Helper()
+
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(spec))
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+
+	validate, err := openapi.Validate(slog.New(slog.NewJSONHandler(io.Discard, nil)), doc,
+		func(writer http.ResponseWriter, err error) {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+		})
+	require.NoError(t, err)
+
+	return validate(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusOK)
+		_, _ = writer.Write([]byte(response))
+	}))
+}
+
+func post(handler http.Handler, path, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, request)
+
+	return rec
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		response string
+		status   int
+	}{
+		{"valid", "/items", `{"id":1}`, `{"id":1}`, http.StatusOK},
+		{"request breaks the contract", "/items", `{"id":"1"}`, `{"id":1}`, http.StatusBadRequest},
+		{"response breaks the contract is still sent", "/items", `{"id":1}`, `{}`, http.StatusOK},
+		{"route outside the document", "/webhook", `anything`, `{}`, http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := post(newHandler(t, tc.response), tc.path, tc.body)
+
+			require.Equal(t, tc.status, rec.Code, rec.Body.String())
+
+			if tc.status == http.StatusOK {
+				require.Equal(t, tc.response, rec.Body.String())
+				require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Package ratelimit lets the request limits of the services change on a
+// config reload.
+package ratelimit
+
+import (
+	"github.com/go-chi/httprate"
+
+	"net/http"
+	"sync/atomic"
+)
+
+// Limit is a request limit of httprate limiters that can be changed while
+// they serve, e.g. on a config reload. The limiters keep their counters.
+type Limit struct {
+	n atomic.Int64
+}
+
+func NewLimit(n int) *Limit {
+	l := &Limit{}
+	l.Set(n)
+
+	return l
+}
+
+func (l *Limit) Set(n int) {
+	l.n.Store(int64(n))
+}
+
+func (l *Limit) Get() int {
+	return int(l.n.Load())
+}
+
+// Apply makes limiter, a middleware built by httprate, enforce l instead of
+// the limit it was built with.
+func (l *Limit) Apply(limiter func(next http.Handler) http.Handler) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		limited := limiter(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limited.ServeHTTP(w, r.WithContext(httprate.WithRequestLimit(r.Context(), l.Get())))
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package ratelimit
+
+import (
+	"github.com/go-chi/httprate"
+	"github.com/stretchr/testify/require"
+
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLimit_Apply(t *testing.T) {
+	limit := NewLimit(1)
+
+	handler := limit.Apply(httprate.LimitByIP(100, time.Minute))(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+	serve := func() int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusOK, serve())
+	require.Equal(t, http.StatusTooManyRequests, serve(), "the limit of l must replace the one of the limiter")
+
+	limit.Set(3)
+
+	require.Equal(t, 3, limit.Get())
+	require.Equal(t, http.StatusOK, serve())
+	require.Equal(t, http.StatusOK, serve())
+	require.Equal(t, http.StatusTooManyRequests, serve(), "a raised limit must keep the counters")
+}
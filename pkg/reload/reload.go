@@ -0,0 +1,213 @@
+// Package reload swaps a service's config at runtime when its file changes
+// or the process gets SIGHUP.
+package reload
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	versionInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "myapp",
+			Name:      "config_info",
+			Help:      "Version of the active config, a hash of its file, with the value 1",
+		},
+		[]string{"version"},
+	)
+
+	reloadsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "myapp",
+			Name:      "config_reloads_total",
+			Help:      "Config reloads by result: applied or rejected",
+		},
+		[]string{"result"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(versionInfo)
+	prometheus.MustRegister(reloadsTotal)
+}
+
+// Version is the short hash of a config file that tells configs apart in
+// logs and metrics.
+func Version(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// Options describe the config file a Reloader watches and how to read it.
+type Options[T any] struct {
+	// Path is the file the running config was read from and Version its
+	// Version.
+	Path    string
+	Version string
+	// Interval is how often the file is checked for changes; zero leaves
+	// only SIGHUP.
+	Interval time.Duration
+	// Parse reads and validates the config in data.
+	Parse func(path string, data []byte) (T, error)
+	// Reloadable is current with the settings that are safe to change at
+	// runtime taken from next.
+	Reloadable func(current, next T) T
+}
+
+// Reloader rereads the config file on SIGHUP and when the file changes, and
+// hands the settings that are safe to change at runtime to the subscribers.
+// Other changes are logged and wait for a restart. A file that fails to load
+// or validate is rejected and the running config is kept.
+type Reloader[T any] struct {
+	log  *slog.Logger
+	opts Options[T]
+
+	mu          sync.Mutex
+	current     T
+	version     string
+	rejected    string
+	subscribers []func(cfg T)
+}
+
+func New[T any](log *slog.Logger, cfg T, opts Options[T]) *Reloader[T] {
+	versionInfo.WithLabelValues(opts.Version).Set(1)
+	log.Info("config loaded", slog.String("version", opts.Version), slog.String("path", opts.Path))
+
+	return &Reloader[T]{log: log, opts: opts, current: cfg, version: opts.Version}
+}
+
+// Subscribe calls apply with every config the Reloader switches to. apply
+// runs on the reloading goroutine and must neither block nor call the
+// Reloader.
+func (r *Reloader[T]) Subscribe(apply func(cfg T)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscribers = append(r.subscribers, apply)
+}
+
+// Current is the running config.
+func (r *Reloader[T]) Current() T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.current
+}
+
+// Reload rereads the config file and applies it unless it is unchanged.
+func (r *Reloader[T]) Reload() error {
+	const op = "reload.Reload"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := os.ReadFile(r.opts.Path)
+	if err != nil {
+		reloadsTotal.WithLabelValues("rejected").Inc()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	v := Version(data)
+	if v == r.version {
+		return nil
+	}
+
+	next, err := r.opts.Parse(r.opts.Path, data)
+	if err != nil {
+		r.rejected = v
+		reloadsTotal.WithLabelValues("rejected").Inc()
+
+		return fmt.Errorf("%s: version %s: %w", op, v, err)
+	}
+
+	cfg := r.opts.Reloadable(r.current, next)
+	if !reflect.DeepEqual(cfg, next) {
+		r.log.Warn("config has changes that need a restart, they are ignored until then",
+			slog.String("version", v))
+	}
+
+	versionInfo.DeleteLabelValues(r.version)
+	versionInfo.WithLabelValues(v).Set(1)
+
+	r.log.Info("config reloaded", slog.String("from", r.version), slog.String("to", v))
+	r.current = cfg
+	r.version = v
+
+	for _, apply := range r.subscribers {
+		apply(cfg)
+	}
+
+	reloadsTotal.WithLabelValues("applied").Inc()
+
+	return nil
+}
+
+// Run reloads the config on SIGHUP and, every Interval, when the file
+// changed, until ctx is done.
+func (r *Reloader[T]) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+
+	if r.opts.Interval > 0 {
+		ticker := time.NewTicker(r.opts.Interval)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reload()
+		case <-tick:
+			if r.changed() {
+				r.reload()
+			}
+		}
+	}
+}
+
+func (r *Reloader[T]) reload() {
+	if err := r.Reload(); err != nil {
+		r.mu.Lock()
+		running := r.version
+		r.mu.Unlock()
+
+		r.log.Error("config rejected, keeping the running one", slog.String("version", running),
+			slog.String("error", err.Error()))
+	}
+}
+
+// changed reports whether the file differs from the running config and from
+// the last rejected one, so that a bad file is only reported once.
+func (r *Reloader[T]) changed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := os.ReadFile(r.opts.Path)
+	if err != nil {
+		return false
+	}
+
+	v := Version(data)
+
+	return v != r.version && v != r.rejected
+}
@@ -0,0 +1,173 @@
+package reload
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Address   string `json:"address"`
+	RateLimit int    `json:"rate_limit"`
+	Version   string `json:"-"`
+}
+
+func parse(_ string, data []byte) (*testConfig, error) {
+	var cfg testConfig
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.RateLimit <= 0 {
+		return nil, errors.New("rate_limit must be positive")
+	}
+
+	cfg.Version = Version(data)
+
+	return &cfg, nil
+}
+
+// reloadable lets only the rate limit change at runtime.
+func reloadable(current, next *testConfig) *testConfig {
+	cfg := *current
+
+	cfg.Version = next.Version
+	cfg.RateLimit = next.RateLimit
+
+	return &cfg
+}
+
+func writeConfig(t *testing.T, path, address string, rateLimit int) {
+	t.Helper()
+
+	data := fmt.Sprintf(`{"address":%q,"rate_limit":%d}`, address, rateLimit)
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+}
+
+func newReloader(t *testing.T, interval time.Duration) (*Reloader[*testConfig], string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfig(t, path, "0.0.0.0:8080", 10)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	cfg, err := parse(path, data)
+	require.NoError(t, err)
+
+	return New(slog.New(slog.NewJSONHandler(io.Discard, nil)), cfg, Options[*testConfig]{
+		Path:       path,
+		Version:    cfg.Version,
+		Interval:   interval,
+		Parse:      parse,
+		Reloadable: reloadable,
+	}), path
+}
+
+func TestReloader_Reload(t *testing.T) {
+	reloader, path := newReloader(t, 0)
+	first := reloader.Current()
+
+	var applied []*testConfig
+
+	reloader.Subscribe(func(cfg *testConfig) { applied = append(applied, cfg) })
+
+	require.NoError(t, reloader.Reload())
+	require.Empty(t, applied, "an unchanged file is not applied again")
+
+	writeConfig(t, path, "0.0.0.0:8080", 20)
+
+	require.NoError(t, reloader.Reload())
+	require.Len(t, applied, 1)
+	require.Equal(t, 20, applied[0].RateLimit)
+	require.Equal(t, applied[0], reloader.Current())
+	require.NotEqual(t, first.Version, reloader.Current().Version)
+
+	require.InDelta(t, 1, testutil.ToFloat64(versionInfo.WithLabelValues(reloader.Current().Version)), 0)
+	require.False(t, versionInfo.DeleteLabelValues(first.Version), "the old version must leave the metric")
+}
+
+func TestReloader_RejectsInvalidConfig(t *testing.T) {
+	reloader, path := newReloader(t, 0)
+	running := reloader.Current()
+
+	var calls atomic.Int32
+
+	reloader.Subscribe(func(*testConfig) { calls.Add(1) })
+
+	writeConfig(t, path, "0.0.0.0:8080", -1)
+
+	require.ErrorContains(t, reloader.Reload(), "rate_limit")
+	require.Same(t, running, reloader.Current())
+	require.Zero(t, calls.Load())
+	require.False(t, reloader.changed(), "a rejected file is not reloaded again until it changes")
+
+	writeConfig(t, path, "0.0.0.0:8080", 5)
+
+	require.True(t, reloader.changed())
+	require.NoError(t, reloader.Reload())
+	require.Equal(t, 5, reloader.Current().RateLimit)
+}
+
+func TestReloader_KeepsRestartOnlySettings(t *testing.T) {
+	reloader, path := newReloader(t, 0)
+
+	writeConfig(t, path, "0.0.0.0:9090", 30)
+
+	require.NoError(t, reloader.Reload())
+
+	cfg := reloader.Current()
+	require.Equal(t, "0.0.0.0:8080", cfg.Address)
+	require.Equal(t, 30, cfg.RateLimit)
+}
+
+func TestReloader_Run(t *testing.T) {
+	reloader, path := newReloader(t, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go reloader.Run(ctx)
+
+	writeConfig(t, path, "0.0.0.0:8080", 40)
+
+	require.Eventually(t, func() bool { return reloader.Current().RateLimit == 40 },
+		time.Second, 5*time.Millisecond)
+}
+
+func TestReloader_RunOnSIGHUP(t *testing.T) {
+	// Keeps a SIGHUP that arrives before Run listens from killing the test.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	defer signal.Stop(hup)
+
+	reloader, path := newReloader(t, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go reloader.Run(ctx)
+
+	writeConfig(t, path, "0.0.0.0:8080", 50)
+
+	require.Eventually(t, func() bool {
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+		return reloader.Current().RateLimit == 50
+	}, time.Second, 20*time.Millisecond)
+}
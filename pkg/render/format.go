@@ -0,0 +1,76 @@
+package render
+
+import (
+	"html"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatHTML       Format = "HTML"
+	FormatMarkdownV2 Format = "MarkdownV2"
+)
+
+type markup interface {
+	escape(text string) string
+	bold(text string) string
+	italic(text string) string
+	link(text, url string) string
+}
+
+func markupFor(format Format) (markup, bool) {
+	switch format {
+	case FormatHTML:
+		return htmlMarkup{}, true
+	case FormatMarkdownV2:
+		return markdownMarkup{}, true
+	default:
+		return nil, false
+	}
+}
+
+type htmlMarkup struct{}
+
+func (htmlMarkup) escape(text string) string {
+	return html.EscapeString(text)
+}
+
+func (m htmlMarkup) bold(text string) string {
+	return "<b>" + m.escape(text) + "</b>"
+}
+
+func (m htmlMarkup) italic(text string) string {
+	return "<i>" + m.escape(text) + "</i>"
+}
+
+func (m htmlMarkup) link(text, url string) string {
+	return `<a href="` + m.escape(url) + `">` + m.escape(text) + "</a>"
+}
+
+type markdownMarkup struct{}
+
+// See https://core.telegram.org/bots/api#markdownv2-style.
+var (
+	markdownEscaper = strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+		">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+	)
+	markdownURLEscaper = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+)
+
+func (markdownMarkup) escape(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+func (m markdownMarkup) bold(text string) string {
+	return "*" + m.escape(text) + "*"
+}
+
+func (m markdownMarkup) italic(text string) string {
+	return "_" + m.escape(text) + "_"
+}
+
+func (m markdownMarkup) link(text, url string) string {
+	return "[" + m.escape(text) + "](" + markdownURLEscaper.Replace(url) + ")"
+}
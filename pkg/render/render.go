@@ -0,0 +1,164 @@
+package render
+
+import (
+	"pkg/i18n"
+
+	"bytes"
+	"embed"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	ProviderGitHub        = "github"
+	ProviderStackOverflow = "stackoverflow"
+
+	EventGitHubPullRequest    = "github.pull_request"
+	EventGitHubIssue          = "github.issue"
+	EventStackOverflowAnswer  = "stackoverflow.answer"
+	EventStackOverflowComment = "stackoverflow.comment"
+
+	defaultTemplate = "default"
+	headerSuffix    = ".header"
+	previewLength   = 200
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+type Item struct {
+	ID        string
+	Type      string
+	Title     string
+	URL       string
+	Author    string
+	UpdatedAt time.Time
+	Body      string
+}
+
+type Digest struct {
+	Lang     i18n.Lang
+	Provider string
+	Title    string
+	URL      string
+	Items    []Item
+}
+
+// Renderer turns update digests into Telegram messages. Every event type has
+// its own template named after it; templates from templatesPath override the
+// built-in ones with the same name. Templates are parsed once per language so
+// that the "t" and "ago" functions speak the language of the digest.
+type Renderer struct {
+	format Format
+	tmpl   map[i18n.Lang]*template.Template
+}
+
+func New(format Format, templatesPath string, now func() time.Time) (*Renderer, error) {
+	const op = "render.New"
+
+	m, ok := markupFor(format)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported format %q", op, format)
+	}
+
+	r := &Renderer{format: format, tmpl: make(map[i18n.Lang]*template.Template)}
+
+	for _, lang := range i18n.Supported() {
+		tmpl, err := parse(m, lang, templatesPath, now)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		r.tmpl[lang] = tmpl
+	}
+
+	return r, nil
+}
+
+func parse(m markup, lang i18n.Lang, templatesPath string, now func() time.Time) (*template.Template, error) {
+	funcs := template.FuncMap{
+		"esc":    m.escape,
+		"bold":   m.bold,
+		"italic": m.italic,
+		"link":   m.link,
+		"t": func(key string) string {
+			return i18n.T(lang, key)
+		},
+		"ago": func(t time.Time) string {
+			return m.escape(relativeTime(t, now(), lang))
+		},
+		"preview": func(body string) string {
+			return preview(body, previewLength)
+		},
+	}
+
+	tmpl, err := template.New("").Funcs(funcs).ParseFS(builtinTemplates, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	if templatesPath != "" {
+		tmpl, err = tmpl.ParseGlob(filepath.Join(templatesPath, "*.tmpl"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tmpl, nil
+}
+
+// Excerpt is the plain text body preview used in rendered messages.
+func Excerpt(body string) string {
+	return preview(body, previewLength)
+}
+
+func (r *Renderer) Format() Format {
+	return r.format
+}
+
+func (r *Renderer) Render(digest Digest) (string, error) {
+	const op = "render.Render"
+
+	sections := make([]string, 0, len(digest.Items)+1)
+
+	tmpl, ok := r.tmpl[digest.Lang]
+	if !ok {
+		tmpl = r.tmpl[i18n.Default]
+	}
+
+	header, err := execute(tmpl, digest.Provider+headerSuffix, digest)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	sections = append(sections, header)
+
+	for _, item := range digest.Items {
+		section, err := execute(tmpl, item.Type, item)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+
+		sections = append(sections, section)
+	}
+
+	return strings.Join(sections, "\n\n"), nil
+}
+
+func execute(set *template.Template, name string, data any) (string, error) {
+	tmpl := set.Lookup(name + ".tmpl")
+	if tmpl == nil {
+		tmpl = set.Lookup(defaultTemplate + ".tmpl")
+	}
+
+	var buf bytes.Buffer
+
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
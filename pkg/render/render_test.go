@@ -0,0 +1,127 @@
+package render_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pkg/i18n"
+	"pkg/render"
+
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+var now = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func digests() map[string]render.Digest {
+	return map[string]render.Digest{
+		"github": {
+			Provider: render.ProviderGitHub,
+			Title:    "example/repo",
+			URL:      "https://github.com/example/repo",
+			Items: []render.Item{
+				{
+					Type:      render.EventGitHubPullRequest,
+					Title:     "Fix <script> injection & [escaping]",
+					URL:       "https://github.com/example/repo/pull/42",
+					Author:    "user_1",
+					UpdatedAt: now.Add(-5 * time.Minute),
+					Body:      "Closes #41.\n\nReplaces `fmt.Sprintf` with *proper* escaping (see docs).",
+				},
+				{
+					Type:      render.EventGitHubIssue,
+					Title:     "Crash on start",
+					URL:       "https://github.com/example/repo/issues/43",
+					Author:    "user2",
+					UpdatedAt: now.Add(-3 * time.Hour),
+					Body:      strings.Repeat("very long stack trace ", 20),
+				},
+			},
+		},
+		"stackoverflow": {
+			Provider: render.ProviderStackOverflow,
+			Title:    "questions/123456",
+			URL:      "https://stackoverflow.com/questions/123456",
+			Items: []render.Item{
+				{
+					Type:      render.EventStackOverflowAnswer,
+					URL:       "https://stackoverflow.com/a/654321",
+					Author:    "Jon Skeet",
+					UpdatedAt: now.Add(-2 * 24 * time.Hour),
+					Body:      "<p>Use <code>sync.Once</code> &amp; you are done.</p>",
+				},
+				{
+					Type:      render.EventStackOverflowComment,
+					URL:       "https://stackoverflow.com/questions/123456#comment1_654321",
+					Author:    "anon",
+					UpdatedAt: now.Add(-60 * 24 * time.Hour),
+				},
+			},
+		},
+		"unknown_event": {
+			Provider: render.ProviderGitHub,
+			Title:    "example/repo",
+			URL:      "https://github.com/example/repo",
+			Items: []render.Item{
+				{Type: "github.release", Title: "v1.0.0", URL: "https://github.com/example/repo/releases/v1.0.0"},
+			},
+		},
+	}
+}
+
+func TestRenderer_Golden(t *testing.T) {
+	for _, format := range []render.Format{render.FormatHTML, render.FormatMarkdownV2} {
+		renderer, err := render.New(format, "", func() time.Time { return now })
+		require.NoError(t, err)
+
+		for _, lang := range i18n.Supported() {
+			for name, digest := range digests() {
+				digest.Lang = lang
+
+				t.Run(string(format)+"/"+string(lang)+"/"+name, func(t *testing.T) {
+					got, err := renderer.Render(digest)
+					require.NoError(t, err)
+
+					golden := filepath.Join("testdata",
+						name+"."+string(lang)+"."+strings.ToLower(string(format))+".golden")
+
+					if *update {
+						require.NoError(t, os.WriteFile(golden, []byte(got), 0o600))
+					}
+
+					want, err := os.ReadFile(golden)
+					require.NoError(t, err)
+					assert.Equal(t, string(want), got)
+				})
+			}
+		}
+	}
+}
+
+func TestRenderer_OverrideTemplates(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "github.issue.tmpl"), []byte(`{{bold .Title}}`), 0o600))
+
+	renderer, err := render.New(render.FormatHTML, dir, func() time.Time { return now })
+	require.NoError(t, err)
+
+	got, err := renderer.Render(render.Digest{
+		Provider: render.ProviderGitHub,
+		Title:    "example/repo",
+		URL:      "https://github.com/example/repo",
+		Items:    []render.Item{{Type: render.EventGitHubIssue, Title: "a < b"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "<b>Новые изменения на GitHub</b> <a href=\"https://github.com/example/repo\">example/repo</a>\n\n<b>a &lt; b</b>", got)
+}
+
+func TestNew_UnsupportedFormat(t *testing.T) {
+	_, err := render.New("Markdown", "", time.Now)
+	assert.Error(t, err)
+}
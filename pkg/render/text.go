@@ -0,0 +1,48 @@
+package render
+
+import (
+	"pkg/i18n"
+
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var tagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// preview turns a provider body (markdown from GitHub, HTML from
+// StackOverflow) into a single-line plain text excerpt of at most limit runes.
+func preview(body string, limit int) string {
+	text := html.UnescapeString(tagRegex.ReplaceAllString(body, " "))
+	text = strings.Join(strings.Fields(text), " ")
+
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	cut := strings.TrimRight(string(runes[:limit]), " ")
+	if i := strings.LastIndex(cut, " "); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+
+	return cut + "…"
+}
+
+func relativeTime(t, now time.Time, lang i18n.Lang) string {
+	d := now.Sub(t)
+
+	switch {
+	case d < time.Minute:
+		return i18n.T(lang, i18n.KeyTimeJustNow)
+	case d < time.Hour:
+		return i18n.T(lang, i18n.KeyTimeMinutesAgo, int(d.Minutes()))
+	case d < 24*time.Hour:
+		return i18n.T(lang, i18n.KeyTimeHoursAgo, int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return i18n.T(lang, i18n.KeyTimeDaysAgo, int(d.Hours()/24))
+	default:
+		return t.Format(i18n.T(lang, i18n.KeyTimeDateLayout))
+	}
+}
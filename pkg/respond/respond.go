@@ -0,0 +1,9 @@
+// Package respond lets handlers shared through pkg answer errors in the
+// format of the service that mounts them.
+package respond
+
+import "net/http"
+
+// Error writes an API error with statusCode, e.g. utils.RespondWithError of
+// a service.
+type Error func(writer http.ResponseWriter, statusCode int, description, code, exceptionName, exceptionMessage string)
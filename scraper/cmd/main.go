@@ -5,22 +5,39 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/httprate"
 	"github.com/go-co-op/gocron"
+	"google.golang.org/grpc"
+	"pkg/admin"
+	"pkg/api/scraperv1"
+	"pkg/auth"
+	"pkg/breaker"
+	breakershandler "pkg/breakers"
+	"pkg/ratelimit"
 	scraperapplication "scraper/internal/application"
 	"scraper/internal/clients/github"
 	"scraper/internal/clients/sender"
 	"scraper/internal/clients/stackoverflow"
 	scraperconfig "scraper/internal/config"
 	cronModel "scraper/internal/cron"
+	"scraper/internal/grpcapi"
+	"scraper/internal/health"
 	addlinkhandler "scraper/internal/http/handlers/add_link"
 	deletehandler "scraper/internal/http/handlers/delete_chat"
+	gethistoryhandler "scraper/internal/http/handlers/get_history"
 	getlinkhandler "scraper/internal/http/handlers/get_links"
+	issuetokenhandler "scraper/internal/http/handlers/issue_token"
 	newchathandler "scraper/internal/http/handlers/new_chat"
 	removelinkhandler "scraper/internal/http/handlers/remove_link"
+	revoketokenshandler "scraper/internal/http/handlers/revoke_tokens"
+	"scraper/internal/http/middleware/apitoken"
 	mwlogger "scraper/internal/http/middleware/logger"
 	mw "scraper/internal/http/middleware/prometheus"
+	"scraper/internal/http/openapi"
 	"scraper/internal/metrics"
+	scrapModel "scraper/internal/model/scraper"
 	db "scraper/internal/storage/postgres"
+	"scraper/internal/tracing"
 	scraperUC "scraper/internal/usecase"
+	"scraper/utils"
 
 	"context"
 	"log/slog"
@@ -45,6 +62,13 @@ func main() {
 	log := setupLogger(cfg.Env)
 	ctx, cancel := context.WithCancel(context.Background())
 	metricManager := metrics.NewMetricManager()
+	reloader := scraperconfig.NewReloader(log, cfg)
+
+	shutdownTracing, err := tracing.Setup(ctx, &cfg.Tracing)
+	if err != nil {
+		log.Error("Failed to initialize tracing", slog.String("error", err.Error()))
+		return
+	}
 
 	storage, err := db.New(ctx, cfg.Scraper.AccessType, cfg.Scraper.StoragePath, cfg.Scraper.MaxConn, cfg.Scraper.MinConn)
 	if err != nil {
@@ -64,13 +88,23 @@ func main() {
 		return
 	}
 
-	updateSender, err := sender.New(log, cfg)
+	var (
+		updateStream sender.Sender
+		updates      *grpcapi.Updates
+	)
+
+	if cfg.Scraper.GRPCAddress != "" {
+		updates = grpcapi.NewUpdates()
+		updateStream = updates
+	}
+
+	updateSender, err := sender.New(log, cfg, updateStream)
 	if err != nil {
 		log.Error("Failed to initialize sender client")
 		return
 	}
 
-	cron, err := setupCron(log, storage, gitClient, stackClient, updateSender, cfg.Scraper.BatchSize)
+	cron, err := setupCron(log, storage, gitClient, stackClient, updateSender, &cfg.Scraper)
 	if err != nil {
 		log.Error("Failed to initialize cron")
 		return
@@ -79,10 +113,38 @@ func main() {
 	metricManager.StartCollecting()
 	metricManager.CheckDBMetric(ctx, storage)
 
-	router := setupRouter(ctx, log, storage, cfg, metricManager)
+	checker := setupHealth(storage, gitClient, stackClient, updateSender, cron, &cfg.Scraper)
+	breakers := setupBreakers(gitClient, stackClient, updateSender)
+
+	setupReload(reloader, cron, gitClient, stackClient, updateSender)
+
+	nonces := setupNonces(log, &cfg.Scraper)
+
+	router, err := setupRouter(ctx, log, storage, cfg, metricManager, checker, breakers, reloader, nonces)
+	if err != nil {
+		log.Error("Failed to initialize router", slog.String("error", err.Error()))
+		return
+	}
 
 	server := scraperapplication.New(log, cfg.Scraper.Address, cfg.Scraper.Timeout, router, cron)
 
+	if updates != nil {
+		opts := []grpc.ServerOption{tracing.ServerOption()}
+
+		if keys := auth.Keys(cfg.Clients.Bot.ServiceKeys); len(keys) > 0 {
+			opts = append(opts,
+				grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(keys, nonces)),
+				grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(keys, nonces)),
+			)
+		}
+
+		grpcServer := grpc.NewServer(opts...)
+		scraperv1.RegisterScraperServiceServer(grpcServer,
+			grpcapi.New(log, scraperUC.New(log, storage, metricManager), updates))
+
+		server.WithGRPC(grpcServer, cfg.Scraper.GRPCAddress)
+	}
+
 	app := &App{
 		ScraperServer: server,
 	}
@@ -91,6 +153,8 @@ func main() {
 		app.ScraperServer.MustRun()
 	}()
 
+	go reloader.Run(ctx)
+
 	// Graceful shutdown
 
 	stop := make(chan os.Signal, 1)
@@ -99,44 +163,209 @@ func main() {
 	<-stop
 
 	cancel()
+
+	if updates != nil {
+		updates.Close()
+	}
+
 	app.ScraperServer.Stop()
+
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Error("Failed to flush traces", slog.String("error", err.Error()))
+	}
+
 	log.Info("Gracefully stopped")
 }
 
-func setupRouter(ctx context.Context, log *slog.Logger, storage db.Storage, cfg *scraperconfig.Config, manager *metrics.MetricManager) *chi.Mux {
+func setupRouter(ctx context.Context, log *slog.Logger, storage db.Storage, cfg *scraperconfig.Config,
+	manager *metrics.MetricManager, checker *health.Checker, breakers *breaker.Registry,
+	reloader *scraperconfig.Reloader, nonces auth.Nonces) (*chi.Mux, error) {
+	doc, err := openapi.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	validate, err := openapi.Validate(log, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimit := ratelimit.NewLimit(cfg.Scraper.RateLimit)
+	linksRateLimit := ratelimit.NewLimit(cfg.Scraper.LinksRateLimit)
+	apiRateLimit := ratelimit.NewLimit(cfg.Scraper.APIRateLimit)
+
+	reloader.Subscribe(func(cfg *scraperconfig.Config) {
+		rateLimit.Set(cfg.Scraper.RateLimit)
+		linksRateLimit.Set(cfg.Scraper.LinksRateLimit)
+		apiRateLimit.Set(cfg.Scraper.APIRateLimit)
+	})
+
 	router := chi.NewRouter()
 
 	router.Use(middleware.RequestID)
+	router.Use(tracing.Middleware)
 	router.Use(mwlogger.New(log))
 	router.Use(middleware.Recoverer)
-	router.Use(httprate.LimitByIP(cfg.Scraper.RateLimit, 1*time.Minute))
+	router.Use(rateLimit.Apply(httprate.LimitByIP(cfg.Scraper.RateLimit, 1*time.Minute)))
 	router.Use(mw.PrometheusMiddleware)
 
-	router.Route("/tg-chat", func(r chi.Router) {
-		r.Post("/{id}", newchathandler.New(ctx, log, scraperUC.New(log, storage, manager)))
-		r.Delete("/{id}", deletehandler.New(ctx, log, scraperUC.New(log, storage, manager)))
+	// The contract is checked after authentication, so that an unauthenticated
+	// caller gets a 401 and learns nothing about the schema.
+	router.Group(func(router chi.Router) {
+		router.Use(validate)
+
+		router.Get("/openapi.json", openapi.Handler(doc))
+		router.Get("/healthz", checker.Live())
+		router.Get("/readyz", checker.Ready())
 	})
 
-	router.Route("/links", func(r chi.Router) {
-		r.Use(httprate.LimitByIP(cfg.Scraper.LinksRateLimit, 1*time.Minute))
-		r.Get("/", getlinkhandler.New(ctx, log, scraperUC.New(log, storage, manager)))
-		r.Post("/", addlinkhandler.New(ctx, log, scraperUC.New(log, storage, manager)))
-		r.Delete("/", removelinkhandler.New(ctx, log, scraperUC.New(log, storage, manager)))
+	router.Group(func(router chi.Router) {
+		if keys := cfg.Clients.Bot.ServiceKeys; len(keys) > 0 {
+			router.Use(auth.Middleware(log, keys, nonces, utils.RespondWithError))
+		} else {
+			log.Warn("No service keys configured, chat and link endpoints accept unsigned requests")
+		}
+
+		router.Use(validate)
+
+		router.Route("/tg-chat", func(r chi.Router) {
+			r.Post("/{id}", newchathandler.New(ctx, log, scraperUC.New(log, storage, manager)))
+			r.Delete("/{id}", deletehandler.New(ctx, log, scraperUC.New(log, storage, manager)))
+			r.Post("/{id}/tokens", issuetokenhandler.New(ctx, log, scraperUC.New(log, storage, manager)))
+			r.Delete("/{id}/tokens", revoketokenshandler.New(ctx, log, scraperUC.New(log, storage, manager)))
+		})
+
+		router.Route("/links", func(r chi.Router) {
+			r.Use(linksRateLimit.Apply(httprate.LimitByIP(cfg.Scraper.LinksRateLimit, 1*time.Minute)))
+			r.Get("/", getlinkhandler.New(ctx, log, scraperUC.New(log, storage, manager)))
+			r.Post("/", addlinkhandler.New(ctx, log, scraperUC.New(log, storage, manager)))
+			r.Delete("/", removelinkhandler.New(ctx, log, scraperUC.New(log, storage, manager)))
+		})
+
+		router.Get("/history", gethistoryhandler.New(ctx, log, scraperUC.New(log, storage, manager)))
+	})
+
+	// The public API is used by scripts with tokens issued through the bot.
+	router.Route("/api/v1", func(r chi.Router) {
+		r.Use(apitoken.New(log, scraperUC.New(log, storage, manager)))
+		r.Use(apiRateLimit.Apply(apitoken.RateLimit(cfg.Scraper.APIRateLimit, 1*time.Minute)))
+		r.Use(validate)
+
+		read := apitoken.RequireScope(scrapModel.ScopeLinksRead)
+		write := apitoken.RequireScope(scrapModel.ScopeLinksWrite)
+
+		r.With(read).Get("/links", getlinkhandler.New(ctx, log, scraperUC.New(log, storage, manager)))
+		r.With(write).Post("/links", addlinkhandler.New(ctx, log, scraperUC.New(log, storage, manager)))
+		r.With(write).Delete("/links", removelinkhandler.New(ctx, log, scraperUC.New(log, storage, manager)))
+		r.With(read).Get("/updates", gethistoryhandler.New(ctx, log, scraperUC.New(log, storage, manager)))
 	})
 
-	return router
+	// Admin endpoints are only served when a token is configured.
+	if cfg.Scraper.AdminToken != "" {
+		router.Route("/admin", func(r chi.Router) {
+			r.Use(admin.New(cfg.Scraper.AdminToken, utils.RespondWithError))
+			r.Use(validate)
+
+			r.Get("/breakers", breakershandler.List(breakers))
+			r.Post("/breakers/{name}/reset", breakershandler.Reset(log, breakers, utils.RespondWithError))
+		})
+	}
+
+	return router, nil
+}
+
+// setupHealth registers the checks of /readyz. The external APIs and the
+// Kafka fallback only degrade the report: the scraper keeps serving without
+// them.
+func setupHealth(storage db.Storage, gitClient *github.Client, stackClient *stackoverflow.Client,
+	updateSender sender.Sender, cron *cronModel.Cron, cfg *scraperconfig.ScraperConfig) *health.Checker {
+	checker := health.New(cfg.Timeout)
+
+	checker.Add("postgres", storage.Ping)
+	checker.Add("cron", cron.CheckTick(cfg.MaxTickAge))
+	checker.AddOptional("github_breaker", health.Breaker(gitClient.Breaker()))
+	checker.AddOptional("stackoverflow_breaker", health.Breaker(stackClient.Breaker()))
+
+	if fallback, ok := updateSender.(*sender.FallbackSender); ok {
+		if client := fallback.HTTPClient(); client != nil {
+			checker.AddOptional("bot_breaker", health.Breaker(client.Breaker()))
+		}
+
+		if producer := fallback.Producer(); producer != nil {
+			checker.AddOptional("kafka_producer", producer.Check)
+		}
+	}
+
+	return checker
+}
+
+// setupNonces picks where the nonces of requests signed by the bot are kept.
+func setupNonces(log *slog.Logger, cfg *scraperconfig.ScraperConfig) auth.Nonces {
+	if cfg.NonceStoragePath == "" {
+		log.Warn("No nonce storage configured, replayed requests are only caught by the replica that saw them")
+		return auth.NewMemoryNonces()
+	}
+
+	return auth.NewRedisNonces(cfg.NonceStoragePath)
+}
+
+// setupBreakers collects the circuit breakers shown and reset under
+// /admin/breakers.
+func setupBreakers(gitClient *github.Client, stackClient *stackoverflow.Client,
+	updateSender sender.Sender) *breaker.Registry {
+	breakers := breaker.NewRegistry()
+
+	breakers.Add("github", gitClient.Breaker())
+	breakers.Add("stackoverflow", stackClient.Breaker())
+
+	if fallback, ok := updateSender.(*sender.FallbackSender); ok {
+		if client := fallback.HTTPClient(); client != nil {
+			breakers.Add("bot", client.Breaker())
+		}
+	}
+
+	return breakers
+}
+
+// setupReload hands the settings a config reload may change to the cron and
+// the clients. The rate limits are handled by setupRouter.
+func setupReload(reloader *scraperconfig.Reloader, cron *cronModel.Cron, gitClient *github.Client,
+	stackClient *stackoverflow.Client, updateSender sender.Sender) {
+	var botClient *sender.Client
+
+	if fallback, ok := updateSender.(*sender.FallbackSender); ok {
+		botClient = fallback.HTTPClient()
+	}
+
+	reloader.Subscribe(func(cfg *scraperconfig.Config) {
+		cron.Configure(cfg.Scraper.BatchSize, cfg.Scraper.MaxFailures)
+		gitClient.Configure(&cfg.Clients)
+		stackClient.Configure(&cfg.Clients)
+
+		if botClient != nil {
+			botClient.Configure(&cfg.Clients)
+		}
+	})
 }
 
 func setupCron(log *slog.Logger, storage db.Storage, gitClient *github.Client,
-	stackClient *stackoverflow.Client, sender cronModel.Sender, batchSize uint64) (*cronModel.Cron, error) {
+	stackClient *stackoverflow.Client, sender cronModel.Sender,
+	cfg *scraperconfig.ScraperConfig) (*cronModel.Cron, error) {
 	baseCron := gocron.NewScheduler(time.UTC)
-	cron := cronModel.New(log, baseCron, storage, gitClient, stackClient, sender, batchSize)
+	cron := cronModel.New(log, baseCron, storage, gitClient, stackClient, sender, cfg.BatchSize,
+		cfg.MaxFailures, cfg.HistoryRetention)
 
 	_, err := cron.Cron.Every(1).Minutes().Do(cron.UpdateCron)
 	if err != nil {
 		return nil, err
 	}
 
+	if cfg.HistoryRetention > 0 {
+		if _, err = cron.Cron.Every(1).Hours().Do(cron.CleanupHistory); err != nil {
+			return nil, err
+		}
+	}
+
 	return cron, nil
 }
 
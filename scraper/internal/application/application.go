@@ -3,19 +3,24 @@ package application
 import (
 	"github.com/go-chi/chi/v5"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
 	"scraper/internal/cron"
 
 	"context"
 	"log/slog"
+	"net"
 	"net/http"
 	"sync"
 	"time"
 )
 
 type App struct {
-	log    *slog.Logger
-	server *http.Server
-	cron   *cron.Cron
+	log         *slog.Logger
+	server      *http.Server
+	grpcServer  *grpc.Server
+	grpcAddress string
+	timeout     time.Duration
+	cron        *cron.Cron
 }
 
 func New(
@@ -34,12 +39,21 @@ func New(
 	}
 
 	return &App{
-		log:    log,
-		server: srv,
-		cron:   cron,
+		log:     log,
+		server:  srv,
+		timeout: timeout,
+		cron:    cron,
 	}
 }
 
+// WithGRPC serves grpcServer on address next to the HTTP API.
+func (a *App) WithGRPC(grpcServer *grpc.Server, address string) *App {
+	a.grpcServer = grpcServer
+	a.grpcAddress = address
+
+	return a
+}
+
 func (a *App) MustRun() {
 	if err := a.Run(); err != nil {
 		panic(err)
@@ -61,6 +75,25 @@ func (a *App) Run() error {
 		}
 	}()
 
+	if a.grpcServer != nil {
+		listener, err := net.Listen("tcp", a.grpcAddress)
+		if err != nil {
+			return err
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			a.log.Info("gRPC listening", slog.String("addr", a.grpcAddress))
+
+			if err := a.grpcServer.Serve(listener); err != nil {
+				a.log.Error("gRPC server error", slog.String("err", err.Error()))
+			}
+		}()
+	}
+
 	wg.Add(1)
 
 	metricsMux := http.NewServeMux()
@@ -107,5 +140,26 @@ func (a *App) Stop() {
 		a.log.Info("failed to stop server", slog.String("op", op), slog.String("err", err.Error()))
 	}
 
+	if a.grpcServer != nil {
+		a.stopGRPC()
+	}
+
 	a.cron.Cron.Stop()
 }
+
+// stopGRPC waits for unary calls to finish; update streams that are still
+// open after the timeout are cut.
+func (a *App) stopGRPC() {
+	stopped := make(chan struct{})
+
+	go func() {
+		a.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(a.timeout):
+		a.grpcServer.Stop()
+	}
+}
@@ -1,110 +1,174 @@
 package github
 
 import (
-	"github.com/avast/retry-go/v4"
-	"github.com/sony/gobreaker"
+	"pkg/breaker"
+	"pkg/httpclient"
 	"scraper/internal/config"
 	"scraper/internal/metrics"
 	"scraper/internal/model/github"
 	"scraper/internal/model/scraper"
+	"scraper/internal/tracing"
 
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
 
+var (
+	ErrRepoNotFound    = errors.New("repository not found")
+	ErrRepoUnavailable = errors.New("repository is unavailable for legal reasons")
+)
+
+// movedError is returned for a 301 that GitHub sends for renamed and
+// transferred repositories.
+type movedError struct {
+	location string
+}
+
+func (e *movedError) Error() string {
+	return "repository moved to " + e.location
+}
+
 type Client struct {
+	apiBase       string
 	token         string
 	log           *slog.Logger
-	client        *http.Client
-	retries       uint
-	backoff       time.Duration
-	breaker       *gobreaker.CircuitBreaker
+	client        *httpclient.Client
 	metricManager *metrics.MetricManager
 }
 
-const apiURL = "https://api.github.com/repos/%s/%s"
+const (
+	apiBase = "https://api.github.com"
+	apiURL  = "%s/repos/%s/%s"
+	webURL  = "https://github.com/"
+)
 
 func New(log *slog.Logger, cfg *config.ClientsConfig, metrics *metrics.MetricManager) (*Client, error) {
-	httpClient := &http.Client{
-		Timeout: cfg.Github.Timeout,
-	}
+	client := httpclient.New(log, httpclient.Options{
+		Name:        "github",
+		BreakerName: "GitHub API Circuit Breaker",
+		Breaker:     cfg.CircuitBreaker,
+		Timeout:     cfg.Github.Timeout,
+		Retries:     cfg.Github.Retry,
+		Backoff:     cfg.Github.Backoff,
+		Transport:   tracing.Transport(nil),
+		// Redirects of renamed repositories are followed by GetUpdates, which
+		// also has to learn the new name.
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		CheckResponse: checkResponse,
+		// A missing or moved repository says nothing about GitHub's health.
+		IsSuccessful: func(err error) bool {
+			var moved *movedError
 
-	cbSettings := gobreaker.Settings{
-		Name:        "GitHub API Circuit Breaker",
-		MaxRequests: cfg.CircuitBreaker.SlidingWindowSize,
-		Timeout:     cfg.CircuitBreaker.Timeout,
-		ReadyToTrip: func(counts gobreaker.Counts) bool {
-			return counts.Requests >= cfg.CircuitBreaker.MaxRequests &&
-				counts.TotalFailures >= cfg.CircuitBreaker.FailureCount
+			return err == nil || errors.Is(err, ErrRepoNotFound) || errors.Is(err, ErrRepoUnavailable) ||
+				errors.As(err, &moved)
 		},
-	}
+	})
 
 	return &Client{
+		apiBase:       apiBase,
 		token:         cfg.Github.Token,
 		log:           log,
-		client:        httpClient,
-		backoff:       cfg.Github.Backoff,
-		retries:       cfg.Github.Retry,
-		breaker:       gobreaker.NewCircuitBreaker(cbSettings),
+		client:        client,
 		metricManager: metrics,
 	}, nil
 }
 
+// Breaker is the circuit breaker guarding the GitHub API.
+func (c *Client) Breaker() *breaker.Breaker {
+	return c.client.Breaker()
+}
+
+// Configure applies reloaded timeouts, retries and breaker settings.
+func (c *Client) Configure(cfg *config.ClientsConfig) {
+	c.client.Configure(httpclient.Options{
+		Breaker: cfg.CircuitBreaker,
+		Timeout: cfg.Github.Timeout,
+		Retries: cfg.Github.Retry,
+		Backoff: cfg.Github.Backoff,
+	})
+}
+
 func (c *Client) GetUpdates(ctx context.Context, link *scraper.Link) (*githubrepo.GitHubRepo, error) {
+	ctx, span := tracing.Start(ctx, "github.GetUpdates", tracing.LinkURL(link.URL))
+
+	repo, err := c.getUpdates(ctx, link)
+	tracing.End(span, err)
+
+	return repo, err
+}
+
+func (c *Client) getUpdates(ctx context.Context, link *scraper.Link) (*githubrepo.GitHubRepo, error) {
 	const op = "Client.GetIssues"
 
 	start := time.Now()
-	repo := strings.TrimPrefix(link.URL, "https://github.com/")
+	repo := strings.TrimPrefix(link.URL, webURL)
 
-	parts := strings.Split(repo, "/")
-	if len(parts) != 2 {
+	owner, repoName, ok := splitRepo(repo)
+	if !ok {
 		c.log.Info("Invalid repo format", "repo", repo)
 		return nil, fmt.Errorf("%s: invalid repo format", op)
 	}
 
-	owner := parts[0]
-	repoName := parts[1]
-	urlPR := fmt.Sprintf(apiURL, owner, repoName) + "/pulls?state=open"
-	urlIssue := fmt.Sprintf(apiURL, owner, repoName) + "/issues?state=open"
 	lastUpdate := *link.LastUpdated
 
-	prData, err := c.sendRequest(ctx, urlPR)
+	var (
+		newData githubrepo.GitHubRepo
+		prData  []githubrepo.GitHubData
+		moved   *movedError
+	)
+
+	err := c.sendRequest(ctx, c.repoURL(owner, repoName)+"/pulls?state=open", &prData)
+	if errors.As(err, &moved) {
+		newData.CanonicalURL, err = c.resolveMoved(ctx, moved.location)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to follow renamed repo: %w", op, err)
+		}
+
+		c.log.Info("Repo moved", "repo", repo, "url", newData.CanonicalURL)
+
+		owner, repoName, _ = splitRepo(strings.TrimPrefix(newData.CanonicalURL, webURL))
+		err = c.sendRequest(ctx, c.repoURL(owner, repoName)+"/pulls?state=open", &prData)
+	}
+
 	if err != nil {
 		c.log.Info("Failed to get PR data", "repo", repo)
-		return nil, fmt.Errorf("%s: failed to get PR data", op)
+		return nil, fmt.Errorf("%s: failed to get PR data: %w", op, err)
 	}
 
-	issueData, err := c.sendRequest(ctx, urlIssue)
+	var issueData []githubrepo.GitHubData
+
+	err = c.sendRequest(ctx, c.repoURL(owner, repoName)+"/issues?state=open", &issueData)
 	if err != nil {
 		c.log.Info("Failed to get Issue data", "repo", repo)
-		return nil, fmt.Errorf("%s: Failed to get Issue data", op)
+		return nil, fmt.Errorf("%s: Failed to get Issue data: %w", op, err)
 	}
 
-	var newData githubrepo.GitHubRepo
-
 	for _, pr := range prData {
-		pr.UpdatedAt = pr.UpdatedAt.Add(3 * time.Hour)
-		if pr.UpdatedAt.After(lastUpdate) {
+		updatedAt := pr.UpdatedAt.Add(3 * time.Hour)
+		if updatedAt.After(lastUpdate) {
 			newData.PoolRequests = append(newData.PoolRequests, pr)
 
-			if pr.UpdatedAt.After(*link.LastUpdated) {
-				*link.LastUpdated = pr.UpdatedAt
+			if updatedAt.After(*link.LastUpdated) {
+				*link.LastUpdated = updatedAt
 			}
 		}
 	}
 
 	for _, issue := range issueData {
-		issue.UpdatedAt = issue.UpdatedAt.Add(3 * time.Hour)
-		if issue.UpdatedAt.After(lastUpdate) {
+		updatedAt := issue.UpdatedAt.Add(3 * time.Hour)
+		if updatedAt.After(lastUpdate) {
 			newData.Issues = append(newData.Issues, issue)
 
-			if issue.UpdatedAt.After(*link.LastUpdated) {
-				*link.LastUpdated = issue.UpdatedAt
+			if updatedAt.After(*link.LastUpdated) {
+				*link.LastUpdated = updatedAt
 			}
 		}
 	}
@@ -114,58 +178,75 @@ func (c *Client) GetUpdates(ctx context.Context, link *scraper.Link) (*githubrep
 	return &newData, nil
 }
 
-func (c *Client) sendRequest(ctx context.Context, url string) ([]githubrepo.GitHubData, error) {
-	const op = "Client.SendRequest"
+func (c *Client) repoURL(owner, repoName string) string {
+	return fmt.Sprintf(apiURL, c.apiBase, owner, repoName)
+}
 
-	var result []githubrepo.GitHubData
-
-	_, err := c.breaker.Execute(func() (any, error) {
-		err := retry.Do(
-			func() error {
-				req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
-				if err != nil {
-					return retry.Unrecoverable(fmt.Errorf("%s: %w", op, err))
-				}
-
-				req.Header.Set("Accept", "application/vnd.github.v3+json")
-				req.Header.Set("Authorization", "token "+c.token)
-
-				c.log.Debug("Sending GET request", slog.String("url", req.URL.String()))
-
-				resp, err := c.client.Do(req)
-				if err != nil {
-					return fmt.Errorf("%s: %w", op, err)
-				}
-				defer resp.Body.Close()
-
-				if resp.StatusCode >= 500 || resp.StatusCode == 429 {
-					return fmt.Errorf("%s: temporary server error: %s", op, resp.Status)
-				} else if resp.StatusCode != http.StatusOK {
-					return retry.Unrecoverable(fmt.Errorf("%s: bad status: %s", op, resp.Status))
-				}
-
-				if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-					return retry.Unrecoverable(fmt.Errorf("%s: failed to decode response: %w", op, err))
-				}
-
-				return nil
-			},
-			retry.Attempts(c.retries),
-			retry.Delay(c.backoff),
-			retry.DelayType(retry.BackOffDelay),
-			retry.Context(ctx),
-		)
+func splitRepo(repo string) (owner, repoName string, ok bool) {
+	parts := strings.Split(repo, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
 
-		if err != nil {
-			return nil, err
-		}
+	return parts[0], parts[1], true
+}
 
-		return result, nil
-	})
+// resolveMoved turns the location of a 301, which points to the repository by
+// id (/repositories/{id}/...), into the repository's new web URL.
+func (c *Client) resolveMoved(ctx context.Context, location string) (string, error) {
+	const op = "Client.ResolveMoved"
 
+	parsed, err := url.Parse(location)
 	if err != nil {
-		return nil, fmt.Errorf("%s: retries exhausted: %w", op, err)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(segments) < 2 || segments[0] != "repositories" {
+		return "", fmt.Errorf("%s: unexpected location %q", op, location)
+	}
+
+	var info githubrepo.RepoInfo
+
+	if err = c.sendRequest(ctx, c.apiBase+"/repositories/"+segments[1], &info); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch {
+	case info.HTMLURL != "":
+		return info.HTMLURL, nil
+	case info.FullName != "":
+		return webURL + info.FullName, nil
+	default:
+		return "", fmt.Errorf("%s: repository %s has no name", op, segments[1])
+	}
+}
+
+func (c *Client) sendRequest(ctx context.Context, url string, result any) error {
+	const op = "Client.SendRequest"
+
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github.v3+json")
+	header.Set("Authorization", "token "+c.token)
+
+	err := c.client.Do(ctx, httpclient.Request{Method: http.MethodGet, URL: url, Header: header}, result)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// checkResponse tells renamed and gone repositories from GitHub's failures.
+func checkResponse(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect:
+		return &movedError{location: resp.Header.Get("Location")}
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrRepoNotFound, httpclient.NewStatusError(resp))
+	case http.StatusUnavailableForLegalReasons:
+		return fmt.Errorf("%w: %w", ErrRepoUnavailable, httpclient.NewStatusError(resp))
 	}
 
-	return result, nil
+	return nil
 }
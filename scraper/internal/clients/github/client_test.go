@@ -0,0 +1,90 @@
+package github
+
+import (
+	"github.com/stretchr/testify/require"
+	"scraper/internal/config"
+	"scraper/internal/metrics"
+	"scraper/internal/model/scraper"
+
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var metricManager = metrics.NewMetricManager()
+
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := New(slog.New(slog.NewJSONHandler(io.Discard, nil)), &config.ClientsConfig{
+		Github: config.Client{Timeout: time.Second, Retry: 1, Backoff: time.Millisecond},
+		CircuitBreaker: config.CBConfig{
+			MinimumCalls: 1,
+			Timeout:      time.Second,
+		},
+	}, metricManager)
+	require.NoError(t, err)
+
+	client.apiBase = server.URL
+
+	return client
+}
+
+func newLink(url string) *scraper.Link {
+	lastUpdated := time.Now().Add(-24 * time.Hour)
+	return &scraper.Link{URL: url, LastUpdated: &lastUpdated}
+}
+
+func TestClient_GetUpdates_FollowsRename(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/old/repo/pulls", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Location", "https://api.github.com/repositories/42/pulls?state=open")
+		w.WriteHeader(http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/repositories/42", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"full_name":"new/repo","html_url":"https://github.com/new/repo"}`))
+	})
+	mux.HandleFunc("/repos/new/repo/pulls", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	})
+	mux.HandleFunc("/repos/new/repo/issues", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"title":"Issue","updated_at":"` + time.Now().UTC().Format(time.RFC3339) + `"}]`))
+	})
+
+	repo, err := newTestClient(t, mux).GetUpdates(context.Background(), newLink("https://github.com/old/repo"))
+
+	require.NoError(t, err)
+	require.Equal(t, "https://github.com/new/repo", repo.CanonicalURL)
+	require.Len(t, repo.Issues, 1)
+}
+
+func TestClient_GetUpdates_GoneRepo(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		want   error
+	}{
+		{name: "not found", status: http.StatusNotFound, want: ErrRepoNotFound},
+		{name: "legal reasons", status: http.StatusUnavailableForLegalReasons, want: ErrRepoUnavailable},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tc.status)
+			}))
+
+			for i := 0; i < 3; i++ {
+				_, err := client.GetUpdates(context.Background(), newLink("https://github.com/gone/repo"))
+				require.ErrorIs(t, err, tc.want, "a gone repo must not open the breaker")
+			}
+		})
+	}
+}
@@ -1,28 +1,23 @@
 package sender
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"pkg/auth"
+	"pkg/breaker"
+	"pkg/httpclient"
 	"scraper/internal/config"
-	"scraper/utils"
-	"time"
+	"scraper/internal/tracing"
 
-	"github.com/avast/retry-go/v4"
-	"github.com/sony/gobreaker"
 	"scraper/internal/model/scraper"
 )
 
 type Client struct {
-	addr    string
-	log     *slog.Logger
-	client  *http.Client
-	codec   utils.JSONCodec
-	retries uint
-	backoff time.Duration
-	breaker *gobreaker.CircuitBreaker
+	addr   string
+	log    *slog.Logger
+	client *httpclient.Client
 }
 
 const (
@@ -30,86 +25,51 @@ const (
 )
 
 func NewClient(log *slog.Logger, cfg *config.ClientsConfig) (*Client, error) {
-	httpClient := &http.Client{
-		Timeout: cfg.Bot.Timeout,
-	}
+	transport := tracing.Transport(nil)
 
-	cbSettings := gobreaker.Settings{
-		Name:        "Bot API Circuit Breaker",
-		MaxRequests: cfg.CircuitBreaker.SlidingWindowSize,
-		Timeout:     cfg.CircuitBreaker.Timeout,
-		ReadyToTrip: func(counts gobreaker.Counts) bool {
-			return counts.Requests >= cfg.CircuitBreaker.MaxRequests &&
-				counts.TotalFailures >= cfg.CircuitBreaker.FailureCount
-		},
+	if len(cfg.Bot.ServiceKeys) > 0 {
+		transport = auth.NewTransport(cfg.Bot.ServiceKeys, transport)
 	}
 
+	client := httpclient.New(log, httpclient.Options{
+		Name:        "bot",
+		BreakerName: "Bot API Circuit Breaker",
+		Breaker:     cfg.CircuitBreaker,
+		Timeout:     cfg.Bot.Timeout,
+		Retries:     cfg.Bot.Retry,
+		Backoff:     cfg.Bot.Backoff,
+		Transport:   transport,
+	})
+
 	return &Client{
-		addr:    cfg.Bot.Address,
-		log:     log,
-		client:  httpClient,
-		codec:   utils.JSONCodec{},
-		backoff: cfg.Bot.Backoff,
-		retries: cfg.Bot.Retry,
-		breaker: gobreaker.NewCircuitBreaker(cbSettings),
+		addr:   cfg.Bot.Address,
+		log:    log,
+		client: client,
 	}, nil
 }
 
-func (c *Client) Updates(ctx context.Context, link *scraper.LinkUpdate, isFailed bool) error {
-	const op = "Client.Bot.Updates"
-
-	if isFailed {
-		return fmt.Errorf("%s: unsupported link", op)
-	}
-
-	body, err := c.codec.Marshal(link)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	url := c.addr + "/" + endpoint
-
-	_, err = c.breaker.Execute(func() (any, error) {
-		err = retry.Do(
-			func() error {
-				req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-				if err != nil {
-					return retry.Unrecoverable(fmt.Errorf("%s: %w", op, err))
-				}
-
-				req.Header.Set("Content-Type", "application/json")
-
-				c.log.Debug("Sending POST request", slog.String("url", url))
-
-				resp, err := c.client.Do(req)
-				if err != nil {
-					return fmt.Errorf("%s: %w", op, err)
-				}
-				defer resp.Body.Close()
-
-				if resp.StatusCode >= 500 || resp.StatusCode == 429 {
-					return fmt.Errorf("%s: temporary server error: %s", op, resp.Status)
-				} else if resp.StatusCode != http.StatusOK {
-					return retry.Unrecoverable(fmt.Errorf("%s: bad status: %s", op, resp.Status))
-				}
+// Breaker is the circuit breaker guarding the bot's API.
+func (c *Client) Breaker() *breaker.Breaker {
+	return c.client.Breaker()
+}
 
-				return nil
-			},
-			retry.Attempts(c.retries),
-			retry.Delay(c.backoff),
-			retry.DelayType(retry.BackOffDelay),
-			retry.Context(ctx),
-		)
+// Configure applies reloaded timeouts, retries and breaker settings.
+func (c *Client) Configure(cfg *config.ClientsConfig) {
+	c.client.Configure(httpclient.Options{
+		Breaker: cfg.CircuitBreaker,
+		Timeout: cfg.Bot.Timeout,
+		Retries: cfg.Bot.Retry,
+		Backoff: cfg.Bot.Backoff,
+	})
+}
 
-		if err != nil {
-			return nil, err
-		}
+func (c *Client) Updates(ctx context.Context, link *scraper.LinkUpdate) error {
+	const op = "Client.Bot.Updates"
 
-		return nil, nil
-	})
+	req := httpclient.Request{Method: http.MethodPost, URL: c.addr + "/" + endpoint, Body: link}
 
-	if err != nil {
-		return fmt.Errorf("%s: retries exhausted: %w", op, err)
+	if err := c.client.Do(ctx, req, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
@@ -4,7 +4,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"scraper/internal/clients/sender"
-	_ "scraper/internal/clients/sender"
 	"scraper/internal/config"
 	"scraper/internal/model/scraper"
 
@@ -12,11 +11,8 @@ import (
 	"errors"
 	"io"
 	"log/slog"
-	"net/http"
-	"net/http/httptest"
 	"sync/atomic"
 	"testing"
-	"time"
 )
 
 type mockSender struct {
@@ -24,7 +20,7 @@ type mockSender struct {
 	called     int32
 }
 
-func (m *mockSender) Updates(_ context.Context, _ *scraper.LinkUpdate, _ bool) error {
+func (m *mockSender) Updates(_ context.Context, _ *scraper.LinkUpdate) error {
 	atomic.AddInt32(&m.called, 1)
 
 	if m.shouldFail {
@@ -34,157 +30,48 @@ func (m *mockSender) Updates(_ context.Context, _ *scraper.LinkUpdate, _ bool) e
 	return nil
 }
 
-func TestClient_Updates_RetriesOn500(t *testing.T) {
-	var callCount uint64
-
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		atomic.AddUint64(&callCount, 1)
-		w.WriteHeader(http.StatusInternalServerError)
-	}))
-	defer server.Close()
-
-	cfg := &config.ClientsConfig{
-		Bot: config.Client{
-			Address: server.URL,
-			Timeout: time.Second,
-			Backoff: 10 * time.Millisecond,
-			Retry:   3,
-		},
-		CircuitBreaker: config.CBConfig{
-			SlidingWindowSize: 10,
-			FailureCount:      3,
-			Timeout:           time.Second,
-		},
-	}
-
-	client, err := sender.NewClient(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg)
-
-	require.NoError(t, err)
-
-	err = client.Updates(context.Background(), &scraper.LinkUpdate{}, false)
-
-	assert.Error(t, err)
-	assert.GreaterOrEqual(t, uint(callCount), cfg.Bot.Retry)
-}
-
-func TestClient_Updates_RetriesOn429(t *testing.T) {
-	var callCount uint64
-
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		atomic.AddUint64(&callCount, 1)
-		w.WriteHeader(http.StatusTooManyRequests)
-	}))
-	defer server.Close()
-
-	cfg := &config.ClientsConfig{
-		Bot: config.Client{
-			Address: server.URL,
-			Timeout: time.Second,
-			Backoff: 10 * time.Millisecond,
-			Retry:   3,
-		},
-		CircuitBreaker: config.CBConfig{
-			SlidingWindowSize: 10,
-			FailureCount:      3,
-			Timeout:           time.Second,
-		},
-	}
-
-	client, err := sender.NewClient(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg)
-
-	require.NoError(t, err)
-
-	err = client.Updates(context.Background(), &scraper.LinkUpdate{}, false)
-
-	assert.Error(t, err)
-	assert.GreaterOrEqual(t, uint(callCount), cfg.Bot.Retry)
-}
+func TestFallbackSender_PrimaryFails_FallbackUsed(t *testing.T) {
+	primary := &mockSender{shouldFail: true}
+	fallback := &mockSender{shouldFail: false}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-func TestClient_Updates_RetriesOn4xx(t *testing.T) {
-	var callCount uint64
-
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		atomic.AddUint64(&callCount, 1)
-		w.WriteHeader(http.StatusNotFound)
-	}))
-	defer server.Close()
-
-	cfg := &config.ClientsConfig{
-		Bot: config.Client{
-			Address: server.URL,
-			Timeout: time.Second,
-			Backoff: 10 * time.Millisecond,
-			Retry:   3,
-		},
-		CircuitBreaker: config.CBConfig{
-			SlidingWindowSize: 10,
-			FailureCount:      3,
-			Timeout:           time.Second,
-		},
+	sender := &sender.FallbackSender{
+		Primary:  primary,
+		Fallback: fallback,
+		Logger:   logger,
 	}
 
-	client, err := sender.NewClient(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg)
+	err := sender.Updates(context.Background(), &scraper.LinkUpdate{})
 
 	require.NoError(t, err)
-
-	err = client.Updates(context.Background(), &scraper.LinkUpdate{}, false)
-
-	assert.Error(t, err)
-	assert.GreaterOrEqual(t, uint(callCount), uint(1))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&primary.called), "primary should be called once")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fallback.called), "fallback should be called once")
 }
 
-func TestClient_Updates_CircuitBreakerOpen(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		w.WriteHeader(http.StatusInternalServerError)
-	}))
-	defer server.Close()
-
-	cfg := &config.ClientsConfig{
-		Bot: config.Client{
-			Address: server.URL,
-			Timeout: 100 * time.Millisecond,
-			Backoff: 10 * time.Millisecond,
-			Retry:   1,
-		},
-		CircuitBreaker: config.CBConfig{
-			SlidingWindowSize: 3,
-			FailureCount:      3,
-			Timeout:           5 * time.Second,
-		},
-	}
-
-	client, err := sender.NewClient(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg)
-
-	require.NoError(t, err)
+func TestFallbackSender_NoFallback(t *testing.T) {
+	primary := &mockSender{shouldFail: true}
 
-	for i := 0; i < 3; i++ {
-		_ = client.Updates(context.Background(), &scraper.LinkUpdate{}, false)
+	sender := &sender.FallbackSender{
+		Primary: primary,
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
 	}
 
-	start := time.Now()
-
-	err = client.Updates(context.Background(), &scraper.LinkUpdate{}, false)
-
-	duration := time.Since(start)
+	err := sender.Updates(context.Background(), &scraper.LinkUpdate{})
 
 	require.Error(t, err)
-	assert.Less(t, duration, 50*time.Millisecond, "Breaker should fail fast")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&primary.called))
 }
 
-func TestFallbackSender_PrimaryFails_FallbackUsed(t *testing.T) {
-	primary := &mockSender{shouldFail: true}
-	fallback := &mockSender{shouldFail: false}
-	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-
-	sender := &sender.FallbackSender{
-		Primary:  primary,
-		Fallback: fallback,
-		Logger:   logger,
-	}
-
-	err := sender.Updates(context.Background(), &scraper.LinkUpdate{}, false)
+func TestNew_KafkaDownLeavesNoFallback(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Scraper.TransportType = "GRPC"
+	cfg.Clients.Kafka.Address = "127.0.0.1:1"
 
+	s, err := sender.New(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg, &mockSender{shouldFail: true})
 	require.NoError(t, err)
-	assert.Equal(t, int32(1), atomic.LoadInt32(&primary.called), "primary should be called once")
-	assert.Equal(t, int32(1), atomic.LoadInt32(&fallback.called), "fallback should be called once")
+
+	fallback := s.(*sender.FallbackSender)
+	require.True(t, fallback.Fallback == nil, "a producer that failed to start must not be a fallback")
+	require.Nil(t, fallback.Producer())
+	require.Error(t, s.Updates(context.Background(), &scraper.LinkUpdate{}))
 }
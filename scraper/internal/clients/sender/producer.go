@@ -3,23 +3,69 @@ package sender
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"scraper/utils"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/Shopify/sarama"
+	"github.com/go-chi/chi/v5/middleware"
 	"scraper/internal/model/scraper"
+	"scraper/internal/tracing"
 )
 
+const (
+	HeaderEventID       = "event-id"
+	HeaderSchemaVersion = "schema-version"
+	HeaderTraceID       = "trace-id"
+
+	// The dlq-* headers are the ones the bot's DLQ admin reads, so updates
+	// parked here are listed and replayed the same way as the bot's own.
+	HeaderDLQError    = "dlq-error"
+	HeaderDLQReason   = "dlq-reason"
+	HeaderDLQTopic    = "dlq-original-topic"
+	HeaderDLQFailedAt = "dlq-failed-at"
+
+	ReasonSendFailed  = "send_failed"
+	ReasonUnencodable = "unencodable"
+
+	// failureWindow is how long a failed send keeps the producer unhealthy.
+	failureWindow = time.Minute
+)
+
+// deadLetter is written to the DLQ topic instead of an update that could not
+// be encoded.
+type deadLetter struct {
+	Error   string `json:"error"`
+	EventID string `json:"eventId,omitempty"`
+	LinkID  int    `json:"linkId"`
+	URL     string `json:"url"`
+}
+
 type Producer struct {
 	asyncProducer sarama.AsyncProducer
 	codec         utils.JSONCodec
 	topic         string
 	dlqTopic      string
+
+	mu        sync.Mutex
+	lastErr   error
+	lastErrAt time.Time
 }
 
-func NewProducer(brokers []string, topic, dlqTopic string, timeout time.Duration) (*Producer, error) {
+func NewProducer(log *slog.Logger, brokers []string, topic, dlqTopic string, timeout time.Duration,
+	retries uint) (*Producer, error) {
+	const op = "Producer.Kafka"
+
 	cfg := sarama.NewConfig()
-	cfg.Producer.RequiredAcks = sarama.WaitForLocal
+	// Idempotence needs acks from all replicas and a single in-flight request
+	// per connection so that retries cannot reorder messages of one chat.
+	cfg.Version = sarama.V2_1_0_0
+	cfg.Producer.Idempotent = true
+	cfg.Producer.RequiredAcks = sarama.WaitForAll
+	cfg.Producer.Retry.Max = int(retries)
+	cfg.Net.MaxOpenRequests = 1
 	cfg.Producer.Return.Errors = true
 	cfg.Producer.Timeout = timeout
 
@@ -30,44 +76,53 @@ func NewProducer(brokers []string, topic, dlqTopic string, timeout time.Duration
 
 	p := &Producer{asyncProducer: prod, codec: utils.JSONCodec{}, topic: topic, dlqTopic: dlqTopic}
 
+	log = log.With(slog.String("op", op))
+
 	go func() {
 		for errMsg := range prod.Errors() {
-			fmt.Printf("producer failed to send message: %v\n", err)
+			log.Error("producer failed to send message", slog.String("topic", errMsg.Msg.Topic),
+				slog.String("error", errMsg.Err.Error()))
 
-			failedMsg := &sarama.ProducerMessage{
-				Topic: dlqTopic,
-				Key:   errMsg.Msg.Key,
-				Value: errMsg.Msg.Value,
+			p.failed(errMsg.Err)
+
+			// A message that did not make it to the DLQ either is dropped,
+			// otherwise it would circle here while Kafka is down.
+			if errMsg.Msg.Topic == dlqTopic {
+				continue
 			}
 
-			p.asyncProducer.Input() <- failedMsg
+			p.asyncProducer.Input() <- p.park(errMsg.Msg, ReasonSendFailed, errMsg.Err)
 		}
 	}()
 
 	return p, nil
 }
 
-func (p *Producer) Updates(ctx context.Context, req *scraper.LinkUpdate, isFailed bool) error {
+// Updates publishes req to the updates topic. The DLQ topic only gets updates
+// that could not be delivered; removed links are ordinary updates carrying a
+// link.removed event.
+func (p *Producer) Updates(ctx context.Context, req *scraper.LinkUpdate) error {
 	data, err := p.codec.Marshal(req)
-	if err != nil || isFailed {
-		dlqData := fmt.Sprintf(`{"error:"marshal failed, "raw":"%v"}`, req)
-		dlqMsg := &sarama.ProducerMessage{
-			Topic: p.dlqTopic,
-			Value: sarama.ByteEncoder(dlqData),
+	if err != nil {
+		dlqData, dlqErr := p.codec.Marshal(deadLetter{
+			Error:   err.Error(),
+			EventID: req.EventID,
+			LinkID:  req.ID,
+			URL:     req.URL,
+		})
+		if dlqErr != nil {
+			return fmt.Errorf("json marshal LinkUpdate failed: %w", err)
 		}
 
 		select {
-		case p.asyncProducer.Input() <- dlqMsg:
+		case p.asyncProducer.Input() <- p.park(p.message(ctx, "", req, dlqData), ReasonUnencodable, err):
 			return fmt.Errorf("json marshal LinkUpdate failed; sent to DLQ: %w", err)
 		case <-ctx.Done():
 			return ctx.Err()
 		}
 	}
 
-	msg := &sarama.ProducerMessage{
-		Topic: p.topic,
-		Value: sarama.ByteEncoder(data),
-	}
+	msg := p.message(ctx, p.topic, req, data)
 
 	select {
 	case p.asyncProducer.Input() <- msg:
@@ -77,6 +132,76 @@ func (p *Producer) Updates(ctx context.Context, req *scraper.LinkUpdate, isFaile
 	}
 }
 
+// message keys updates by chat id so that all updates of a chat go to the
+// same partition and keep their order.
+func (p *Producer) message(ctx context.Context, topic string, req *scraper.LinkUpdate, value []byte) *sarama.ProducerMessage {
+	msg := &sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.ByteEncoder(value),
+		Headers: []sarama.RecordHeader{
+			{Key: []byte(HeaderEventID), Value: []byte(req.EventID)},
+			{Key: []byte(HeaderSchemaVersion), Value: []byte(strconv.Itoa(req.SchemaVersion))},
+		},
+	}
+
+	if len(req.TgChatIDs) > 0 {
+		msg.Key = sarama.StringEncoder(strconv.Itoa(req.TgChatIDs[0]))
+	}
+
+	if traceID := middleware.GetReqID(ctx); traceID != "" {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(HeaderTraceID), Value: []byte(traceID)})
+	} else if req.EventID != "" {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(HeaderTraceID), Value: []byte(req.EventID)})
+	}
+
+	tracing.InjectKafka(ctx, msg)
+
+	return msg
+}
+
+// park copies msg to the DLQ topic with the reason it failed and, for
+// messages that were on their way to a topic, that topic.
+func (p *Producer) park(msg *sarama.ProducerMessage, reason string, err error) *sarama.ProducerMessage {
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+4)
+	headers = append(headers, msg.Headers...)
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(HeaderDLQReason), Value: []byte(reason)},
+		sarama.RecordHeader{Key: []byte(HeaderDLQError), Value: []byte(err.Error())},
+		sarama.RecordHeader{Key: []byte(HeaderDLQFailedAt), Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	)
+
+	if msg.Topic != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(HeaderDLQTopic), Value: []byte(msg.Topic)})
+	}
+
+	return &sarama.ProducerMessage{
+		Topic:   p.dlqTopic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+}
+
+func (p *Producer) failed(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lastErr = err
+	p.lastErrAt = time.Now()
+}
+
+// Check fails while a message failed to reach Kafka within failureWindow.
+func (p *Producer) Check(_ context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.lastErr != nil && time.Since(p.lastErrAt) < failureWindow {
+		return fmt.Errorf("send failed %s ago: %w", time.Since(p.lastErrAt).Round(time.Second), p.lastErr)
+	}
+
+	return nil
+}
+
 func (p *Producer) Close() error {
 	return p.asyncProducer.Close()
 }
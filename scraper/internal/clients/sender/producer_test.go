@@ -0,0 +1,99 @@
+package sender
+
+import (
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/require"
+	"scraper/internal/model/scraper"
+	"scraper/utils"
+
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func header(msg *sarama.ProducerMessage, key string) string {
+	for _, h := range msg.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+
+	return ""
+}
+
+func TestProducer_Updates_KeysAndHeaders(t *testing.T) {
+	cfg := mocks.NewTestConfig()
+	cfg.Producer.Return.Successes = true
+
+	mock := mocks.NewAsyncProducer(t, cfg)
+	defer mock.Close()
+
+	p := &Producer{asyncProducer: mock, codec: utils.JSONCodec{}, topic: "updates", dlqTopic: "dlq"}
+
+	mock.ExpectInputWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		key, err := msg.Key.Encode()
+		require.NoError(t, err)
+
+		switch {
+		case msg.Topic != "updates":
+			return errors.New("unexpected topic " + msg.Topic)
+		case string(key) != "42":
+			return errors.New("message must be keyed by chat id, got " + string(key))
+		case header(msg, HeaderEventID) != "event-1":
+			return errors.New("missing event id header")
+		case header(msg, HeaderSchemaVersion) != "1":
+			return errors.New("missing schema version header")
+		case header(msg, HeaderTraceID) == "":
+			return errors.New("missing trace header")
+		}
+
+		return nil
+	})
+
+	err := p.Updates(context.Background(), &scraper.LinkUpdate{
+		ID:            1,
+		EventID:       "event-1",
+		SchemaVersion: scraper.LinkUpdateSchemaVersion,
+		TgChatIDs:     []int{42},
+	})
+	require.NoError(t, err)
+
+	msg := <-mock.Successes()
+	require.Equal(t, "updates", msg.Topic)
+}
+
+func TestProducer_Park(t *testing.T) {
+	p := &Producer{topic: "updates", dlqTopic: "dlq"}
+
+	msg := &sarama.ProducerMessage{
+		Topic:   "updates",
+		Key:     sarama.StringEncoder("42"),
+		Value:   sarama.StringEncoder(`{"id":1}`),
+		Headers: []sarama.RecordHeader{{Key: []byte(HeaderEventID), Value: []byte("event-1")}},
+	}
+
+	parked := p.park(msg, ReasonSendFailed, sarama.ErrOutOfBrokers)
+
+	require.Equal(t, "dlq", parked.Topic)
+	require.Equal(t, msg.Key, parked.Key)
+	require.Equal(t, msg.Value, parked.Value)
+	require.Equal(t, "event-1", header(parked, HeaderEventID))
+	require.Equal(t, ReasonSendFailed, header(parked, HeaderDLQReason))
+	require.Equal(t, sarama.ErrOutOfBrokers.Error(), header(parked, HeaderDLQError))
+	require.Equal(t, "updates", header(parked, HeaderDLQTopic))
+	require.NotEmpty(t, header(parked, HeaderDLQFailedAt))
+	require.Len(t, msg.Headers, 1, "the original message must not be changed")
+}
+
+func TestProducer_Check(t *testing.T) {
+	p := &Producer{}
+	require.NoError(t, p.Check(context.Background()))
+
+	p.failed(sarama.ErrOutOfBrokers)
+	require.ErrorIs(t, p.Check(context.Background()), sarama.ErrOutOfBrokers)
+
+	p.lastErrAt = time.Now().Add(-failureWindow)
+	require.NoError(t, p.Check(context.Background()))
+}
@@ -5,17 +5,19 @@ import (
 	"fmt"
 	"log/slog"
 	"scraper/internal/config"
+	"scraper/internal/tracing"
 
 	"scraper/internal/model/scraper"
 )
 
 type Sender interface {
-	Updates(ctx context.Context, req *scraper.LinkUpdate, isFailed bool) error
+	Updates(ctx context.Context, req *scraper.LinkUpdate) error
 }
 
 const (
 	messageTransportHTTP  = "HTTP"
 	messageTransportKafka = "KAFKA"
+	messageTransportGRPC  = "GRPC"
 )
 
 type FallbackSender struct {
@@ -24,15 +26,27 @@ type FallbackSender struct {
 	Logger   *slog.Logger
 }
 
-func New(logger *slog.Logger, cfg *config.Config) (Sender, error) {
+// New picks the primary sender by the configured transport. stream hands
+// updates to bots subscribed over gRPC and is only used with GRPC, where
+// Kafka is the fallback.
+func New(logger *slog.Logger, cfg *config.Config, stream Sender) (Sender, error) {
+	// A failed constructor returns a nil pointer, which must not end up in a
+	// Sender: the interface would not be nil and the fallback would panic.
 	var (
 		httpSender  Sender
 		kafkaSender Sender
 	)
 
-	httpSender, httpErr := NewClient(logger, &cfg.Clients)
-	kafkaSender, kafkaErr := NewProducer([]string{cfg.Clients.Kafka.Address}, cfg.Clients.Kafka.Topic,
-		cfg.Clients.Kafka.DLQTopic, cfg.Clients.Kafka.Timeout)
+	client, httpErr := NewClient(logger, &cfg.Clients)
+	if httpErr == nil {
+		httpSender = client
+	}
+
+	producer, kafkaErr := NewProducer(logger, []string{cfg.Clients.Kafka.Address}, cfg.Clients.Kafka.Topic,
+		cfg.Clients.Kafka.DLQTopic, cfg.Clients.Kafka.Timeout, cfg.Clients.Kafka.Retry)
+	if kafkaErr == nil {
+		kafkaSender = producer
+	}
 
 	switch cfg.Scraper.TransportType {
 	case messageTransportHTTP:
@@ -57,20 +71,66 @@ func New(logger *slog.Logger, cfg *config.Config) (Sender, error) {
 
 		return &FallbackSender{Primary: kafkaSender, Fallback: httpSender, Logger: logger}, nil
 
+	case messageTransportGRPC:
+		if stream == nil {
+			return nil, fmt.Errorf("gRPC transport requires grpc_address to be set")
+		}
+
+		if kafkaErr != nil {
+			logger.Warn("Kafka fallback unavailable", slog.String("error", kafkaErr.Error()))
+		}
+
+		return &FallbackSender{Primary: stream, Fallback: kafkaSender, Logger: logger}, nil
+
 	default:
 		return nil, fmt.Errorf("unsupported transport type: %s", cfg.Scraper.TransportType)
 	}
 }
 
-func (f *FallbackSender) Updates(ctx context.Context, req *scraper.LinkUpdate, isFailed bool) error {
-	err := f.Primary.Updates(ctx, req, isFailed)
+// HTTPClient returns the bot client behind f, nil when f does not use one.
+func (f *FallbackSender) HTTPClient() *Client {
+	for _, s := range []Sender{f.Primary, f.Fallback} {
+		if client, ok := s.(*Client); ok && client != nil {
+			return client
+		}
+	}
+
+	return nil
+}
+
+// Producer returns the Kafka producer behind f, nil when f does not use one.
+func (f *FallbackSender) Producer() *Producer {
+	for _, s := range []Sender{f.Primary, f.Fallback} {
+		if producer, ok := s.(*Producer); ok && producer != nil {
+			return producer
+		}
+	}
+
+	return nil
+}
+
+func (f *FallbackSender) Updates(ctx context.Context, req *scraper.LinkUpdate) error {
+	ctx, span := tracing.Start(ctx, "Sender.Updates", tracing.EventID(req.EventID))
+
+	err := f.updates(ctx, req)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (f *FallbackSender) updates(ctx context.Context, req *scraper.LinkUpdate) error {
+	err := f.Primary.Updates(ctx, req)
 	if err == nil {
 		return nil
 	}
 
+	if f.Fallback == nil {
+		return fmt.Errorf("primary failed, no fallback: %w", err)
+	}
+
 	f.Logger.Warn("primary sender failed, falling back", slog.String("error", err.Error()))
 
-	fallbackErr := f.Fallback.Updates(ctx, req, isFailed)
+	fallbackErr := f.Fallback.Updates(ctx, req)
 	if fallbackErr != nil {
 		return fmt.Errorf("primary failed: %v, fallback also failed: %w", err, fallbackErr)
 	}
@@ -1,15 +1,16 @@
 package stackoverflow
 
 import (
-	"github.com/avast/retry-go/v4"
-	"github.com/sony/gobreaker"
+	"pkg/breaker"
+	"pkg/httpclient"
 	"scraper/internal/config"
 	"scraper/internal/metrics"
 	"scraper/internal/model/scraper"
 	"scraper/internal/model/stackoverflow"
+	"scraper/internal/tracing"
 
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -19,42 +20,63 @@ import (
 type Client struct {
 	key           string
 	log           *slog.Logger
-	client        *http.Client
-	retries       uint
-	backoff       time.Duration
-	breaker       *gobreaker.CircuitBreaker
+	client        *httpclient.Client
 	metricManager *metrics.MetricManager
 }
 
-const apiURL = "https://api.stackexchange.com/2.3/questions/%d/%s?site=stackoverflow&key=%s&filter=withbody"
+// ErrQuestionDeleted is returned for a question the API no longer lists,
+// which is what it does for deleted ones.
+var ErrQuestionDeleted = errors.New("question was deleted")
 
-func New(log *slog.Logger, cfg *config.ClientsConfig, metrics *metrics.MetricManager) (*Client, error) {
-	httpClient := &http.Client{
-		Timeout: cfg.StackOverFlow.Timeout,
-	}
+const (
+	apiURL      = "https://api.stackexchange.com/2.3/questions/%d/%s?site=stackoverflow&key=%s&filter=withbody"
+	questionURL = "https://api.stackexchange.com/2.3/questions/%d?site=stackoverflow&key=%s"
+)
 
-	cbSettings := gobreaker.Settings{
-		Name:        "StackOverFlow API Circuit Breaker",
-		MaxRequests: cfg.CircuitBreaker.SlidingWindowSize,
-		Timeout:     cfg.CircuitBreaker.Timeout,
-		ReadyToTrip: func(counts gobreaker.Counts) bool {
-			return counts.Requests >= cfg.CircuitBreaker.MaxRequests &&
-				counts.TotalFailures >= cfg.CircuitBreaker.FailureCount
-		},
-	}
+func New(log *slog.Logger, cfg *config.ClientsConfig, metrics *metrics.MetricManager) (*Client, error) {
+	client := httpclient.New(log, httpclient.Options{
+		Name:        "stackoverflow",
+		BreakerName: "StackOverFlow API Circuit Breaker",
+		Breaker:     cfg.CircuitBreaker,
+		Timeout:     cfg.StackOverFlow.Timeout,
+		Retries:     cfg.StackOverFlow.Retry,
+		Backoff:     cfg.StackOverFlow.Backoff,
+		Transport:   tracing.Transport(nil),
+	})
 
 	return &Client{
 		key:           cfg.StackOverFlow.Token,
 		log:           log,
-		client:        httpClient,
-		backoff:       cfg.StackOverFlow.Backoff,
-		retries:       cfg.StackOverFlow.Retry,
-		breaker:       gobreaker.NewCircuitBreaker(cbSettings),
+		client:        client,
 		metricManager: metrics,
 	}, nil
 }
 
+// Breaker is the circuit breaker guarding the StackExchange API.
+func (c *Client) Breaker() *breaker.Breaker {
+	return c.client.Breaker()
+}
+
+// Configure applies reloaded timeouts, retries and breaker settings.
+func (c *Client) Configure(cfg *config.ClientsConfig) {
+	c.client.Configure(httpclient.Options{
+		Breaker: cfg.CircuitBreaker,
+		Timeout: cfg.StackOverFlow.Timeout,
+		Retries: cfg.StackOverFlow.Retry,
+		Backoff: cfg.StackOverFlow.Backoff,
+	})
+}
+
 func (c *Client) GetUpdates(ctx context.Context, link *scraper.Link) (*stackoverflowquest.StackOverflowData, error) {
+	ctx, span := tracing.Start(ctx, "stackoverflow.GetUpdates", tracing.LinkURL(link.URL))
+
+	data, err := c.getUpdates(ctx, link)
+	tracing.End(span, err)
+
+	return data, err
+}
+
+func (c *Client) getUpdates(ctx context.Context, link *scraper.Link) (*stackoverflowquest.StackOverflowData, error) {
 	const op = "Client.Stack.Get"
 
 	var questionID int
@@ -73,13 +95,21 @@ func (c *Client) GetUpdates(ctx context.Context, link *scraper.Link) (*stackover
 	commentData, err := c.sendRequest(ctx, urlComment)
 	if err != nil {
 		c.log.Info("Failed to get comment data", "op", op)
-		return nil, fmt.Errorf("%s: failed to get comment data", op)
+		return nil, fmt.Errorf("%s: failed to get comment data: %w", op, err)
 	}
 
 	answerData, err := c.sendRequest(ctx, urlAnswer)
 	if err != nil {
 		c.log.Info("Failed to get answer data", "op", op)
-		return nil, fmt.Errorf("%s: Failed to get answer data", op)
+		return nil, fmt.Errorf("%s: Failed to get answer data: %w", op, err)
+	}
+
+	// A deleted question has no answers or comments either, so the question
+	// itself is only asked for when both lists are empty.
+	if len(commentData.Items) == 0 && len(answerData.Items) == 0 {
+		if err = c.checkQuestion(ctx, questionID); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
 	}
 
 	var newData stackoverflowquest.StackOverflowData
@@ -111,54 +141,29 @@ func (c *Client) GetUpdates(ctx context.Context, link *scraper.Link) (*stackover
 	return &newData, nil
 }
 
+// checkQuestion returns ErrQuestionDeleted, carrying a 404 for the link
+// health, when the API does not list the question.
+func (c *Client) checkQuestion(ctx context.Context, questionID int) error {
+	question, err := c.sendRequest(ctx, fmt.Sprintf(questionURL, questionID, c.key))
+	if err != nil {
+		return fmt.Errorf("failed to get question: %w", err)
+	}
+
+	if len(question.Items) == 0 {
+		return fmt.Errorf("%w: %w", ErrQuestionDeleted,
+			&httpclient.StatusError{Code: http.StatusNotFound, Status: "404 Not Found"})
+	}
+
+	return nil
+}
+
 func (c *Client) sendRequest(ctx context.Context, url string) (stackoverflowquest.StackOverflowQuestion, error) {
 	const op = "Client.SendRequest"
 
 	var result stackoverflowquest.StackOverflowQuestion
 
-	_, err := c.breaker.Execute(func() (any, error) {
-		err := retry.Do(
-			func() error {
-				req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
-				if err != nil {
-					return retry.Unrecoverable(fmt.Errorf("%s: %w", op, err))
-				}
-
-				c.log.Debug("Sending GET request", slog.String("url", req.URL.String()))
-
-				resp, err := c.client.Do(req)
-				if err != nil {
-					return fmt.Errorf("%s: %w", op, err)
-				}
-				defer resp.Body.Close()
-
-				if resp.StatusCode >= 500 || resp.StatusCode == 429 {
-					return fmt.Errorf("%s: temporary server error: %s", op, resp.Status)
-				} else if resp.StatusCode != http.StatusOK {
-					return retry.Unrecoverable(fmt.Errorf("%s: bad status: %s", op, resp.Status))
-				}
-
-				if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-					return retry.Unrecoverable(fmt.Errorf("%s: failed to decode response: %w", op, err))
-				}
-
-				return nil
-			},
-			retry.Attempts(c.retries),
-			retry.Delay(c.backoff),
-			retry.DelayType(retry.BackOffDelay),
-			retry.Context(ctx),
-		)
-
-		if err != nil {
-			return nil, err
-		}
-
-		return result, nil
-	})
-
-	if err != nil {
-		return stackoverflowquest.StackOverflowQuestion{}, fmt.Errorf("%s: retries exhausted: %w", op, err)
+	if err := c.client.Do(ctx, httpclient.Request{Method: http.MethodGet, URL: url}, &result); err != nil {
+		return stackoverflowquest.StackOverflowQuestion{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return result, nil
@@ -1,21 +1,43 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
+	"pkg/breaker"
+	"pkg/reload"
 )
 
 type Config struct {
-	Env     string        `yaml:"env" env-default:"local"`
-	Scraper ScraperConfig `yaml:"scraper"`
-	Clients ClientsConfig `yaml:"scraper_clients"`
+	Env string `yaml:"env" env-default:"local"`
+	// ReloadInterval is how often the file is checked for changes; zero, the
+	// default, leaves reloads to SIGHUP.
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+	Scraper        ScraperConfig `yaml:"scraper"`
+	Clients        ClientsConfig `yaml:"scraper_clients"`
+	Tracing        TracingConfig `yaml:"tracing"`
+
+	// Path is the file the config was read from and Version a hash of its
+	// content, both set by Load.
+	Path    string `yaml:"-"`
+	Version string `yaml:"-"`
+}
+
+// TracingConfig points the OTLP/HTTP trace exporter at a collector, e.g.
+// http://otel-collector:4318. Without an endpoint spans are not recorded.
+type TracingConfig struct {
+	Endpoint    string  `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
 }
 
 type ScraperConfig struct {
 	Address        string        `yaml:"address"`
+	GRPCAddress    string        `yaml:"grpc_address" env-default:""`
 	TransportType  string        `yaml:"message_transport"`
 	AccessType     string        `yaml:"access_type" env-default:"ORM"`
 	MaxConn        int32         `yaml:"max_conn" env-default:"15"`
@@ -26,24 +48,37 @@ type ScraperConfig struct {
 	Timeout        time.Duration `yaml:"timeout" env-default:"10s"`
 	RateLimit      int           `yaml:"rate_limit" env-default:"50"`
 	LinksRateLimit int           `yaml:"links_rate_limit" env-default:"25"`
+	APIRateLimit   int           `yaml:"api_rate_limit" env-default:"60"`
+	MaxFailures    int           `yaml:"max_failures" env-default:"3"`
+	// HistoryRetention is how long sent updates are kept for /history and
+	// the public API; zero keeps them forever.
+	HistoryRetention time.Duration `yaml:"history_retention" env-default:"720h"`
+	// MaxTickAge is how long the link poll may not finish before /readyz
+	// reports the scraper unready.
+	MaxTickAge time.Duration `yaml:"max_tick_age" env-default:"5m"`
+	AdminToken string        `yaml:"admin_token" env:"ADMIN_TOKEN"`
+	// NonceStoragePath is the Redis that remembers the nonces of requests
+	// signed by the bot. Without it they are kept in memory, which only
+	// stops replays within one replica.
+	NonceStoragePath string `yaml:"nonce_storage_path" env-default:""`
 }
 
+// Client describes a service the scraper calls. ServiceKeys are only set for
+// the bot: the first one signs requests to it, any of them is accepted on
+// requests from it. Without keys requests are not authenticated.
 type Client struct {
-	Address  string        `yaml:"address"`
-	Timeout  time.Duration `yaml:"timeout"`
-	Retry    uint          `yaml:"retry"`
-	Backoff  time.Duration `yaml:"backoff"`
-	Token    string        `env:"TOKEN"`
-	Topic    string        `yaml:"base_topic"`
-	DLQTopic string        `yaml:"dlq_topic"`
+	Address     string        `yaml:"address"`
+	Timeout     time.Duration `yaml:"timeout"`
+	Retry       uint          `yaml:"retry"`
+	Backoff     time.Duration `yaml:"backoff"`
+	Token       string        `env:"TOKEN"`
+	Topic       string        `yaml:"base_topic"`
+	DLQTopic    string        `yaml:"dlq_topic"`
+	ServiceKeys []string      `yaml:"service_keys" env:"SERVICE_KEYS" env-separator:","`
 }
 
-type CBConfig struct {
-	MaxRequests       uint32        `yaml:"max_requests"`
-	Timeout           time.Duration `yaml:"timeout"`
-	SlidingWindowSize uint32        `yaml:"sliding_window_size"`
-	FailureCount      uint32        `yaml:"failure_count"`
-}
+// CBConfig describes the circuit breaker of every client.
+type CBConfig = breaker.Config
 
 type ClientsConfig struct {
 	Bot            Client   `yaml:"bot"`
@@ -67,16 +102,97 @@ func MustLoadPath(configPath string) *Config {
 		panic("config file does not exist: " + configPath)
 	}
 
+	cfg, err := Load(configPath)
+	if err != nil {
+		panic("cannot read config: " + err.Error())
+	}
+
+	return cfg
+}
+
+// Load reads and validates the config at path.
+func Load(path string) (*Config, error) {
+	const op = "config.Load"
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	cfg, err := parse(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cfg, nil
+}
+
+func parse(path string, data []byte) (*Config, error) {
 	var cfg Config
 
-	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-		panic("cannot read config: " + err.Error())
+	if err := cleanenv.ParseYAML(bytes.NewReader(data), &cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cleanenv.ReadEnv(&cfg); err != nil {
+		return nil, err
 	}
 
 	cfg.Clients.Github.Token = os.Getenv("GITHUB_TOKEN")
 	cfg.Clients.StackOverFlow.Token = os.Getenv("STACK_OVERFLOW_TOKEN")
 
-	return &cfg
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	cfg.Path = path
+	cfg.Version = reload.Version(data)
+
+	return &cfg, nil
+}
+
+// Validate reports every setting the scraper cannot run with.
+func (c *Config) Validate() error {
+	var errs []error
+
+	check := func(ok bool, msg string) {
+		if !ok {
+			errs = append(errs, errors.New(msg))
+		}
+	}
+
+	check(c.ReloadInterval >= 0, "reload_interval must not be negative")
+	check(c.Scraper.BatchSize > 0, "scraper.batch_size must be positive")
+	check(c.Scraper.RateLimit > 0, "scraper.rate_limit must be positive")
+	check(c.Scraper.LinksRateLimit > 0, "scraper.links_rate_limit must be positive")
+	check(c.Scraper.APIRateLimit > 0, "scraper.api_rate_limit must be positive")
+	check(c.Scraper.MaxFailures > 0, "scraper.max_failures must be positive")
+
+	clients := []struct {
+		name   string
+		client Client
+	}{
+		{"bot", c.Clients.Bot},
+		{"kafka", c.Clients.Kafka},
+		{"github", c.Clients.Github},
+		{"stack_overflow", c.Clients.StackOverFlow},
+	}
+
+	for _, client := range clients {
+		check(client.client.Timeout >= 0, "scraper_clients."+client.name+".timeout must not be negative")
+		check(client.client.Backoff >= 0, "scraper_clients."+client.name+".backoff must not be negative")
+	}
+
+	cb := c.Clients.CircuitBreaker
+
+	check(cb.WindowSize > 0, "scraper_clients.circuit_breaker.window_size must be positive")
+	check(cb.MinimumCalls > 0, "scraper_clients.circuit_breaker.minimum_calls must be positive")
+	check(cb.FailureRateThreshold > 0 && cb.FailureRateThreshold <= 100,
+		"scraper_clients.circuit_breaker.failure_rate_threshold must be in (0, 100]")
+	check(cb.HalfOpenCalls > 0, "scraper_clients.circuit_breaker.half_open_calls must be positive")
+	check(cb.Timeout > 0, "scraper_clients.circuit_breaker.timeout must be positive")
+
+	return errors.Join(errs...)
 }
 
 func fetchConfigPath() string {
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"log/slog"
+
+	"pkg/reload"
+)
+
+// Reloader hands the settings that are safe to change at runtime to its
+// subscribers: the batch size and MaxFailures of the cron, rate limits, client
+// timeouts, retries and backoff, and the circuit breaker.
+type Reloader = reload.Reloader[*Config]
+
+func NewReloader(log *slog.Logger, cfg *Config) *Reloader {
+	return reload.New(log, cfg, reload.Options[*Config]{
+		Path:       cfg.Path,
+		Version:    cfg.Version,
+		Interval:   cfg.ReloadInterval,
+		Parse:      parse,
+		Reloadable: reloadable,
+	})
+}
+
+// reloadable is current with the settings that are safe to change at runtime
+// taken from next.
+func reloadable(current, next *Config) *Config {
+	cfg := *current
+
+	cfg.Path = next.Path
+	cfg.Version = next.Version
+
+	cfg.Scraper.BatchSize = next.Scraper.BatchSize
+	cfg.Scraper.MaxFailures = next.Scraper.MaxFailures
+	cfg.Scraper.RateLimit = next.Scraper.RateLimit
+	cfg.Scraper.LinksRateLimit = next.Scraper.LinksRateLimit
+	cfg.Scraper.APIRateLimit = next.Scraper.APIRateLimit
+
+	cfg.Clients.Bot = withRetries(cfg.Clients.Bot, next.Clients.Bot)
+	cfg.Clients.Github = withRetries(cfg.Clients.Github, next.Clients.Github)
+	cfg.Clients.StackOverFlow = withRetries(cfg.Clients.StackOverFlow, next.Clients.StackOverFlow)
+	cfg.Clients.CircuitBreaker = next.Clients.CircuitBreaker
+
+	return &cfg
+}
+
+func withRetries(client, next Client) Client {
+	client.Timeout = next.Timeout
+	client.Retry = next.Retry
+	client.Backoff = next.Backoff
+
+	return client
+}
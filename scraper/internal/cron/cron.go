@@ -2,25 +2,44 @@ package cron
 
 import (
 	"github.com/go-co-op/gocron"
+	"github.com/google/uuid"
+	"pkg/httpclient"
+	"pkg/render"
 	"scraper/internal/clients/github"
 	"scraper/internal/clients/sender"
 	"scraper/internal/clients/stackoverflow"
 	"scraper/internal/model/github"
 	"scraper/internal/model/scraper"
 	"scraper/internal/model/stackoverflow"
+	"scraper/internal/storage"
 	"scraper/internal/storage/postgres"
+	"scraper/internal/tracing"
 
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Storage interface {
-	GetLinks(ctx context.Context, limit, offset uint64) ([]scraper.Link, error)
+	GetLinks(ctx context.Context, limit uint64, afterID int64) ([]scraper.Link, error)
 	UpdateLink(ctx context.Context, link *scraper.Link) (*scraper.Link, error)
 	RemoveLink(ctx context.Context, chatID int64, link string) (*scraper.Link, error)
+	RenameLink(ctx context.Context, link *scraper.Link, newURL string) (*scraper.Link, error)
+	RecordSuccess(ctx context.Context, link *scraper.Link, status int) error
+	RecordFailure(ctx context.Context, link *scraper.Link, status int, lastError string, gone bool) (int, error)
+	MarkBroken(ctx context.Context, link *scraper.Link) error
+}
+
+// History keeps the events sent to chats for HistoryRetention.
+type History interface {
+	AddHistory(ctx context.Context, entries []scraper.UpdateEntry) error
+	DeleteHistory(ctx context.Context, before time.Time) (int64, error)
 }
 
 type GithubClient interface {
@@ -32,33 +51,61 @@ type StackOverflowClient interface {
 }
 
 type Sender interface {
-	Updates(ctx context.Context, req *scraper.LinkUpdate, isFailed bool) error
+	Updates(ctx context.Context, req *scraper.LinkUpdate) error
 }
 
 type Cron struct {
 	Logger   *slog.Logger
 	Cron     *gocron.Scheduler
 	Storage  Storage
+	History  History
 	Github   GithubClient
 	Stack    StackOverflowClient
 	Sender   Sender
 	Produced *sender.Producer
 	Limit    uint64
+	// MaxFailures is the number of polls in a row a link may be gone for
+	// before it is marked broken.
+	MaxFailures int
+	// HistoryRetention is how long sent events are kept; zero keeps them
+	// forever.
+	HistoryRetention time.Duration
+
+	// mu guards Limit and MaxFailures against Configure.
+	mu sync.RWMutex
+
+	// lastTick is the unix time in nanoseconds UpdateCron last went through
+	// all links.
+	lastTick atomic.Int64
 }
 
 func New(logger *slog.Logger, cron *gocron.Scheduler, storage postgres.Storage, github *github.Client,
-	stack *stackoverflow.Client, sender Sender, limit uint64) *Cron {
+	stack *stackoverflow.Client, sender Sender, limit uint64, maxFailures int,
+	historyRetention time.Duration) *Cron {
 	return &Cron{
-		Logger:  logger,
-		Cron:    cron,
-		Storage: storage,
-		Github:  github,
-		Stack:   stack,
-		Sender:  sender,
-		Limit:   limit,
+		Logger:           logger,
+		Cron:             cron,
+		Storage:          storage,
+		History:          storage,
+		Github:           github,
+		Stack:            stack,
+		Sender:           sender,
+		Limit:            limit,
+		MaxFailures:      maxFailures,
+		HistoryRetention: historyRetention,
 	}
 }
 
+// Configure changes the batch size and MaxFailures, e.g. on a config reload.
+// A poll in progress keeps its batch size.
+func (c *Cron) Configure(limit uint64, maxFailures int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Limit = limit
+	c.MaxFailures = maxFailures
+}
+
 func (c *Cron) UpdateCron() {
 	const op = "Cron.Update"
 
@@ -69,13 +116,19 @@ func (c *Cron) UpdateCron() {
 
 	log.Info("checking for updates")
 
-	var offset uint64
+	c.mu.RLock()
+	limit := c.Limit
+	c.mu.RUnlock()
+
+	// Links are paged by id rather than by offset, so that links removed or
+	// marked broken during the pass do not shift the following pages.
+	var afterID int64
 
 	for {
-		links, linkErr := c.Storage.GetLinks(ctx, c.Limit, offset)
+		links, linkErr := c.Storage.GetLinks(ctx, limit, afterID)
 		if linkErr != nil {
 			log.Error(linkErr.Error())
-			break
+			return
 		}
 
 		if len(links) == 0 {
@@ -91,7 +144,39 @@ func (c *Cron) UpdateCron() {
 			}
 		}
 
-		offset += c.Limit
+		afterID = links[len(links)-1].ID
+	}
+
+	c.lastTick.Store(time.Now().UnixNano())
+}
+
+// LastTick is when UpdateCron last went through all links, zero before the
+// first time.
+func (c *Cron) LastTick() time.Time {
+	if tick := c.lastTick.Load(); tick != 0 {
+		return time.Unix(0, tick)
+	}
+
+	return time.Time{}
+}
+
+// CheckTick returns a health check that fails when UpdateCron has not gone
+// through all links within maxAge; until the first time maxAge counts from
+// now.
+func (c *Cron) CheckTick(maxAge time.Duration) func(ctx context.Context) error {
+	started := time.Now()
+
+	return func(context.Context) error {
+		last := c.LastTick()
+		if last.IsZero() {
+			last = started
+		}
+
+		if age := time.Since(last); age > maxAge {
+			return fmt.Errorf("last successful tick was %s ago", age.Round(time.Second))
+		}
+
+		return nil
 	}
 }
 
@@ -103,99 +188,231 @@ func isStackOverflowURL(url string) bool {
 	return strings.Contains(url, "https://stackoverflow.com/")
 }
 
-func (c *Cron) sendUpdate(ctx context.Context, link *scraper.Link, desc string, isFailed bool) error {
+// sendUpdate sends the events of link; the bot renders them in the language
+// of every chat.
+func (c *Cron) sendUpdate(ctx context.Context, link *scraper.Link, events []scraper.LinkEvent) error {
 	req := &scraper.LinkUpdate{
-		ID:          int(link.ID),
-		URL:         link.URL,
-		Description: desc,
-		TgChatIDs:   []int{int(link.ChatID)},
+		ID:            int(link.ID),
+		EventID:       uuid.NewString(),
+		URL:           link.URL,
+		TgChatIDs:     []int{int(link.ChatID)},
+		SchemaVersion: scraper.LinkUpdateSchemaVersion,
+		Events:        events,
 	}
 
-	err := c.Sender.Updates(ctx, req, isFailed)
+	err := c.Sender.Updates(ctx, req)
 	if err != nil {
 		return err
 	}
 
+	c.recordHistory(ctx, link, events)
+
 	return nil
 }
 
-func (c *Cron) ProcessLink(ctx context.Context, link *scraper.Link) error {
-	var description string
+// recordHistory keeps the sent events. The update is already delivered, so
+// a failure is only logged.
+func (c *Cron) recordHistory(ctx context.Context, link *scraper.Link, events []scraper.LinkEvent) {
+	if c.History == nil || len(events) == 0 {
+		return
+	}
 
-	switch {
-	case isGitHubURL(link.URL):
-		content, _ := c.Github.GetUpdates(ctx, link)
-		if content != nil && (len(content.Issues) > 0 || len(content.PoolRequests) > 0) {
-			description = createGitHubDescription(content)
-		} else {
-			return nil
-		}
-	case isStackOverflowURL(link.URL):
-		content, _ := c.Stack.GetUpdates(ctx, link)
-		if content != nil && (len(content.Answers) > 0 || len(content.Comments) > 0) {
-			description = createStackOverFlowDescription(content)
-		} else {
-			return nil
-		}
-	default:
-		c.Logger.Info("skipping unsupported link", slog.String("url", link.URL))
+	entries := make([]scraper.UpdateEntry, 0, len(events))
+
+	for _, event := range events {
+		entries = append(entries, scraper.UpdateEntry{
+			ChatID:    link.ChatID,
+			LinkID:    link.ID,
+			URL:       link.URL,
+			Tags:      link.Tags,
+			Provider:  event.Provider,
+			Type:      event.Type,
+			Title:     event.Title,
+			ItemURL:   event.URL,
+			Timestamp: event.Timestamp,
+		})
+	}
 
-		_, err := c.Storage.RemoveLink(ctx, link.ChatID, link.URL)
-		if err != nil {
-			return err
-		}
+	if err := c.History.AddHistory(ctx, entries); err != nil {
+		c.Logger.Warn("failed to record history", slog.String("url", link.URL), slog.String("error", err.Error()))
+	}
+}
 
-		err = c.sendUpdate(ctx, link, description, true)
-		if err != nil {
-			return err
-		}
+// CleanupHistory drops the events older than HistoryRetention.
+func (c *Cron) CleanupHistory() {
+	const op = "Cron.CleanupHistory"
 
-		return nil
+	if c.History == nil || c.HistoryRetention <= 0 {
+		return
 	}
 
-	_, err := c.Storage.UpdateLink(ctx, link)
+	log := c.Logger.With(
+		slog.String("op", op),
+	)
+
+	deleted, err := c.History.DeleteHistory(context.Background(), time.Now().Add(-c.HistoryRetention))
 	if err != nil {
-		return err
+		log.Error(err.Error())
+		return
 	}
 
-	err = c.sendUpdate(ctx, link, description, false)
+	log.Info("history cleaned up", slog.Int64("deleted", deleted))
+}
+
+// removeLink stops tracking the link and tells the chat why.
+func (c *Cron) removeLink(ctx context.Context, link *scraper.Link, provider, reason string) error {
+	_, err := c.Storage.RemoveLink(ctx, link.ChatID, link.URL)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return c.sendRemoved(ctx, link, provider, reason)
 }
 
-func createGitHubDescription(repo *githubrepo.GitHubRepo) string {
-	baseString := "\nНовые изменения на GitHub!\n------------\n"
+// pollFailed records the error in the link's health. A link that is gone is
+// marked broken once MaxFailures polls in a row found it gone, which stops
+// polling it, and the chat is told why. Other errors are returned; they do
+// not count towards MaxFailures and start the count over.
+func (c *Cron) pollFailed(ctx context.Context, link *scraper.Link, provider string, err error) error {
+	var reason string
 
-	for _, item := range repo.PoolRequests {
-		baseString += fmt.Sprintf("Изменение в PR: %s\nПользователем: %s\nВ %s\nC описанием: %s\n------------\n",
-			item.Title, item.User, item.UpdatedAt, item.Body)
+	switch {
+	case errors.Is(err, github.ErrRepoNotFound):
+		reason = scraper.RemovalNotFound
+	case errors.Is(err, github.ErrRepoUnavailable):
+		reason = scraper.RemovalAccessLost
+	case errors.Is(err, stackoverflow.ErrQuestionDeleted):
+		reason = scraper.RemovalDeleted
 	}
 
-	for _, item := range repo.Issues {
-		baseString += fmt.Sprintf("Изменение в Issue: %s\nПользователем: %s\nВ %s\nC описанием: %s\n------------\n",
-			item.Title, item.User, item.UpdatedAt, item.Body)
+	gone, recErr := c.Storage.RecordFailure(ctx, link, httpclient.Code(err), err.Error(), reason != "")
+	if recErr != nil {
+		return errors.Join(err, recErr)
 	}
 
-	return baseString
+	if reason == "" {
+		return err
+	}
+
+	c.mu.RLock()
+	maxFailures := c.MaxFailures
+	c.mu.RUnlock()
+
+	if gone < max(maxFailures, 1) {
+		c.Logger.Info("link is gone", slog.String("url", link.URL), slog.Int("gone_polls", gone),
+			slog.String("error", err.Error()))
+
+		return nil
+	}
+
+	c.Logger.Info("marking link broken", slog.String("url", link.URL), slog.String("reason", reason))
+
+	if err = c.Storage.MarkBroken(ctx, link); err != nil {
+		return err
+	}
+
+	return c.sendRemoved(ctx, link, provider, reason)
 }
 
-func createStackOverFlowDescription(data *stackoverflowquest.StackOverflowData) string {
-	baseString := "\nНовые изменения на StackOverFlow!\n------------\n"
+func (c *Cron) pollSucceeded(ctx context.Context, link *scraper.Link) {
+	if err := c.Storage.RecordSuccess(ctx, link, http.StatusOK); err != nil {
+		c.Logger.Warn("failed to record link health", slog.String("url", link.URL), slog.String("error", err.Error()))
+	}
+}
+
+// followRename moves the link to the canonical URL of a renamed or
+// transferred repository. It reports false when the chat already tracks the
+// new URL and the old link was dropped instead.
+func (c *Cron) followRename(ctx context.Context, link *scraper.Link, canonicalURL string) (bool, error) {
+	if canonicalURL == "" || canonicalURL == link.URL {
+		return true, nil
+	}
+
+	c.Logger.Info("link moved", slog.String("url", link.URL), slog.String("new_url", canonicalURL))
+
+	_, err := c.Storage.RenameLink(ctx, link, canonicalURL)
+	if errors.Is(err, storage.ErrAlreadyExists) {
+		_, err = c.Storage.RemoveLink(ctx, link.ChatID, link.URL)
+		return false, err
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	link.URL = canonicalURL
+
+	return true, nil
+}
+
+func (c *Cron) sendRemoved(ctx context.Context, link *scraper.Link, provider, reason string) error {
+	return c.sendUpdate(ctx, link, []scraper.LinkEvent{{
+		Provider:  provider,
+		Type:      scraper.EventLinkRemoved,
+		URL:       link.URL,
+		Timestamp: time.Now(),
+		Reason:    reason,
+	}})
+}
+
+func (c *Cron) ProcessLink(ctx context.Context, link *scraper.Link) error {
+	ctx, span := tracing.Start(ctx, "Cron.ProcessLink", tracing.LinkURL(link.URL))
+
+	err := c.processLink(ctx, link)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (c *Cron) processLink(ctx context.Context, link *scraper.Link) error {
+	var digest render.Digest
+
+	switch {
+	case isGitHubURL(link.URL):
+		content, err := c.Github.GetUpdates(ctx, link)
+		if err != nil {
+			return c.pollFailed(ctx, link, render.ProviderGitHub, err)
+		}
+
+		c.pollSucceeded(ctx, link)
+
+		if tracked, err := c.followRename(ctx, link, content.CanonicalURL); err != nil || !tracked {
+			return err
+		}
+
+		if len(content.Issues) > 0 || len(content.PoolRequests) > 0 {
+			digest = gitHubDigest(link, content)
+		} else {
+			return nil
+		}
+	case isStackOverflowURL(link.URL):
+		content, err := c.Stack.GetUpdates(ctx, link)
+		if err != nil {
+			return c.pollFailed(ctx, link, render.ProviderStackOverflow, err)
+		}
 
-	for _, item := range data.Answers {
-		date := time.Unix(item.UpdatedAt, 0)
-		baseString += fmt.Sprintf("Появился новый ответ: %s\nОт пользователя: %s\nВ %s\nC описанием: %s\n------------\n",
-			item.Title, item.User, date, item.Body)
+		c.pollSucceeded(ctx, link)
+
+		if len(content.Answers) > 0 || len(content.Comments) > 0 {
+			digest = stackOverflowDigest(link, content)
+		} else {
+			return nil
+		}
+	default:
+		c.Logger.Info("removing unsupported link", slog.String("url", link.URL))
+
+		return c.removeLink(ctx, link, "", scraper.RemovalUnsupported)
+	}
+
+	_, err := c.Storage.UpdateLink(ctx, link)
+	if err != nil {
+		return err
 	}
 
-	for _, item := range data.Comments {
-		date := time.Unix(item.UpdatedAt, 0)
-		baseString += fmt.Sprintf("Появился новый комментарий: %s\nОт пользователя: %s\nВ %s\nC описанием: %s\n------------\n",
-			item.Title, item.User, date, item.Body)
+	err = c.sendUpdate(ctx, link, linkEvents(digest))
+	if err != nil {
+		return err
 	}
 
-	return baseString
+	return nil
 }
@@ -2,15 +2,21 @@ package cron_test
 
 import (
 	"github.com/stretchr/testify/mock"
+	"pkg/httpclient"
+	"pkg/render"
+	"scraper/internal/clients/github"
+	"scraper/internal/clients/stackoverflow"
 	"scraper/internal/cron"
 	"scraper/internal/model/github"
 	"scraper/internal/model/scraper"
 	"scraper/internal/model/stackoverflow"
+	"scraper/internal/storage"
 
 	"context"
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"testing"
 	"time"
 )
@@ -19,8 +25,8 @@ type MockStorage struct {
 	mock.Mock
 }
 
-func (m *MockStorage) GetLinks(ctx context.Context, limit, offset uint64) ([]scraper.Link, error) {
-	args := m.Called(ctx, limit, offset)
+func (m *MockStorage) GetLinks(ctx context.Context, limit uint64, afterID int64) ([]scraper.Link, error) {
+	args := m.Called(ctx, limit, afterID)
 	return args.Get(0).([]scraper.Link), args.Error(1)
 }
 
@@ -34,6 +40,40 @@ func (m *MockStorage) RemoveLink(ctx context.Context, chatID int64, link string)
 	return args.Get(0).(*scraper.Link), args.Error(1)
 }
 
+func (m *MockStorage) RenameLink(ctx context.Context, link *scraper.Link, newURL string) (*scraper.Link, error) {
+	args := m.Called(ctx, link, newURL)
+	return args.Get(0).(*scraper.Link), args.Error(1)
+}
+
+func (m *MockStorage) RecordSuccess(ctx context.Context, link *scraper.Link, status int) error {
+	args := m.Called(ctx, link, status)
+	return args.Error(0)
+}
+
+func (m *MockStorage) RecordFailure(ctx context.Context, link *scraper.Link, status int, lastError string, gone bool) (int, error) {
+	args := m.Called(ctx, link, status, lastError, gone)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockStorage) MarkBroken(ctx context.Context, link *scraper.Link) error {
+	args := m.Called(ctx, link)
+	return args.Error(0)
+}
+
+type MockHistory struct {
+	mock.Mock
+}
+
+func (m *MockHistory) AddHistory(ctx context.Context, entries []scraper.UpdateEntry) error {
+	args := m.Called(ctx, entries)
+	return args.Error(0)
+}
+
+func (m *MockHistory) DeleteHistory(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 type MockGithubClient struct {
 	mock.Mock
 }
@@ -56,8 +96,8 @@ type MockBotClient struct {
 	mock.Mock
 }
 
-func (m *MockBotClient) Updates(ctx context.Context, req *scraper.LinkUpdate, isFailed bool) error {
-	args := m.Called(ctx, req, isFailed)
+func (m *MockBotClient) Updates(ctx context.Context, req *scraper.LinkUpdate) error {
+	args := m.Called(ctx, req)
 	return args.Error(0)
 }
 
@@ -77,7 +117,7 @@ func TestCron_UpdateCronGit(t *testing.T) {
 		Limit:   10,
 	}
 
-	var offset uint64
+	var afterID int64
 
 	// Пример данных
 	links := []scraper.Link{
@@ -94,15 +134,20 @@ func TestCron_UpdateCronGit(t *testing.T) {
 		},
 	}
 
-	mockStorage.On("GetLinks", mock.Anything, c.Limit, offset).Return(links, nil)
+	mockStorage.On("GetLinks", mock.Anything, c.Limit, afterID).Return(links, nil)
 
 	mockGithub.On("GetUpdates", mock.Anything, &links[0]).Return(&updated, nil)
 
+	mockStorage.On("RecordSuccess", mock.Anything, &links[0], http.StatusOK).Return(nil)
+
 	mockStorage.On("UpdateLink", mock.Anything, &links[0]).Return(&links[0], nil)
 
-	mockBot.On("Updates", mock.Anything, mock.AnythingOfType("*scraper.LinkUpdate"), false).Return(nil)
+	mockBot.On("Updates", mock.Anything, mock.MatchedBy(func(req *scraper.LinkUpdate) bool {
+		return req.SchemaVersion == scraper.LinkUpdateSchemaVersion && len(req.Events) == 2 &&
+			req.Events[0].Type == render.EventGitHubPullRequest && req.Events[1].Author == "User1"
+	})).Return(nil)
 
-	mockStorage.On("GetLinks", mock.Anything, c.Limit, offset+c.Limit).Return(links, fmt.Errorf("some error"))
+	mockStorage.On("GetLinks", mock.Anything, c.Limit, links[0].ID).Return(links, fmt.Errorf("some error"))
 
 	// Запускаем функцию
 	c.UpdateCron()
@@ -120,7 +165,7 @@ func TestCron_UpdateCronStackOverflow(t *testing.T) {
 	mockStack := new(MockStackOverflowClient)
 	mockBot := new(MockBotClient)
 
-	var offset uint64
+	var afterID int64
 
 	c := &cron.Cron{
 		Logger:  logger,
@@ -144,15 +189,17 @@ func TestCron_UpdateCronStackOverflow(t *testing.T) {
 		},
 	}
 
-	mockStorage.On("GetLinks", mock.Anything, c.Limit, offset).Return(links, nil)
+	mockStorage.On("GetLinks", mock.Anything, c.Limit, afterID).Return(links, nil)
 
 	mockStack.On("GetUpdates", mock.Anything, &links[0]).Return(&updated, nil)
 
+	mockStorage.On("RecordSuccess", mock.Anything, &links[0], http.StatusOK).Return(nil)
+
 	mockStorage.On("UpdateLink", mock.Anything, &links[0]).Return(&links[0], nil)
 
-	mockBot.On("Updates", mock.Anything, mock.AnythingOfType("*scraper.LinkUpdate"), false).Return(nil)
+	mockBot.On("Updates", mock.Anything, mock.AnythingOfType("*scraper.LinkUpdate")).Return(nil)
 
-	mockStorage.On("GetLinks", mock.Anything, c.Limit, offset+c.Limit).Return(links, fmt.Errorf("some error"))
+	mockStorage.On("GetLinks", mock.Anything, c.Limit, links[0].ID).Return(links, fmt.Errorf("some error"))
 
 	c.UpdateCron()
 
@@ -168,7 +215,7 @@ func TestCron_UpdateFail(t *testing.T) {
 	mockStack := new(MockStackOverflowClient)
 	mockBot := new(MockBotClient)
 
-	var offset uint64
+	var afterID int64
 
 	c := &cron.Cron{
 		Logger:  logger,
@@ -183,13 +230,16 @@ func TestCron_UpdateFail(t *testing.T) {
 		{URL: "https://somelink.com/123456", ID: 2, ChatID: 456},
 	}
 
-	mockStorage.On("GetLinks", mock.Anything, c.Limit, offset).Return(links, nil)
+	mockStorage.On("GetLinks", mock.Anything, c.Limit, afterID).Return(links, nil)
 
 	mockStorage.On("RemoveLink", mock.Anything, links[0].ChatID, links[0].URL).Return(&links[0], nil)
 
-	mockBot.On("Updates", mock.Anything, mock.AnythingOfType("*scraper.LinkUpdate"), true).Return(nil)
+	mockBot.On("Updates", mock.Anything, mock.MatchedBy(func(req *scraper.LinkUpdate) bool {
+		return len(req.Events) == 1 && req.Events[0].Type == scraper.EventLinkRemoved &&
+			req.Events[0].Reason == scraper.RemovalUnsupported
+	})).Return(nil)
 
-	mockStorage.On("GetLinks", mock.Anything, c.Limit, offset+c.Limit).Return(links, fmt.Errorf("some error"))
+	mockStorage.On("GetLinks", mock.Anything, c.Limit, links[0].ID).Return(links, fmt.Errorf("some error"))
 
 	c.UpdateCron()
 
@@ -197,3 +247,267 @@ func TestCron_UpdateFail(t *testing.T) {
 	mockStack.AssertExpectations(t)
 	mockBot.AssertExpectations(t)
 }
+
+func newGitHubCron(t *testing.T) (*cron.Cron, *MockStorage, *MockGithubClient, *MockBotClient) {
+	t.Helper()
+
+	mockStorage := new(MockStorage)
+	mockGithub := new(MockGithubClient)
+	mockBot := new(MockBotClient)
+
+	return &cron.Cron{
+		Logger:      slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		Storage:     mockStorage,
+		Github:      mockGithub,
+		Stack:       new(MockStackOverflowClient),
+		Sender:      mockBot,
+		Limit:       10,
+		MaxFailures: 3,
+	}, mockStorage, mockGithub, mockBot
+}
+
+func TestCron_ProcessLink_FollowsRename(t *testing.T) {
+	c, mockStorage, mockGithub, mockBot := newGitHubCron(t)
+
+	lastUpdated := time.Now().Add(-time.Hour)
+	link := &scraper.Link{URL: "https://github.com/old/repo", ID: 1, ChatID: 123, LastUpdated: &lastUpdated}
+
+	mockGithub.On("GetUpdates", mock.Anything, link).Return(&githubrepo.GitHubRepo{
+		CanonicalURL: "https://github.com/new/repo",
+		Issues:       []githubrepo.GitHubData{{Title: "Issue", UpdatedAt: time.Now()}},
+	}, nil)
+	mockStorage.On("RecordSuccess", mock.Anything, link, http.StatusOK).Return(nil)
+	mockStorage.On("RenameLink", mock.Anything, link, "https://github.com/new/repo").Return(link, nil)
+	mockStorage.On("UpdateLink", mock.Anything, link).Return(link, nil)
+	mockBot.On("Updates", mock.Anything, mock.MatchedBy(func(req *scraper.LinkUpdate) bool {
+		return req.URL == "https://github.com/new/repo"
+	})).Return(nil)
+
+	if err := c.ProcessLink(context.Background(), link); err != nil {
+		t.Fatal(err)
+	}
+
+	if link.URL != "https://github.com/new/repo" {
+		t.Fatalf("link must be moved to the canonical URL, got %s", link.URL)
+	}
+
+	mockStorage.AssertExpectations(t)
+	mockBot.AssertExpectations(t)
+}
+
+func TestCron_ProcessLink_RenameToTrackedLink(t *testing.T) {
+	c, mockStorage, mockGithub, mockBot := newGitHubCron(t)
+
+	lastUpdated := time.Now()
+	link := &scraper.Link{URL: "https://github.com/old/repo", ID: 1, ChatID: 123, LastUpdated: &lastUpdated}
+
+	mockGithub.On("GetUpdates", mock.Anything, link).Return(&githubrepo.GitHubRepo{
+		CanonicalURL: "https://github.com/new/repo",
+	}, nil)
+	mockStorage.On("RecordSuccess", mock.Anything, link, http.StatusOK).Return(nil)
+	mockStorage.On("RenameLink", mock.Anything, link, "https://github.com/new/repo").
+		Return((*scraper.Link)(nil), storage.ErrAlreadyExists)
+	mockStorage.On("RemoveLink", mock.Anything, link.ChatID, "https://github.com/old/repo").Return(link, nil)
+
+	if err := c.ProcessLink(context.Background(), link); err != nil {
+		t.Fatal(err)
+	}
+
+	mockStorage.AssertExpectations(t)
+	mockBot.AssertNotCalled(t, "Updates", mock.Anything, mock.Anything)
+}
+
+func TestCron_ProcessLink_MarksBrokenAfterFailures(t *testing.T) {
+	c, mockStorage, mockGithub, mockBot := newGitHubCron(t)
+
+	lastUpdated := time.Now()
+	link := &scraper.Link{URL: "https://github.com/gone/repo", ID: 1, ChatID: 123, LastUpdated: &lastUpdated}
+	notFound := fmt.Errorf("Client.GetIssues: %w: %w", github.ErrRepoNotFound,
+		&httpclient.StatusError{Code: http.StatusNotFound, Status: "404 Not Found"})
+
+	mockGithub.On("GetUpdates", mock.Anything, link).Return((*githubrepo.GitHubRepo)(nil), notFound)
+	mockStorage.On("RecordFailure", mock.Anything, link, http.StatusNotFound, notFound.Error(), true).Return(1, nil).Once()
+	mockStorage.On("RecordFailure", mock.Anything, link, http.StatusNotFound, notFound.Error(), true).Return(2, nil).Once()
+	mockStorage.On("RecordFailure", mock.Anything, link, http.StatusNotFound, notFound.Error(), true).Return(3, nil).Once()
+	mockStorage.On("MarkBroken", mock.Anything, link).Return(nil).Once()
+	mockBot.On("Updates", mock.Anything, mock.MatchedBy(func(req *scraper.LinkUpdate) bool {
+		return len(req.Events) == 1 && req.Events[0].Type == scraper.EventLinkRemoved &&
+			req.Events[0].Reason == scraper.RemovalNotFound && req.Events[0].Provider == render.ProviderGitHub
+	})).Return(nil).Once()
+
+	for i := 0; i < 3; i++ {
+		if err := c.ProcessLink(context.Background(), link); err != nil {
+			t.Fatal(err)
+		}
+
+		if i < 2 {
+			mockStorage.AssertNotCalled(t, "MarkBroken", mock.Anything, mock.Anything)
+			mockBot.AssertNotCalled(t, "Updates", mock.Anything, mock.Anything)
+		}
+	}
+
+	mockStorage.AssertExpectations(t)
+	mockBot.AssertExpectations(t)
+}
+
+func TestCron_ProcessLink_DeletedQuestion(t *testing.T) {
+	c, mockStorage, _, mockBot := newGitHubCron(t)
+	mockStack := new(MockStackOverflowClient)
+	c.Stack = mockStack
+	c.MaxFailures = 1
+
+	lastUpdated := time.Now()
+	link := &scraper.Link{URL: "https://stackoverflow.com/questions/1", ID: 1, ChatID: 123, LastUpdated: &lastUpdated}
+	deleted := fmt.Errorf("Client.Stack.Get: %w: %w", stackoverflow.ErrQuestionDeleted,
+		&httpclient.StatusError{Code: http.StatusNotFound, Status: "404 Not Found"})
+
+	mockStack.On("GetUpdates", mock.Anything, link).Return((*stackoverflowquest.StackOverflowData)(nil), deleted)
+	mockStorage.On("RecordFailure", mock.Anything, link, http.StatusNotFound, deleted.Error(), true).Return(1, nil)
+	mockStorage.On("MarkBroken", mock.Anything, link).Return(nil)
+	mockBot.On("Updates", mock.Anything, mock.MatchedBy(func(req *scraper.LinkUpdate) bool {
+		return len(req.Events) == 1 && req.Events[0].Type == scraper.EventLinkRemoved &&
+			req.Events[0].Reason == scraper.RemovalDeleted && req.Events[0].Provider == render.ProviderStackOverflow
+	})).Return(nil)
+
+	if err := c.ProcessLink(context.Background(), link); err != nil {
+		t.Fatal(err)
+	}
+
+	mockStorage.AssertExpectations(t)
+	mockBot.AssertExpectations(t)
+}
+
+func TestCron_ProcessLink_RecordsTransientError(t *testing.T) {
+	c, mockStorage, mockGithub, mockBot := newGitHubCron(t)
+
+	lastUpdated := time.Now()
+	link := &scraper.Link{URL: "https://github.com/some/repo", ID: 1, ChatID: 123, LastUpdated: &lastUpdated}
+	unavailable := &httpclient.StatusError{Code: http.StatusBadGateway, Status: "502 Bad Gateway"}
+
+	mockGithub.On("GetUpdates", mock.Anything, link).Return((*githubrepo.GitHubRepo)(nil), unavailable)
+	mockStorage.On("RecordFailure", mock.Anything, link, http.StatusBadGateway, unavailable.Error(), false).Return(5, nil)
+
+	if err := c.ProcessLink(context.Background(), link); err == nil {
+		t.Fatal("transient error must be returned")
+	}
+
+	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNotCalled(t, "MarkBroken", mock.Anything, mock.Anything)
+	mockBot.AssertNotCalled(t, "Updates", mock.Anything, mock.Anything)
+}
+
+func TestCron_ProcessLink_RecordsHistory(t *testing.T) {
+	c, mockStorage, mockGithub, mockBot := newGitHubCron(t)
+	mockHistory := new(MockHistory)
+	c.History = mockHistory
+
+	updatedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	link := &scraper.Link{URL: "https://github.com/some/repo", ID: 1, ChatID: 123, Tags: []string{"work"}}
+
+	mockGithub.On("GetUpdates", mock.Anything, link).Return(&githubrepo.GitHubRepo{
+		Issues: []githubrepo.GitHubData{{
+			Number:    42,
+			Title:     "Issue",
+			HTMLURL:   "https://github.com/some/repo/issues/42",
+			UpdatedAt: updatedAt,
+		}},
+	}, nil)
+	mockStorage.On("RecordSuccess", mock.Anything, link, http.StatusOK).Return(nil)
+	mockStorage.On("UpdateLink", mock.Anything, link).Return(link, nil)
+	mockBot.On("Updates", mock.Anything, mock.Anything).Return(nil)
+	mockHistory.On("AddHistory", mock.Anything, []scraper.UpdateEntry{{
+		ChatID:    123,
+		LinkID:    1,
+		URL:       "https://github.com/some/repo",
+		Tags:      []string{"work"},
+		Provider:  render.ProviderGitHub,
+		Type:      render.EventGitHubIssue,
+		Title:     "Issue",
+		ItemURL:   "https://github.com/some/repo/issues/42",
+		Timestamp: updatedAt,
+	}}).Return(fmt.Errorf("history is down"))
+
+	// A failure to record history does not fail the delivered update.
+	if err := c.ProcessLink(context.Background(), link); err != nil {
+		t.Fatal(err)
+	}
+
+	mockHistory.AssertExpectations(t)
+}
+
+func TestCron_ProcessLink_SkipsHistoryWhenNotSent(t *testing.T) {
+	c, mockStorage, mockGithub, mockBot := newGitHubCron(t)
+	mockHistory := new(MockHistory)
+	c.History = mockHistory
+
+	link := &scraper.Link{URL: "https://github.com/some/repo", ID: 1, ChatID: 123}
+
+	mockGithub.On("GetUpdates", mock.Anything, link).Return(&githubrepo.GitHubRepo{
+		Issues: []githubrepo.GitHubData{{Title: "Issue", UpdatedAt: time.Now()}},
+	}, nil)
+	mockStorage.On("RecordSuccess", mock.Anything, link, http.StatusOK).Return(nil)
+	mockStorage.On("UpdateLink", mock.Anything, link).Return(link, nil)
+	mockBot.On("Updates", mock.Anything, mock.Anything).Return(fmt.Errorf("bot is down"))
+
+	if err := c.ProcessLink(context.Background(), link); err == nil {
+		t.Fatal("expected the send error")
+	}
+
+	mockHistory.AssertNotCalled(t, "AddHistory", mock.Anything, mock.Anything)
+}
+
+func TestCron_CleanupHistory(t *testing.T) {
+	mockHistory := new(MockHistory)
+	c := &cron.Cron{
+		Logger:           slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		History:          mockHistory,
+		HistoryRetention: 24 * time.Hour,
+	}
+
+	start := time.Now()
+
+	mockHistory.On("DeleteHistory", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return !before.Before(start.Add(-24*time.Hour)) && !before.After(time.Now().Add(-24*time.Hour))
+	})).Return(int64(3), nil).Once()
+
+	c.CleanupHistory()
+
+	c.HistoryRetention = 0
+	c.CleanupHistory()
+
+	mockHistory.AssertExpectations(t)
+}
+
+func TestCron_CheckTick(t *testing.T) {
+	c, mockStorage, _, _ := newGitHubCron(t)
+
+	mockStorage.On("GetLinks", mock.Anything, c.Limit, int64(0)).Return([]scraper.Link(nil), fmt.Errorf("some error")).Once()
+	mockStorage.On("GetLinks", mock.Anything, c.Limit, int64(0)).Return([]scraper.Link(nil), nil).Once()
+
+	stale := c.CheckTick(10 * time.Millisecond)
+	fresh := c.CheckTick(time.Minute)
+
+	c.UpdateCron()
+
+	if !c.LastTick().IsZero() {
+		t.Fatal("failed tick must not be recorded")
+	}
+
+	if err := fresh(context.Background()); err != nil {
+		t.Fatalf("check failed before maxAge passed: %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if err := stale(context.Background()); err == nil {
+		t.Fatal("check passed without a tick within maxAge")
+	}
+
+	c.UpdateCron()
+
+	if err := stale(context.Background()); err != nil {
+		t.Fatalf("check failed right after a tick: %v", err)
+	}
+
+	mockStorage.AssertExpectations(t)
+}
@@ -0,0 +1,112 @@
+package cron
+
+import (
+	"pkg/render"
+	"scraper/internal/model/github"
+	"scraper/internal/model/scraper"
+	"scraper/internal/model/stackoverflow"
+
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const stackOverflowURL = "https://stackoverflow.com"
+
+func gitHubDigest(link *scraper.Link, repo *githubrepo.GitHubRepo) render.Digest {
+	digest := render.Digest{
+		Provider: render.ProviderGitHub,
+		Title:    strings.TrimPrefix(link.URL, "https://github.com/"),
+		URL:      link.URL,
+	}
+
+	for _, pr := range repo.PoolRequests {
+		digest.Items = append(digest.Items, gitHubItem(render.EventGitHubPullRequest, pr))
+	}
+
+	for _, issue := range repo.Issues {
+		digest.Items = append(digest.Items, gitHubItem(render.EventGitHubIssue, issue))
+	}
+
+	return digest
+}
+
+func gitHubItem(eventType string, data githubrepo.GitHubData) render.Item {
+	return render.Item{
+		ID:        strconv.Itoa(data.Number),
+		Type:      eventType,
+		Title:     data.Title,
+		URL:       data.HTMLURL,
+		Author:    data.User.Login,
+		UpdatedAt: data.UpdatedAt,
+		Body:      data.Body,
+	}
+}
+
+func stackOverflowDigest(link *scraper.Link, data *stackoverflowquest.StackOverflowData) render.Digest {
+	digest := render.Digest{
+		Provider: render.ProviderStackOverflow,
+		Title:    strings.TrimPrefix(link.URL, stackOverflowURL+"/"),
+		URL:      link.URL,
+	}
+
+	for _, answer := range data.Answers {
+		digest.Items = append(digest.Items, stackOverflowItem(render.EventStackOverflowAnswer, answer,
+			answer.AnswerID, fmt.Sprintf("%s/a/%d", stackOverflowURL, answer.AnswerID)))
+	}
+
+	// The comments API leaves question_id out, the question is the one
+	// the link points to. It stays 0 for links of another form.
+	var questionID int64
+
+	_, _ = fmt.Sscanf(link.URL, stackOverflowURL+"/questions/%d", &questionID)
+
+	for _, comment := range data.Comments {
+		digest.Items = append(digest.Items, stackOverflowItem(render.EventStackOverflowComment, comment,
+			comment.CommentID, commentURL(questionID, comment)))
+	}
+
+	return digest
+}
+
+// commentURL links a comment under its question, or by the post it is on
+// when the question is not known.
+func commentURL(questionID int64, comment stackoverflowquest.Item) string {
+	if questionID == 0 {
+		return fmt.Sprintf("%s/posts/comments/%d", stackOverflowURL, comment.CommentID)
+	}
+
+	return fmt.Sprintf("%s/questions/%d#comment%d_%d", stackOverflowURL, questionID, comment.CommentID, comment.PostID)
+}
+
+func stackOverflowItem(eventType string, data stackoverflowquest.Item, id int64, url string) render.Item {
+	return render.Item{
+		ID:        strconv.FormatInt(id, 10),
+		Type:      eventType,
+		Title:     data.Title,
+		URL:       url,
+		Author:    data.User.Login,
+		UpdatedAt: time.Unix(data.UpdatedAt, 0),
+		Body:      data.Body,
+	}
+}
+
+func linkEvents(digest render.Digest) []scraper.LinkEvent {
+	events := make([]scraper.LinkEvent, 0, len(digest.Items))
+
+	for _, item := range digest.Items {
+		events = append(events, scraper.LinkEvent{
+			Provider:  digest.Provider,
+			Type:      item.Type,
+			ItemID:    item.ID,
+			Title:     item.Title,
+			Author:    item.Author,
+			URL:       item.URL,
+			Timestamp: item.UpdatedAt,
+			Excerpt:   render.Excerpt(item.Body),
+		})
+	}
+
+	return events
+}
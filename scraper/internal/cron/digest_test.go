@@ -0,0 +1,37 @@
+package cron
+
+import (
+	"github.com/stretchr/testify/require"
+	"scraper/internal/model/scraper"
+	"scraper/internal/model/stackoverflow"
+
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestStackOverflowDigest_CommentLinks(t *testing.T) {
+	data, err := os.ReadFile("testdata/stackoverflow_comments.json")
+	require.NoError(t, err)
+
+	var comments stackoverflowquest.StackOverflowQuestion
+
+	require.NoError(t, json.Unmarshal(data, &comments))
+
+	link := &scraper.Link{
+		URL: "https://stackoverflow.com/questions/11227809/why-is-processing-a-sorted-array-faster-than-processing-an-unsorted-array",
+	}
+
+	digest := stackOverflowDigest(link, &stackoverflowquest.StackOverflowData{Comments: comments.Items})
+
+	require.Len(t, digest.Items, 2)
+	require.Equal(t, "https://stackoverflow.com/questions/11227809#comment14749032_11227809", digest.Items[0].URL)
+	require.Equal(t, "https://stackoverflow.com/questions/11227809#comment14749480_11227902", digest.Items[1].URL,
+		"a comment on an answer links under the question")
+	require.Equal(t, "Mysticial", digest.Items[0].Author)
+
+	digest = stackOverflowDigest(&scraper.Link{URL: "https://stackoverflow.com/q/11227809"},
+		&stackoverflowquest.StackOverflowData{Comments: comments.Items})
+
+	require.Equal(t, "https://stackoverflow.com/posts/comments/14749032", digest.Items[0].URL)
+}
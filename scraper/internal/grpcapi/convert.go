@@ -0,0 +1,74 @@
+package grpcapi
+
+import (
+	"pkg/api/scraperv1"
+	"scraper/internal/model/scraper"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func linkToProto(link *scraper.Link) *scraperv1.Link {
+	health := &scraperv1.LinkHealth{
+		LastError:  link.Health.LastError,
+		LastStatus: int32(link.Health.LastStatus), //nolint:gosec // HTTP status codes fit
+		Failures:   int32(link.Health.Failures),   //nolint:gosec // capped by max_failures in practice
+		Broken:     link.Health.Broken,
+	}
+
+	if link.Health.LastSuccess != nil {
+		health.LastSuccess = timestamppb.New(*link.Health.LastSuccess)
+	}
+
+	return &scraperv1.Link{
+		Id:      link.ID,
+		Url:     link.URL,
+		Tags:    link.Tags,
+		Filters: link.Filters,
+		Health:  health,
+	}
+}
+
+func historyToProto(entry *scraper.UpdateEntry) *scraperv1.HistoryEntry {
+	return &scraperv1.HistoryEntry{
+		Id:        entry.ID,
+		LinkId:    entry.LinkID,
+		Url:       entry.URL,
+		Tags:      entry.Tags,
+		Provider:  entry.Provider,
+		Type:      entry.Type,
+		Title:     entry.Title,
+		ItemUrl:   entry.ItemURL,
+		Timestamp: timestamppb.New(entry.Timestamp),
+	}
+}
+
+func updateToProto(update *scraper.LinkUpdate) *scraperv1.LinkUpdate {
+	out := &scraperv1.LinkUpdate{
+		Id:            int64(update.ID),
+		EventId:       update.EventID,
+		Url:           update.URL,
+		SchemaVersion: int32(update.SchemaVersion), //nolint:gosec // small version numbers
+		TgChatIds:     make([]int64, 0, len(update.TgChatIDs)),
+		Events:        make([]*scraperv1.LinkEvent, 0, len(update.Events)),
+	}
+
+	for _, id := range update.TgChatIDs {
+		out.TgChatIds = append(out.TgChatIds, int64(id))
+	}
+
+	for _, event := range update.Events {
+		out.Events = append(out.Events, &scraperv1.LinkEvent{
+			Provider:  event.Provider,
+			Type:      event.Type,
+			ItemId:    event.ItemID,
+			Title:     event.Title,
+			Author:    event.Author,
+			Url:       event.URL,
+			Timestamp: timestamppb.New(event.Timestamp),
+			Excerpt:   event.Excerpt,
+			Reason:    event.Reason,
+		})
+	}
+
+	return out
+}
@@ -0,0 +1,304 @@
+package grpcapi
+
+import (
+	"pkg/api/scraperv1"
+	scrapModel "scraper/internal/model/scraper"
+	"scraper/internal/storage"
+	"scraper/internal/usecase"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+type UseCase interface {
+	NewChat(ctx context.Context, id int64) error
+	DeleteChat(ctx context.Context, id int64) error
+	GetLinks(ctx context.Context, id int64) ([]scrapModel.Link, error)
+	AddLink(ctx context.Context, id int64, link *scrapModel.Link) (scrapModel.Link, error)
+	RemoveLink(ctx context.Context, id int64, link string) (scrapModel.Link, error)
+	IssueToken(ctx context.Context, id int64, scopes []string) (string, *scrapModel.APIToken, error)
+	RevokeTokens(ctx context.Context, id int64) (int64, error)
+	GetHistory(ctx context.Context, query *scrapModel.HistoryQuery) ([]scrapModel.UpdateEntry, error)
+}
+
+// Server serves the same chat and link management as the HTTP handlers and
+// streams updates published to Updates.
+type Server struct {
+	scraperv1.UnimplementedScraperServiceServer
+
+	log     *slog.Logger
+	uc      UseCase
+	updates *Updates
+}
+
+func New(log *slog.Logger, uc UseCase, updates *Updates) *Server {
+	return &Server{
+		log:     log,
+		uc:      uc,
+		updates: updates,
+	}
+}
+
+func (s *Server) RegisterChat(ctx context.Context, req *scraperv1.RegisterChatRequest) (
+	*scraperv1.RegisterChatResponse, error) {
+	if err := s.uc.NewChat(ctx, req.GetChatId()); err != nil {
+		return nil, s.statusOf("grpc.RegisterChat", err)
+	}
+
+	return &scraperv1.RegisterChatResponse{}, nil
+}
+
+func (s *Server) DeleteChat(ctx context.Context, req *scraperv1.DeleteChatRequest) (*scraperv1.DeleteChatResponse,
+	error) {
+	if err := s.uc.DeleteChat(ctx, req.GetChatId()); err != nil {
+		return nil, s.statusOf("grpc.DeleteChat", err)
+	}
+
+	return &scraperv1.DeleteChatResponse{}, nil
+}
+
+func (s *Server) ListLinks(ctx context.Context, req *scraperv1.ListLinksRequest) (*scraperv1.ListLinksResponse, error) {
+	links, err := s.uc.GetLinks(ctx, req.GetChatId())
+	if err != nil {
+		return nil, s.statusOf("grpc.ListLinks", err)
+	}
+
+	resp := &scraperv1.ListLinksResponse{Links: make([]*scraperv1.Link, 0, len(links))}
+
+	for i := range links {
+		resp.Links = append(resp.Links, linkToProto(&links[i]))
+	}
+
+	return resp, nil
+}
+
+func (s *Server) AddLink(ctx context.Context, req *scraperv1.AddLinkRequest) (*scraperv1.Link, error) {
+	if req.GetUrl() == "" {
+		return nil, status.Error(codes.InvalidArgument, "url is required")
+	}
+
+	link := scrapModel.Link{
+		URL:     req.GetUrl(),
+		Tags:    req.GetTags(),
+		Filters: req.GetFilters(),
+	}
+
+	if link.Tags == nil {
+		link.Tags = []string{}
+	}
+
+	if link.Filters == nil {
+		link.Filters = []string{}
+	}
+
+	added, err := s.uc.AddLink(ctx, req.GetChatId(), &link)
+	if err != nil {
+		return nil, s.statusOf("grpc.AddLink", err)
+	}
+
+	return linkToProto(&added), nil
+}
+
+func (s *Server) RemoveLink(ctx context.Context, req *scraperv1.RemoveLinkRequest) (*scraperv1.Link, error) {
+	if req.GetUrl() == "" {
+		return nil, status.Error(codes.InvalidArgument, "url is required")
+	}
+
+	removed, err := s.uc.RemoveLink(ctx, req.GetChatId(), req.GetUrl())
+	if err != nil {
+		return nil, s.statusOf("grpc.RemoveLink", err)
+	}
+
+	return linkToProto(&removed), nil
+}
+
+func (s *Server) IssueToken(ctx context.Context, req *scraperv1.IssueTokenRequest) (*scraperv1.IssueTokenResponse,
+	error) {
+	token, issued, err := s.uc.IssueToken(ctx, req.GetChatId(), req.GetScopes())
+	if err != nil {
+		return nil, s.statusOf("grpc.IssueToken", err)
+	}
+
+	return &scraperv1.IssueTokenResponse{Token: token, Scopes: issued.Scopes}, nil
+}
+
+func (s *Server) RevokeTokens(ctx context.Context, req *scraperv1.RevokeTokensRequest) (
+	*scraperv1.RevokeTokensResponse, error) {
+	revoked, err := s.uc.RevokeTokens(ctx, req.GetChatId())
+	if err != nil {
+		return nil, s.statusOf("grpc.RevokeTokens", err)
+	}
+
+	return &scraperv1.RevokeTokensResponse{Revoked: revoked}, nil
+}
+
+func (s *Server) GetHistory(ctx context.Context, req *scraperv1.GetHistoryRequest) (*scraperv1.GetHistoryResponse,
+	error) {
+	query := scrapModel.HistoryQuery{
+		ChatID: req.GetChatId(),
+		URL:    req.GetUrl(),
+		Limit:  req.GetLimit(),
+		Offset: req.GetOffset(),
+	}
+
+	if req.GetSince() != nil {
+		query.Since = req.GetSince().AsTime()
+	}
+
+	if req.GetUntil() != nil {
+		query.Until = req.GetUntil().AsTime()
+	}
+
+	entries, err := s.uc.GetHistory(ctx, &query)
+	if err != nil {
+		return nil, s.statusOf("grpc.GetHistory", err)
+	}
+
+	resp := &scraperv1.GetHistoryResponse{Entries: make([]*scraperv1.HistoryEntry, 0, len(entries))}
+
+	for i := range entries {
+		resp.Entries = append(resp.Entries, historyToProto(&entries[i]))
+	}
+
+	return resp, nil
+}
+
+// StreamUpdates holds the stream open until the subscriber leaves or the
+// server shuts down. The first request subscribes. Each update goes to one of
+// the subscribers, which acks it before it gets the next one.
+func (s *Server) StreamUpdates(stream scraperv1.ScraperService_StreamUpdatesServer) error {
+	const op = "grpc.StreamUpdates"
+
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	log := s.log.With(slog.String("op", op), slog.String("consumer", req.GetConsumer()))
+
+	acks := make(chan *scraperv1.UpdateAck)
+	broken := make(chan error, 1)
+
+	go receiveAcks(stream, acks, broken)
+
+	sub := s.updates.subscribe()
+	defer s.updates.unsubscribe(sub)
+
+	log.Info("update stream opened")
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			log.Info("update stream closed")
+			return nil
+		case err := <-broken:
+			return closed(log, err)
+		case <-s.updates.closed:
+			return status.Error(codes.Unavailable, "scraper is shutting down")
+		case delivery := <-sub.deliveries:
+			if err := s.deliver(stream, delivery, acks, broken); err != nil {
+				log.Warn("failed to deliver update", slog.String("event_id", delivery.update.GetEventId()),
+					slog.String("error", err.Error()))
+
+				return closed(log, err)
+			}
+		}
+	}
+}
+
+// deliver sends the update and hands its ack to the waiting sender. An error
+// means that the stream is of no further use.
+func (s *Server) deliver(stream scraperv1.ScraperService_StreamUpdatesServer, d delivery,
+	acks <-chan *scraperv1.UpdateAck, broken <-chan error) error {
+	if err := stream.Send(d.update); err != nil {
+		d.result <- err
+		return err
+	}
+
+	timeout := time.NewTimer(ackTimeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case ack := <-acks:
+			// Acks of updates sent before are late and already answered.
+			if ack.GetEventId() != d.update.GetEventId() {
+				continue
+			}
+
+			if ack.GetError() != "" {
+				d.result <- fmt.Errorf("%w: %s", ErrRejected, ack.GetError())
+			} else {
+				d.result <- nil
+			}
+
+			return nil
+		case err := <-broken:
+			d.result <- ErrNotAcked
+			return err
+		case <-timeout.C:
+			d.result <- ErrNotAcked
+			return status.Error(codes.DeadlineExceeded, "update not acknowledged in time")
+		case <-stream.Context().Done():
+			d.result <- ErrNotAcked
+			return stream.Context().Err()
+		case <-s.updates.closed:
+			d.result <- ErrNotAcked
+			return status.Error(codes.Unavailable, "scraper is shutting down")
+		}
+	}
+}
+
+// receiveAcks passes the acks of the subscriber on until the stream breaks.
+func receiveAcks(stream scraperv1.ScraperService_StreamUpdatesServer, acks chan<- *scraperv1.UpdateAck,
+	broken chan<- error) {
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			broken <- err
+			return
+		}
+
+		if req.GetAck() == nil {
+			continue
+		}
+
+		select {
+		case acks <- req.GetAck():
+		case <-stream.Context().Done():
+			return
+		}
+	}
+}
+
+// closed ends the stream. The subscriber closes its side when it leaves.
+func closed(log *slog.Logger, err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, context.Canceled) {
+		log.Info("update stream closed")
+		return nil
+	}
+
+	return err
+}
+
+func (s *Server) statusOf(op string, err error) error {
+	s.log.Error("request failed", slog.String("op", op), slog.String("error", err.Error()))
+
+	switch {
+	case errors.Is(err, storage.ErrAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, storage.ErrNotExists):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, usecase.ErrUnknownScope):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,333 @@
+package grpcapi_test
+
+import (
+	"pkg/api/scraperv1"
+	"scraper/internal/grpcapi"
+	"scraper/internal/model/scraper"
+	"scraper/internal/storage"
+	"scraper/internal/usecase"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+type fakeUseCase struct {
+	links        map[int64][]scraper.Link
+	addErr       error
+	historyQuery *scraper.HistoryQuery
+}
+
+func (f *fakeUseCase) NewChat(_ context.Context, id int64) error {
+	if _, ok := f.links[id]; ok {
+		return storage.ErrAlreadyExists
+	}
+
+	f.links[id] = []scraper.Link{}
+
+	return nil
+}
+
+func (f *fakeUseCase) DeleteChat(_ context.Context, id int64) error {
+	delete(f.links, id)
+	return nil
+}
+
+func (f *fakeUseCase) GetLinks(_ context.Context, id int64) ([]scraper.Link, error) {
+	return f.links[id], nil
+}
+
+func (f *fakeUseCase) AddLink(_ context.Context, id int64, link *scraper.Link) (scraper.Link, error) {
+	if f.addErr != nil {
+		return scraper.Link{}, f.addErr
+	}
+
+	link.ID = int64(len(f.links[id]) + 1)
+	f.links[id] = append(f.links[id], *link)
+
+	return *link, nil
+}
+
+func (f *fakeUseCase) RemoveLink(_ context.Context, id int64, url string) (scraper.Link, error) {
+	for i, link := range f.links[id] {
+		if link.URL == url {
+			f.links[id] = append(f.links[id][:i], f.links[id][i+1:]...)
+			return link, nil
+		}
+	}
+
+	return scraper.Link{}, storage.ErrNotExists
+}
+
+func (f *fakeUseCase) IssueToken(_ context.Context, id int64, scopes []string) (string, *scraper.APIToken, error) {
+	if _, ok := f.links[id]; !ok {
+		return "", nil, storage.ErrNotExists
+	}
+
+	for _, scope := range scopes {
+		if scope != scraper.ScopeLinksRead {
+			return "", nil, usecase.ErrUnknownScope
+		}
+	}
+
+	return "lt_token", &scraper.APIToken{ID: 1, ChatID: id, Scopes: []string{scraper.ScopeLinksRead}}, nil
+}
+
+func (f *fakeUseCase) RevokeTokens(_ context.Context, id int64) (int64, error) {
+	if _, ok := f.links[id]; !ok {
+		return 0, nil
+	}
+
+	return 1, nil
+}
+
+func (f *fakeUseCase) GetHistory(_ context.Context, query *scraper.HistoryQuery) ([]scraper.UpdateEntry, error) {
+	f.historyQuery = query
+
+	return []scraper.UpdateEntry{{
+		ID:        3,
+		LinkID:    1,
+		URL:       "https://github.com/some/repo",
+		Type:      "issue",
+		Title:     "Issue",
+		ItemURL:   "https://github.com/some/repo/issues/42",
+		Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}}, nil
+}
+
+func startServer(t *testing.T, uc grpcapi.UseCase, updates *grpcapi.Updates) scraperv1.ScraperServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	scraperv1.RegisterScraperServiceServer(server,
+		grpcapi.New(slog.New(slog.NewJSONHandler(io.Discard, nil)), uc, updates))
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		updates.Close()
+		_ = conn.Close()
+		server.Stop()
+	})
+
+	return scraperv1.NewScraperServiceClient(conn)
+}
+
+func TestServer_Links(t *testing.T) {
+	ctx := context.Background()
+	uc := &fakeUseCase{links: map[int64][]scraper.Link{}}
+	client := startServer(t, uc, grpcapi.NewUpdates())
+
+	_, err := client.RegisterChat(ctx, &scraperv1.RegisterChatRequest{ChatId: 1})
+	require.NoError(t, err)
+
+	_, err = client.RegisterChat(ctx, &scraperv1.RegisterChatRequest{ChatId: 1})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	added, err := client.AddLink(ctx, &scraperv1.AddLinkRequest{
+		ChatId: 1,
+		Url:    "https://github.com/some/repo",
+		Tags:   []string{"work"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), added.GetId())
+	require.Equal(t, []string{"work"}, added.GetTags())
+
+	_, err = client.AddLink(ctx, &scraperv1.AddLinkRequest{ChatId: 1})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	lastSuccess := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	uc.links[1][0].Health = scraper.LinkHealth{LastSuccess: &lastSuccess, Failures: 2, LastStatus: 502}
+
+	list, err := client.ListLinks(ctx, &scraperv1.ListLinksRequest{ChatId: 1})
+	require.NoError(t, err)
+	require.Len(t, list.GetLinks(), 1)
+	require.Equal(t, int32(2), list.GetLinks()[0].GetHealth().GetFailures())
+	require.Equal(t, int32(502), list.GetLinks()[0].GetHealth().GetLastStatus())
+	require.True(t, lastSuccess.Equal(list.GetLinks()[0].GetHealth().GetLastSuccess().AsTime()))
+
+	_, err = client.RemoveLink(ctx, &scraperv1.RemoveLinkRequest{ChatId: 1, Url: "https://github.com/other/repo"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	removed, err := client.RemoveLink(ctx, &scraperv1.RemoveLinkRequest{ChatId: 1, Url: "https://github.com/some/repo"})
+	require.NoError(t, err)
+	require.Equal(t, "https://github.com/some/repo", removed.GetUrl())
+}
+
+func TestServer_Tokens(t *testing.T) {
+	ctx := context.Background()
+	client := startServer(t, &fakeUseCase{links: map[int64][]scraper.Link{1: {}}}, grpcapi.NewUpdates())
+
+	issued, err := client.IssueToken(ctx, &scraperv1.IssueTokenRequest{ChatId: 1})
+	require.NoError(t, err)
+	require.Equal(t, "lt_token", issued.GetToken())
+	require.Equal(t, []string{scraper.ScopeLinksRead}, issued.GetScopes())
+
+	_, err = client.IssueToken(ctx, &scraperv1.IssueTokenRequest{ChatId: 2})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.IssueToken(ctx, &scraperv1.IssueTokenRequest{ChatId: 1, Scopes: []string{"admin"}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	revoked, err := client.RevokeTokens(ctx, &scraperv1.RevokeTokensRequest{ChatId: 1})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), revoked.GetRevoked())
+}
+
+func TestServer_GetHistory(t *testing.T) {
+	uc := &fakeUseCase{links: map[int64][]scraper.Link{}}
+	client := startServer(t, uc, grpcapi.NewUpdates())
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	resp, err := client.GetHistory(context.Background(), &scraperv1.GetHistoryRequest{
+		ChatId: 1,
+		Url:    "https://github.com/some/repo",
+		Since:  timestamppb.New(since),
+		Limit:  5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &scraper.HistoryQuery{ChatID: 1, URL: "https://github.com/some/repo", Since: since, Limit: 5},
+		uc.historyQuery)
+	require.Len(t, resp.GetEntries(), 1)
+	require.Equal(t, "Issue", resp.GetEntries()[0].GetTitle())
+	require.Equal(t, "https://github.com/some/repo/issues/42", resp.GetEntries()[0].GetItemUrl())
+}
+
+func TestServer_AddLinkInternalError(t *testing.T) {
+	uc := &fakeUseCase{links: map[int64][]scraper.Link{}, addErr: io.ErrUnexpectedEOF}
+	client := startServer(t, uc, grpcapi.NewUpdates())
+
+	_, err := client.AddLink(context.Background(), &scraperv1.AddLinkRequest{ChatId: 1, Url: "https://github.com/a/b"})
+	require.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestServer_StreamUpdates(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	updates := grpcapi.NewUpdates()
+	client := startServer(t, &fakeUseCase{links: map[int64][]scraper.Link{}}, updates)
+
+	update := &scraper.LinkUpdate{
+		ID:            7,
+		EventID:       "event-1",
+		URL:           "https://github.com/some/repo",
+		SchemaVersion: scraper.LinkUpdateSchemaVersion,
+		TgChatIDs:     []int{1, 2},
+		Events: []scraper.LinkEvent{{
+			Provider:  "github",
+			Type:      "issue",
+			ItemID:    "42",
+			URL:       "https://github.com/some/repo/issues/42",
+			Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		}},
+	}
+
+	require.ErrorIs(t, updates.Updates(ctx, update), grpcapi.ErrNoSubscribers)
+
+	stream, err := client.StreamUpdates(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&scraperv1.StreamUpdatesRequest{Consumer: "test"}))
+
+	sent := make(chan error, 1)
+
+	go func() {
+		// The subscription is registered once the server handler runs.
+		for {
+			err := updates.Updates(ctx, update)
+			if err == nil || ctx.Err() != nil {
+				sent <- err
+				return
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	got, err := stream.Recv()
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&scraperv1.StreamUpdatesRequest{
+		Ack: &scraperv1.UpdateAck{EventId: got.GetEventId()},
+	}))
+	require.NoError(t, <-sent)
+
+	require.Equal(t, int64(7), got.GetId())
+	require.Equal(t, "event-1", got.GetEventId())
+	require.Equal(t, []int64{1, 2}, got.GetTgChatIds())
+	require.Len(t, got.GetEvents(), 1)
+	require.Equal(t, "42", got.GetEvents()[0].GetItemId())
+	require.True(t, update.Events[0].Timestamp.Equal(got.GetEvents()[0].GetTimestamp().AsTime()))
+}
+
+func TestServer_StreamUpdatesFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(stream scraperv1.ScraperService_StreamUpdatesClient, update *scraperv1.LinkUpdate) error
+		want    error
+	}{
+		{"rejected", func(stream scraperv1.ScraperService_StreamUpdatesClient, update *scraperv1.LinkUpdate) error {
+			return stream.Send(&scraperv1.StreamUpdatesRequest{
+				Ack: &scraperv1.UpdateAck{EventId: update.GetEventId(), Error: "telegram is down"},
+			})
+		}, grpcapi.ErrRejected},
+		{"stream closed before ack", func(stream scraperv1.ScraperService_StreamUpdatesClient,
+			_ *scraperv1.LinkUpdate) error {
+			return stream.CloseSend()
+		}, grpcapi.ErrNotAcked},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			updates := grpcapi.NewUpdates()
+			client := startServer(t, &fakeUseCase{links: map[int64][]scraper.Link{}}, updates)
+
+			stream, err := client.StreamUpdates(ctx)
+			require.NoError(t, err)
+			require.NoError(t, stream.Send(&scraperv1.StreamUpdatesRequest{Consumer: "test"}))
+
+			sent := make(chan error, 1)
+
+			go func() {
+				for {
+					err := updates.Updates(ctx, &scraper.LinkUpdate{ID: 7, EventID: "event-1", TgChatIDs: []int{1}})
+					if !errors.Is(err, grpcapi.ErrNoSubscribers) || ctx.Err() != nil {
+						sent <- err
+						return
+					}
+
+					time.Sleep(10 * time.Millisecond)
+				}
+			}()
+
+			got, err := stream.Recv()
+			require.NoError(t, err)
+			require.NoError(t, tc.respond(stream, got))
+			require.ErrorIs(t, <-sent, tc.want)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package grpcapi
+
+import (
+	"pkg/api/scraperv1"
+	"scraper/internal/model/scraper"
+
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ackTimeout bounds the wait for the ack of an update. A subscriber that
+// misses it loses its stream.
+const ackTimeout = 30 * time.Second
+
+var (
+	ErrNoSubscribers = errors.New("no update stream subscribers")
+	// ErrNotAcked is returned for an update that left on a stream which broke
+	// before the bot acked it. The bot skips events it has already delivered,
+	// so sending it again is safe.
+	ErrNotAcked = errors.New("update not acknowledged")
+	// ErrRejected is returned for an update the bot failed to deliver.
+	ErrRejected = errors.New("update rejected by the bot")
+)
+
+type delivery struct {
+	update *scraperv1.LinkUpdate
+	result chan error
+}
+
+// subscriber is one open update stream. done is closed once the stream is
+// gone, so that an update handed to it while it closes does not wait for a
+// reader that will never come.
+type subscriber struct {
+	deliveries chan delivery
+	done       chan struct{}
+}
+
+// Updates hands link updates to the bots subscribed with StreamUpdates. It
+// implements the scraper's update sender: Updates returns once the bot acks
+// the update, and fails when no bot is subscribed or the bot does not deliver
+// the update, so that the sender can fall back to another transport.
+type Updates struct {
+	mu     sync.Mutex
+	subs   []*subscriber
+	next   int
+	closed chan struct{}
+	once   sync.Once
+}
+
+func NewUpdates() *Updates {
+	return &Updates{closed: make(chan struct{})}
+}
+
+func (u *Updates) Updates(ctx context.Context, req *scraper.LinkUpdate) error {
+	const op = "grpc.Updates"
+
+	sub := u.pick()
+	if sub == nil {
+		return fmt.Errorf("%s: %w", op, ErrNoSubscribers)
+	}
+
+	d := delivery{update: updateToProto(req), result: make(chan error, 1)}
+
+	select {
+	case sub.deliveries <- d:
+	case <-sub.done:
+		return fmt.Errorf("%s: %w", op, ErrNoSubscribers)
+	case <-u.closed:
+		return fmt.Errorf("%s: %w", op, ErrNoSubscribers)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-d.result:
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close ends all open update streams.
+func (u *Updates) Close() {
+	u.once.Do(func() {
+		close(u.closed)
+	})
+}
+
+func (u *Updates) subscribe() *subscriber {
+	sub := &subscriber{deliveries: make(chan delivery), done: make(chan struct{})}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.subs = append(u.subs, sub)
+
+	return sub
+}
+
+func (u *Updates) unsubscribe(sub *subscriber) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	close(sub.done)
+
+	for i, s := range u.subs {
+		if s == sub {
+			u.subs = append(u.subs[:i], u.subs[i+1:]...)
+			break
+		}
+	}
+}
+
+// pick spreads updates over the subscribers round robin.
+func (u *Updates) pick() *subscriber {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if len(u.subs) == 0 {
+		return nil
+	}
+
+	u.next = (u.next + 1) % len(u.subs)
+
+	return u.subs[u.next]
+}
@@ -0,0 +1,36 @@
+package grpcapi
+
+import (
+	"scraper/internal/model/scraper"
+
+	"github.com/stretchr/testify/require"
+
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUpdates_SubscriberLeavesMidSend(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	updates := NewUpdates()
+	sub := updates.subscribe()
+
+	sent := make(chan error, 1)
+
+	go func() {
+		sent <- updates.Updates(ctx, &scraper.LinkUpdate{ID: 7, TgChatIDs: []int{1}})
+	}()
+
+	// The stream goes away without ever reading the update it was picked for.
+	time.Sleep(20 * time.Millisecond)
+	updates.unsubscribe(sub)
+
+	select {
+	case err := <-sent:
+		require.ErrorIs(t, err, ErrNoSubscribers)
+	case <-ctx.Done():
+		t.Fatal("Updates must not wait for a subscriber that left")
+	}
+}
@@ -0,0 +1,104 @@
+package health_test
+
+import (
+	"scraper/internal/health"
+
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/require"
+
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func ready(t *testing.T, checker *health.Checker) (int, health.Report) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	checker.Ready()(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report health.Report
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+
+	return recorder.Code, report
+}
+
+func TestChecker_Ready(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	failing := func(context.Context) error { return errors.New("connection refused") }
+
+	t.Run("all checks pass", func(t *testing.T) {
+		checker := health.New(time.Second)
+		checker.Add("postgres", ok)
+		checker.AddOptional("github_breaker", ok)
+
+		code, report := ready(t, checker)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, health.StatusOK, report.Status)
+		require.Len(t, report.Checks, 2)
+	})
+
+	t.Run("optional check fails", func(t *testing.T) {
+		checker := health.New(time.Second)
+		checker.Add("postgres", ok)
+		checker.AddOptional("github_breaker", failing)
+
+		code, report := ready(t, checker)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, health.StatusDegraded, report.Status)
+		require.Equal(t, "connection refused", report.Checks["github_breaker"].Error)
+		require.False(t, report.Checks["github_breaker"].Critical)
+	})
+
+	t.Run("critical check fails", func(t *testing.T) {
+		checker := health.New(time.Second)
+		checker.Add("postgres", failing)
+		checker.AddOptional("github_breaker", failing)
+
+		code, report := ready(t, checker)
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		require.Equal(t, health.StatusFail, report.Status)
+		require.Equal(t, health.StatusFail, report.Checks["postgres"].Status)
+	})
+
+	t.Run("slow check times out", func(t *testing.T) {
+		checker := health.New(10 * time.Millisecond)
+		checker.Add("postgres", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		code, report := ready(t, checker)
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		require.Equal(t, context.DeadlineExceeded.Error(), report.Checks["postgres"].Error)
+	})
+}
+
+func TestChecker_Live(t *testing.T) {
+	checker := health.New(time.Second)
+	checker.Add("postgres", func(context.Context) error { return errors.New("down") })
+
+	recorder := httptest.NewRecorder()
+	checker.Live()(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"status":"ok"}`, recorder.Body.String())
+}
+
+func TestBreaker(t *testing.T) {
+	cb := gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        "GitHub",
+		ReadyToTrip: func(counts gobreaker.Counts) bool { return counts.ConsecutiveFailures >= 1 },
+	})
+	check := health.Breaker(cb)
+
+	require.NoError(t, check(context.Background()))
+
+	_, _ = cb.Execute(func() (any, error) { return nil, errors.New("boom") })
+
+	require.EqualError(t, check(context.Background()), `circuit breaker "GitHub" is open`)
+}
@@ -86,6 +86,8 @@ func New(ctx context.Context, log *slog.Logger, uc UseCase) http.HandlerFunc {
 				utils.RespondWithError(writer, http.StatusInternalServerError, "failed to add link", "StatusInternalServerError",
 					"APIError", "failed to add link")
 			}
+
+			return
 		}
 
 		log.Info("success add link")
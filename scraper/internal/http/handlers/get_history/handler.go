@@ -0,0 +1,95 @@
+package gethistory
+
+import (
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	scrapModel "scraper/internal/model/scraper"
+	"scraper/internal/usecase"
+	"scraper/utils"
+
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const defaultLimit = 50
+
+//go:generate ../../../../../../bin/mockery --name=UseCase
+type UseCase interface {
+	GetHistory(ctx context.Context, query *scrapModel.HistoryQuery) ([]scrapModel.UpdateEntry, error)
+}
+
+// New pages through the chat's update history, latest first. The "link",
+// "since" and "until" query parameters narrow it down, "limit" (at most
+// usecase.MaxHistoryLimit) and "offset" pick the page. The chat comes from the Tg-Chat-Id header, which the API
+// token middleware sets for public requests.
+func New(ctx context.Context, log *slog.Logger, uc UseCase) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const op = "handlers.get.history"
+
+		log = log.With(slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(request.Context())))
+
+		intID, err := strconv.ParseInt(request.Header.Get("Tg-Chat-Id"), 10, 64)
+		if err != nil {
+			log.Error("invalid id provided", slog.String("error", err.Error()))
+			utils.RespondWithError(writer, http.StatusBadRequest, "invalid id provided", "BadRequest",
+				"APIError", "Invalid ID provided")
+
+			return
+		}
+
+		params := request.URL.Query()
+		query := scrapModel.HistoryQuery{
+			ChatID: intID,
+			URL:    params.Get("link"),
+			Limit:  defaultLimit,
+		}
+
+		for name, value := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+			if params.Get(name) == "" {
+				continue
+			}
+
+			if *value, err = time.Parse(time.RFC3339, params.Get(name)); err != nil {
+				utils.RespondWithError(writer, http.StatusBadRequest, "invalid "+name, "BadRequest",
+					"APIError", name+" must be an RFC 3339 time")
+
+				return
+			}
+		}
+
+		if value := params.Get("limit"); value != "" {
+			if query.Limit, err = strconv.ParseUint(value, 10, 64); err != nil || query.Limit < 1 ||
+				query.Limit > usecase.MaxHistoryLimit {
+				utils.RespondWithError(writer, http.StatusBadRequest, "invalid limit", "BadRequest",
+					"APIError", "limit must be between 1 and "+strconv.Itoa(usecase.MaxHistoryLimit))
+
+				return
+			}
+		}
+
+		if value := params.Get("offset"); value != "" {
+			if query.Offset, err = strconv.ParseUint(value, 10, 64); err != nil {
+				utils.RespondWithError(writer, http.StatusBadRequest, "invalid offset", "BadRequest",
+					"APIError", "offset must not be negative")
+
+				return
+			}
+		}
+
+		updates, err := uc.GetHistory(ctx, &query)
+		if err != nil {
+			log.Error("failed to get history", slog.String("error", err.Error()))
+			utils.RespondWithError(writer, http.StatusInternalServerError, "failed to get history",
+				"InternalServerError", "APIError", "failed to get history")
+
+			return
+		}
+
+		log.Info("success get history")
+		render.JSON(writer, request, scrapModel.ListUpdatesResponse{Updates: updates, Size: len(updates)})
+	}
+}
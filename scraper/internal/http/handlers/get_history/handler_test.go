@@ -0,0 +1,116 @@
+package gethistory_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"scraper/internal/http/handlers/get_history"
+	"scraper/internal/http/handlers/get_history/mocks"
+	scrapModel "scraper/internal/model/scraper"
+
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func serve(uc gethistory.UseCase, target, chatID string) *httptest.ResponseRecorder {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	req := httptest.NewRequest(http.MethodGet, target, http.NoBody)
+	if chatID != "" {
+		req.Header.Set("Tg-Chat-Id", chatID)
+	}
+
+	rec := httptest.NewRecorder()
+	gethistory.New(context.Background(), logger, uc)(rec, req)
+
+	return rec
+}
+
+func TestGetHistoryHandler_Success(t *testing.T) {
+	mockUseCase := new(mocks.UseCase)
+	occurred := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mockUseCase.On("GetHistory", mock.Anything, &scrapModel.HistoryQuery{
+		ChatID: 12345,
+		URL:    "https://github.com/a/b",
+		Since:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Until:  time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		Limit:  10,
+		Offset: 20,
+	}).Return([]scrapModel.UpdateEntry{{
+		LinkID:    1,
+		URL:       "https://github.com/a/b",
+		Type:      "issue",
+		Title:     "Fix it",
+		ItemURL:   "https://github.com/a/b/issues/1",
+		Timestamp: occurred,
+	}}, nil)
+
+	rec := serve(mockUseCase, "/history?link=https://github.com/a/b&since=2025-01-01T00:00:00Z"+
+		"&until=2025-02-01T00:00:00Z&limit=10&offset=20", "12345")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp scrapModel.ListUpdatesResponse
+
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, 1, resp.Size)
+	assert.Equal(t, "Fix it", resp.Updates[0].Title)
+	assert.Equal(t, "https://github.com/a/b/issues/1", resp.Updates[0].ItemURL)
+
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestGetHistoryHandler_Defaults(t *testing.T) {
+	mockUseCase := new(mocks.UseCase)
+
+	mockUseCase.On("GetHistory", mock.Anything, &scrapModel.HistoryQuery{ChatID: 12345, Limit: 50}).
+		Return([]scrapModel.UpdateEntry{}, nil)
+
+	rec := serve(mockUseCase, "/history", "12345")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestGetHistoryHandler_BadRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		chatID string
+	}{
+		{"no chat", "/history", ""},
+		{"invalid since", "/history?since=yesterday", "12345"},
+		{"invalid until", "/history?until=tomorrow", "12345"},
+		{"invalid limit", "/history?limit=0", "12345"},
+		{"limit too large", "/history?limit=101", "12345"},
+		{"limit overflows", "/history?limit=18446744073709551615", "12345"},
+		{"invalid offset", "/history?offset=-1", "12345"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(new(mocks.UseCase), tc.target, tc.chatID)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestGetHistoryHandler_UseCaseError(t *testing.T) {
+	mockUseCase := new(mocks.UseCase)
+
+	mockUseCase.On("GetHistory", mock.Anything, mock.Anything).
+		Return(nil, errors.New("storage is down"))
+
+	rec := serve(mockUseCase, "/history", "12345")
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	mockUseCase.AssertExpectations(t)
+}
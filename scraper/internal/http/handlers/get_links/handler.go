@@ -51,6 +51,10 @@ func New(ctx context.Context, log *slog.Logger, uc UseCase) http.HandlerFunc {
 			return
 		}
 
+		if links == nil {
+			links = []scrapModel.Link{}
+		}
+
 		resp := scrapModel.ListLinksResponse{
 			Links: links,
 			Size:  len(links),
@@ -0,0 +1,74 @@
+package issuetoken
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	scrapModel "scraper/internal/model/scraper"
+	"scraper/internal/storage"
+	"scraper/internal/usecase"
+	"scraper/utils"
+
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+//go:generate ../../../../../../bin/mockery --name=UseCase
+type UseCase interface {
+	IssueToken(ctx context.Context, id int64, scopes []string) (string, *scrapModel.APIToken, error)
+}
+
+func New(ctx context.Context, log *slog.Logger, uc UseCase) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const op = "handlers.issue.token"
+
+		log = log.With(slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(request.Context())))
+
+		intID, err := strconv.ParseInt(chi.URLParam(request, "id"), 10, 64)
+		if err != nil {
+			log.Error("invalid id provided", slog.String("error", err.Error()))
+			utils.RespondWithError(writer, http.StatusBadRequest, "invalid id provided", "BadRequest",
+				"APIError", "Invalid ID provided")
+
+			return
+		}
+
+		var req scrapModel.IssueTokenRequest
+
+		// The body is optional, without it the token gets the default scopes.
+		if err = render.DecodeJSON(request.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+			log.Error("failed to deserialize request", slog.String("error", err.Error()))
+			utils.RespondWithError(writer, http.StatusBadRequest, "failed to deserialize request", "StatusBadRequest",
+				"APIError", "fail to decode request")
+
+			return
+		}
+
+		token, issued, err := uc.IssueToken(ctx, intID, req.Scopes)
+		if err != nil {
+			log.Error("failed to issue token", slog.String("error", err.Error()))
+
+			switch {
+			case errors.Is(err, usecase.ErrUnknownScope):
+				utils.RespondWithError(writer, http.StatusBadRequest, "unknown scope", "StatusBadRequest",
+					"APIError", err.Error())
+			case errors.Is(err, storage.ErrNotExists):
+				utils.RespondWithError(writer, http.StatusNotFound, "chat does not exist", "StatusNotFound",
+					"APIError", "failed to issue token")
+			default:
+				utils.RespondWithError(writer, http.StatusInternalServerError, "failed to issue token",
+					"StatusInternalServerError", "APIError", "failed to issue token")
+			}
+
+			return
+		}
+
+		log.Info("success issue token", slog.Int64("token_id", issued.ID))
+		render.JSON(writer, request, scrapModel.IssueTokenResponse{Token: token, Scopes: issued.Scopes})
+	}
+}
@@ -0,0 +1,108 @@
+package issuetoken_test
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"scraper/internal/http/handlers/issue_token"
+	"scraper/internal/http/handlers/issue_token/mocks"
+	scrapModel "scraper/internal/model/scraper"
+	"scraper/internal/storage"
+	"scraper/internal/usecase"
+
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serve(uc issuetoken.UseCase, path, body string) *httptest.ResponseRecorder {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	r := chi.NewRouter()
+	r.Post("/tg-chat/{id}/tokens", issuetoken.New(context.Background(), logger, uc))
+
+	var reader io.Reader = http.NoBody
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, reader))
+
+	return rec
+}
+
+func TestIssueTokenHandler_Success(t *testing.T) {
+	mockUseCase := new(mocks.UseCase)
+
+	mockUseCase.On("IssueToken", mock.Anything, int64(12345), []string{scrapModel.ScopeLinksRead}).
+		Return("lt_secret", &scrapModel.APIToken{ID: 1, ChatID: 12345, Scopes: []string{scrapModel.ScopeLinksRead}},
+			nil)
+
+	rec := serve(mockUseCase, "/tg-chat/12345/tokens", `{"scopes":["links:read"]}`)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp scrapModel.IssueTokenResponse
+
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, "lt_secret", resp.Token)
+	assert.Equal(t, []string{scrapModel.ScopeLinksRead}, resp.Scopes)
+
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestIssueTokenHandler_DefaultScopesWithoutBody(t *testing.T) {
+	mockUseCase := new(mocks.UseCase)
+
+	mockUseCase.On("IssueToken", mock.Anything, int64(12345), []string(nil)).
+		Return("lt_secret", &scrapModel.APIToken{ID: 1, ChatID: 12345}, nil)
+
+	rec := serve(mockUseCase, "/tg-chat/12345/tokens", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestIssueTokenHandler_InvalidIDProvided(t *testing.T) {
+	rec := serve(new(mocks.UseCase), "/tg-chat/abc/tokens", "")
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestIssueTokenHandler_InvalidBody(t *testing.T) {
+	rec := serve(new(mocks.UseCase), "/tg-chat/12345/tokens", `{"scopes":`)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestIssueTokenHandler_UseCaseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"unknown scope", usecase.ErrUnknownScope, http.StatusBadRequest},
+		{"chat not registered", storage.ErrNotExists, http.StatusNotFound},
+		{"storage failure", errors.New("storage is down"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUseCase := new(mocks.UseCase)
+
+			mockUseCase.On("IssueToken", mock.Anything, int64(12345), mock.Anything).Return("", nil, tc.err)
+
+			rec := serve(mockUseCase, "/tg-chat/12345/tokens", "")
+
+			assert.Equal(t, tc.status, rec.Code)
+			mockUseCase.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package revoketokens
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	scrapModel "scraper/internal/model/scraper"
+	"scraper/utils"
+
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+//go:generate ../../../../../../bin/mockery --name=UseCase
+type UseCase interface {
+	RevokeTokens(ctx context.Context, id int64) (int64, error)
+}
+
+func New(ctx context.Context, log *slog.Logger, uc UseCase) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const op = "handlers.revoke.tokens"
+
+		log = log.With(slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(request.Context())))
+
+		intID, err := strconv.ParseInt(chi.URLParam(request, "id"), 10, 64)
+		if err != nil {
+			log.Error("invalid id provided", slog.String("error", err.Error()))
+			utils.RespondWithError(writer, http.StatusBadRequest, "invalid id provided", "BadRequest",
+				"APIError", "Invalid ID provided")
+
+			return
+		}
+
+		revoked, err := uc.RevokeTokens(ctx, intID)
+		if err != nil {
+			log.Error("failed to revoke tokens", slog.String("error", err.Error()))
+			utils.RespondWithError(writer, http.StatusInternalServerError, "failed to revoke tokens",
+				"StatusInternalServerError", "APIError", "failed to revoke tokens")
+
+			return
+		}
+
+		log.Info("success revoke tokens", slog.Int64("revoked", revoked))
+		render.JSON(writer, request, scrapModel.RevokeTokensResponse{Revoked: revoked})
+	}
+}
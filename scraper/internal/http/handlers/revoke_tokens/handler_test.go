@@ -0,0 +1,65 @@
+package revoketokens_test
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"scraper/internal/http/handlers/revoke_tokens"
+	"scraper/internal/http/handlers/revoke_tokens/mocks"
+	scrapModel "scraper/internal/model/scraper"
+
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serve(uc revoketokens.UseCase, path string) *httptest.ResponseRecorder {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	r := chi.NewRouter()
+	r.Delete("/tg-chat/{id}/tokens", revoketokens.New(context.Background(), logger, uc))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, path, http.NoBody))
+
+	return rec
+}
+
+func TestRevokeTokensHandler_Success(t *testing.T) {
+	mockUseCase := new(mocks.UseCase)
+
+	mockUseCase.On("RevokeTokens", mock.Anything, int64(12345)).Return(int64(2), nil)
+
+	rec := serve(mockUseCase, "/tg-chat/12345/tokens")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp scrapModel.RevokeTokensResponse
+
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, int64(2), resp.Revoked)
+
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestRevokeTokensHandler_InvalidIDProvided(t *testing.T) {
+	rec := serve(new(mocks.UseCase), "/tg-chat/abc/tokens")
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRevokeTokensHandler_UseCaseError(t *testing.T) {
+	mockUseCase := new(mocks.UseCase)
+
+	mockUseCase.On("RevokeTokens", mock.Anything, int64(12345)).Return(int64(0), errors.New("storage is down"))
+
+	rec := serve(mockUseCase, "/tg-chat/12345/tokens")
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	mockUseCase.AssertExpectations(t)
+}
@@ -0,0 +1,102 @@
+// Package apitoken authenticates requests to the public API by the tokens
+// chats issue with the bot's /token command.
+package apitoken
+
+import (
+	"github.com/go-chi/httprate"
+	scrapModel "scraper/internal/model/scraper"
+	"scraper/internal/usecase"
+	"scraper/utils"
+
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*scrapModel.APIToken, error)
+}
+
+type contextKey struct{}
+
+// New only lets through requests carrying "Authorization: Bearer <token>"
+// with a live token. The chat of the token replaces any Tg-Chat-Id header,
+// so the chat handlers serve the public API as is.
+func New(log *slog.Logger, auth Authenticator) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || presented == "" {
+				utils.RespondWithError(w, http.StatusUnauthorized, "API token required", "Unauthorized",
+					"APIError", "missing API token")
+
+				return
+			}
+
+			token, err := auth.Authenticate(r.Context(), presented)
+			if err != nil {
+				if errors.Is(err, usecase.ErrInvalidToken) {
+					utils.RespondWithError(w, http.StatusUnauthorized, "API token required", "Unauthorized",
+						"APIError", "unknown or revoked API token")
+
+					return
+				}
+
+				log.Error("failed to authenticate API token", slog.String("error", err.Error()))
+				utils.RespondWithError(w, http.StatusInternalServerError, "failed to authenticate",
+					"InternalServerError", "APIError", "failed to authenticate")
+
+				return
+			}
+
+			r.Header.Set("Tg-Chat-Id", strconv.FormatInt(token.ChatID, 10))
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, token)))
+		})
+	}
+}
+
+// FromContext returns the token New authenticated the request with.
+func FromContext(ctx context.Context) *scrapModel.APIToken {
+	token, _ := ctx.Value(contextKey{}).(*scrapModel.APIToken)
+
+	return token
+}
+
+// RequireScope answers 403 to tokens without scope.
+func RequireScope(scope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token := FromContext(r.Context()); token == nil || !token.HasScope(scope) {
+				utils.RespondWithError(w, http.StatusForbidden, "token scope missing", "Forbidden",
+					"APIError", "the token lacks the "+scope+" scope")
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimit allows each token requestLimit requests per window.
+func RateLimit(requestLimit int, window time.Duration) func(next http.Handler) http.Handler {
+	return httprate.Limit(requestLimit, window,
+		httprate.WithKeyFuncs(func(r *http.Request) (string, error) {
+			token := FromContext(r.Context())
+			if token == nil {
+				return "", errors.New("request is not authenticated")
+			}
+
+			return strconv.FormatInt(token.ID, 10), nil
+		}),
+		httprate.WithLimitHandler(func(w http.ResponseWriter, _ *http.Request) {
+			utils.RespondWithError(w, http.StatusTooManyRequests, "rate limit exceeded", "TooManyRequests",
+				"APIError", "too many requests for this token")
+		}),
+	)
+}
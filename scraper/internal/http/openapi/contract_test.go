@@ -0,0 +1,519 @@
+package openapi_test
+
+import (
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"pkg/admin"
+	"pkg/breaker"
+	breakershandler "pkg/breakers"
+	"scraper/internal/config"
+	addlinkhandler "scraper/internal/http/handlers/add_link"
+	deletehandler "scraper/internal/http/handlers/delete_chat"
+	gethistoryhandler "scraper/internal/http/handlers/get_history"
+	getlinkhandler "scraper/internal/http/handlers/get_links"
+	issuetokenhandler "scraper/internal/http/handlers/issue_token"
+	newchathandler "scraper/internal/http/handlers/new_chat"
+	removelinkhandler "scraper/internal/http/handlers/remove_link"
+	revoketokenshandler "scraper/internal/http/handlers/revoke_tokens"
+	"scraper/internal/http/middleware/apitoken"
+	"scraper/internal/http/openapi"
+	scrapModel "scraper/internal/model/scraper"
+	"scraper/internal/storage"
+	"scraper/internal/usecase"
+	"scraper/utils"
+
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const adminToken = "secret"
+
+// fakeUseCase answers by chat id: 1 succeeds, 2 conflicts or is missing, 3
+// fails.
+type fakeUseCase struct{}
+
+func (fakeUseCase) NewChat(_ context.Context, id int64) error {
+	return errorFor(id, storage.ErrAlreadyExists)
+}
+
+func (fakeUseCase) DeleteChat(_ context.Context, id int64) error {
+	return errorFor(id, storage.ErrNotExists)
+}
+
+func (fakeUseCase) GetLinks(_ context.Context, id int64) ([]scrapModel.Link, error) {
+	if err := errorFor(id, storage.ErrNotExists); err != nil {
+		return nil, err
+	}
+
+	if id == 4 {
+		return nil, nil
+	}
+
+	lastSuccess := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	return []scrapModel.Link{
+		{ID: 1, URL: "https://github.com/some/repo", Tags: []string{"work"}, Filters: []string{}, ChatID: id},
+		{ID: 2, URL: "https://stackoverflow.com/questions/1", ChatID: id, LastUpdated: &lastSuccess,
+			Health: scrapModel.LinkHealth{LastSuccess: &lastSuccess, LastError: "502 Bad Gateway", LastStatus: 502,
+				Failures: 1}},
+	}, nil
+}
+
+func (fakeUseCase) AddLink(_ context.Context, id int64, link *scrapModel.Link) (scrapModel.Link, error) {
+	if err := errorFor(id, storage.ErrAlreadyExists); err != nil {
+		return scrapModel.Link{}, err
+	}
+
+	link.ChatID = id
+
+	return *link, nil
+}
+
+func (fakeUseCase) RemoveLink(_ context.Context, id int64, link string) (scrapModel.Link, error) {
+	if err := errorFor(id, storage.ErrNotExists); err != nil {
+		return scrapModel.Link{}, err
+	}
+
+	return scrapModel.Link{ID: 1, URL: link, Tags: []string{}, Filters: []string{}, ChatID: id}, nil
+}
+
+func (fakeUseCase) IssueToken(_ context.Context, id int64, scopes []string) (string, *scrapModel.APIToken, error) {
+	if err := errorFor(id, storage.ErrNotExists); err != nil {
+		return "", nil, err
+	}
+
+	if len(scopes) > 0 && scopes[0] == "links:admin" {
+		return "", nil, usecase.ErrUnknownScope
+	}
+
+	return "lt_secret", &scrapModel.APIToken{ID: 1, ChatID: id, Scopes: []string{scrapModel.ScopeLinksRead}}, nil
+}
+
+func (fakeUseCase) RevokeTokens(_ context.Context, id int64) (int64, error) {
+	return 1, errorFor(id, storage.ErrNotExists)
+}
+
+func (fakeUseCase) GetHistory(_ context.Context, query *scrapModel.HistoryQuery) ([]scrapModel.UpdateEntry, error) {
+	if err := errorFor(query.ChatID, storage.ErrNotExists); err != nil {
+		return nil, err
+	}
+
+	return []scrapModel.UpdateEntry{{
+		ID:        1,
+		ChatID:    query.ChatID,
+		LinkID:    1,
+		URL:       "https://github.com/some/repo",
+		Tags:      []string{"work"},
+		Provider:  "github",
+		Type:      "issue",
+		Title:     "Issue",
+		ItemURL:   "https://github.com/some/repo/issues/1",
+		Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}}, nil
+}
+
+// Authenticate knows a read-write token of chat 1, a read-only token of chat
+// 1 and a token of chat 3, whose requests fail.
+func (fakeUseCase) Authenticate(_ context.Context, token string) (*scrapModel.APIToken, error) {
+	switch token {
+	case "lt_rw":
+		return &scrapModel.APIToken{ID: 1, ChatID: 1,
+			Scopes: []string{scrapModel.ScopeLinksRead, scrapModel.ScopeLinksWrite}}, nil
+	case "lt_ro":
+		return &scrapModel.APIToken{ID: 2, ChatID: 1, Scopes: []string{scrapModel.ScopeLinksRead}}, nil
+	case "lt_failing":
+		return &scrapModel.APIToken{ID: 3, ChatID: 3,
+			Scopes: []string{scrapModel.ScopeLinksRead, scrapModel.ScopeLinksWrite}}, nil
+	default:
+		return nil, usecase.ErrInvalidToken
+	}
+}
+
+func errorFor(id int64, missing error) error {
+	switch id {
+	case 2:
+		return missing
+	case 3:
+		return errors.New("storage is down")
+	default:
+		return nil
+	}
+}
+
+func newRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	ctx := context.Background()
+	log := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	uc := fakeUseCase{}
+
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	validate, err := openapi.Validate(log, doc)
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.With(validate).Get("/openapi.json", openapi.Handler(doc))
+
+	router.Group(func(router chi.Router) {
+		router.Use(validate)
+
+		router.Route("/tg-chat", func(r chi.Router) {
+			r.Post("/{id}", newchathandler.New(ctx, log, uc))
+			r.Delete("/{id}", deletehandler.New(ctx, log, uc))
+			r.Post("/{id}/tokens", issuetokenhandler.New(ctx, log, uc))
+			r.Delete("/{id}/tokens", revoketokenshandler.New(ctx, log, uc))
+		})
+
+		router.Route("/links", func(r chi.Router) {
+			r.Get("/", getlinkhandler.New(ctx, log, uc))
+			r.Post("/", addlinkhandler.New(ctx, log, uc))
+			r.Delete("/", removelinkhandler.New(ctx, log, uc))
+		})
+
+		router.Get("/history", gethistoryhandler.New(ctx, log, uc))
+	})
+
+	router.Route("/api/v1", func(r chi.Router) {
+		r.Use(apitoken.New(log, uc))
+		r.Use(apitoken.RateLimit(3, time.Minute))
+		r.Use(validate)
+
+		read := apitoken.RequireScope(scrapModel.ScopeLinksRead)
+		write := apitoken.RequireScope(scrapModel.ScopeLinksWrite)
+
+		r.With(read).Get("/links", getlinkhandler.New(ctx, log, uc))
+		r.With(write).Post("/links", addlinkhandler.New(ctx, log, uc))
+		r.With(write).Delete("/links", removelinkhandler.New(ctx, log, uc))
+		r.With(read).Get("/updates", gethistoryhandler.New(ctx, log, uc))
+	})
+
+	breakers := breaker.NewRegistry()
+	breakers.Add("github", breaker.New(log, "GitHub API Circuit Breaker", config.CBConfig{}, nil))
+
+	router.Route("/admin", func(r chi.Router) {
+		r.Use(admin.New(adminToken, utils.RespondWithError))
+		r.Use(validate)
+
+		r.Get("/breakers", breakershandler.List(breakers))
+		r.Post("/breakers/{name}/reset", breakershandler.Reset(log, breakers, utils.RespondWithError))
+	})
+
+	return router
+}
+
+// TestContract runs every documented answer of every handler and checks the
+// request and the response against the document.
+func TestContract(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	specRouter, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err)
+
+	router := newRouter(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		chatID string
+		body   string
+		status int
+	}{
+		{"register chat", http.MethodPost, "/tg-chat/1", "", "", http.StatusOK},
+		{"register existing chat", http.MethodPost, "/tg-chat/2", "", "", http.StatusConflict},
+		{"register chat fails", http.MethodPost, "/tg-chat/3", "", "", http.StatusInternalServerError},
+		{"delete chat", http.MethodDelete, "/tg-chat/1", "", "", http.StatusOK},
+		{"delete missing chat", http.MethodDelete, "/tg-chat/2", "", "", http.StatusNotFound},
+		{"list links", http.MethodGet, "/links", "1", "", http.StatusOK},
+		{"list no links", http.MethodGet, "/links", "4", "", http.StatusOK},
+		{"list links fails", http.MethodGet, "/links", "3", "", http.StatusInternalServerError},
+		{"add link", http.MethodPost, "/links", "1", `{"link":"https://github.com/a/b","tags":["x"]}`,
+			http.StatusOK},
+		{"add link without tags", http.MethodPost, "/links", "1", `{"link":"https://github.com/a/b"}`,
+			http.StatusOK},
+		{"add existing link", http.MethodPost, "/links", "2", `{"link":"https://github.com/a/b"}`,
+			http.StatusConflict},
+		{"add link fails", http.MethodPost, "/links", "3", `{"link":"https://github.com/a/b"}`,
+			http.StatusInternalServerError},
+		{"remove link", http.MethodDelete, "/links", "1", `{"link":"https://github.com/a/b"}`, http.StatusOK},
+		{"remove missing link", http.MethodDelete, "/links", "2", `{"link":"https://github.com/a/b"}`,
+			http.StatusNotFound},
+		{"remove link fails", http.MethodDelete, "/links", "3", `{"link":"https://github.com/a/b"}`,
+			http.StatusInternalServerError},
+		{"issue token", http.MethodPost, "/tg-chat/1/tokens", "", `{"scopes":["links:read"]}`, http.StatusOK},
+		{"issue token with default scopes", http.MethodPost, "/tg-chat/1/tokens", "", "", http.StatusOK},
+		{"issue token for missing chat", http.MethodPost, "/tg-chat/2/tokens", "", "", http.StatusNotFound},
+		{"issue token fails", http.MethodPost, "/tg-chat/3/tokens", "", "", http.StatusInternalServerError},
+		{"revoke tokens", http.MethodDelete, "/tg-chat/1/tokens", "", "", http.StatusOK},
+		{"revoke tokens fails", http.MethodDelete, "/tg-chat/3/tokens", "", "", http.StatusInternalServerError},
+		{"get history", http.MethodGet, "/history?link=https://github.com/some/repo&until=2025-02-01T00:00:00Z" +
+			"&limit=20&offset=20", "1", "", http.StatusOK},
+		{"get history fails", http.MethodGet, "/history", "3", "", http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			requireContract(t, specRouter, router, tc.status, func() *http.Request {
+				return newRequest(tc.method, tc.path, tc.chatID, tc.body)
+			})
+		})
+	}
+}
+
+// TestContract_PublicAPI runs the public API with API tokens in place of the
+// chat header.
+func TestContract_PublicAPI(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	specRouter, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   string
+		status int
+	}{
+		{"list links", http.MethodGet, "/api/v1/links", "lt_ro", "", http.StatusOK},
+		{"list links without token", http.MethodGet, "/api/v1/links", "", "", http.StatusUnauthorized},
+		{"list links with revoked token", http.MethodGet, "/api/v1/links", "lt_revoked", "",
+			http.StatusUnauthorized},
+		{"list links fails", http.MethodGet, "/api/v1/links", "lt_failing", "", http.StatusInternalServerError},
+		{"add link", http.MethodPost, "/api/v1/links", "lt_rw", `{"link":"https://github.com/a/b"}`,
+			http.StatusOK},
+		{"add link with read-only token", http.MethodPost, "/api/v1/links", "lt_ro",
+			`{"link":"https://github.com/a/b"}`, http.StatusForbidden},
+		{"remove link", http.MethodDelete, "/api/v1/links", "lt_rw", `{"link":"https://github.com/a/b"}`,
+			http.StatusOK},
+		{"list updates", http.MethodGet, "/api/v1/updates?since=2025-01-01T00:00:00Z&limit=10", "lt_ro", "",
+			http.StatusOK},
+		{"list updates fails", http.MethodGet, "/api/v1/updates", "lt_failing", "", http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			requireContract(t, specRouter, newRouter(t), tc.status, func() *http.Request {
+				request := newRequest(tc.method, tc.path, "", tc.body)
+
+				if tc.token != "" {
+					request.Header.Set("Authorization", "Bearer "+tc.token)
+				}
+
+				return request
+			})
+		})
+	}
+}
+
+func TestContract_Admin(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	specRouter, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{"list breakers", http.MethodGet, "/admin/breakers", adminToken, http.StatusOK},
+		{"list breakers without token", http.MethodGet, "/admin/breakers", "wrong", http.StatusUnauthorized},
+		{"reset breaker", http.MethodPost, "/admin/breakers/github/reset", adminToken, http.StatusOK},
+		{"reset missing breaker", http.MethodPost, "/admin/breakers/gitlab/reset", adminToken, http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			requireContract(t, specRouter, newRouter(t), tc.status, func() *http.Request {
+				request := newRequest(tc.method, tc.path, "", "")
+				request.Header.Set("Authorization", "Bearer "+tc.token)
+
+				return request
+			})
+		})
+	}
+}
+
+func TestPublicAPI_RateLimitsEachToken(t *testing.T) {
+	router := newRouter(t)
+
+	send := func(token string) int {
+		request := newRequest(http.MethodGet, "/api/v1/links", "", "")
+		request.Header.Set("Authorization", "Bearer "+token)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, request)
+
+		return rec.Code
+	}
+
+	for range 3 {
+		require.Equal(t, http.StatusOK, send("lt_rw"))
+	}
+
+	require.Equal(t, http.StatusTooManyRequests, send("lt_rw"))
+	require.Equal(t, http.StatusOK, send("lt_ro"))
+}
+
+func TestPublicAPI_IgnoresChatHeader(t *testing.T) {
+	request := newRequest(http.MethodGet, "/api/v1/links", "3", "")
+	request.Header.Set("Authorization", "Bearer lt_ro")
+
+	rec := httptest.NewRecorder()
+	newRouter(t).ServeHTTP(rec, request)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp scrapModel.ListLinksResponse
+
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Equal(t, int64(1), resp.Links[0].ChatID)
+}
+
+// requireContract validates the request against the document, serves it and
+// validates the response. newRequest is called twice as serving consumes the
+// body.
+func requireContract(t *testing.T, specRouter routers.Router, router http.Handler, status int,
+	newRequest func() *http.Request) {
+	t.Helper()
+
+	request := newRequest()
+
+	route, params, err := specRouter.FindRoute(request)
+	require.NoError(t, err)
+
+	input := &openapi3filter.RequestValidationInput{
+		Request:    request,
+		PathParams: params,
+		Route:      route,
+		Options:    &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+	}
+	require.NoError(t, openapi3filter.ValidateRequest(context.Background(), input))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newRequest())
+
+	require.Equal(t, status, rec.Code, rec.Body.String())
+	require.NoError(t, openapi.ValidateResponse(input, rec.Code, rec.Header(), rec.Body.Bytes()))
+}
+
+func TestValidate_RejectsRequestsOutsideTheContract(t *testing.T) {
+	router := newRouter(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		chatID string
+		body   string
+	}{
+		{"non numeric chat id", http.MethodPost, "/tg-chat/abc", "", ""},
+		{"missing chat header", http.MethodGet, "/links", "", ""},
+		{"non numeric chat header", http.MethodGet, "/links", "abc", ""},
+		{"missing link", http.MethodPost, "/links", "1", `{"tags":["x"]}`},
+		{"wrong tags type", http.MethodPost, "/links", "1", `{"link":"https://github.com/a/b","tags":"x"}`},
+		{"missing body", http.MethodDelete, "/links", "1", ""},
+		{"unknown token scope", http.MethodPost, "/tg-chat/1/tokens", "", `{"scopes":["links:admin"]}`},
+		{"negative history offset", http.MethodGet, "/history?offset=-1", "1", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, newRequest(tc.method, tc.path, tc.chatID, tc.body))
+
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+			require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var resp scrapModel.APIErrorResponse
+
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			require.Equal(t, "ValidationError", resp.ExceptionName)
+		})
+	}
+}
+
+// TestValidate_AuthenticatesFirst checks that the contract is only checked
+// for authenticated callers.
+func TestValidate_AuthenticatesFirst(t *testing.T) {
+	router := newRouter(t)
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"without token", "", http.StatusUnauthorized},
+		{"with token", "lt_ro", http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := newRequest(http.MethodGet, "/api/v1/updates?since=yesterday", "", "")
+			if tc.token != "" {
+				request.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, request)
+
+			require.Equal(t, tc.status, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestHandler_ServesDocument(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRouter(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", http.NoBody))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var doc struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&doc))
+	require.Equal(t, "3.0.3", doc.OpenAPI)
+	require.Contains(t, doc.Paths, "/links")
+	require.Contains(t, doc.Paths, "/tg-chat/{id}")
+}
+
+func newRequest(method, path, chatID, body string) *http.Request {
+	var reader io.Reader = http.NoBody
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	request := httptest.NewRequest(method, path, reader)
+
+	if body != "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	if chatID != "" {
+		request.Header.Set("Tg-Chat-Id", chatID)
+	}
+
+	return request
+}
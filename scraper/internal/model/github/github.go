@@ -5,10 +5,19 @@ import "time"
 type GitHubRepo struct {
 	PoolRequests []GitHubData
 	Issues       []GitHubData
+	// CanonicalURL is set when the repository was renamed or transferred.
+	CanonicalURL string
+}
+
+type RepoInfo struct {
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
 }
 
 type GitHubData struct {
 	Title     string    `json:"title"`
+	HTMLURL   string    `json:"html_url"`
+	Number    int       `json:"number"`
 	User      User      `json:"user"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Body      string    `json:"body"`
@@ -0,0 +1,39 @@
+package scraper
+
+import (
+	"slices"
+	"time"
+)
+
+// Scopes of a public API token.
+const (
+	ScopeLinksRead  = "links:read"
+	ScopeLinksWrite = "links:write"
+)
+
+// APIToken authorizes scripts of a chat on the public API. Only the hash of
+// the token is stored.
+type APIToken struct {
+	ID        int64      `json:"id"`
+	ChatID    int64      `json:"chatId"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"createdAt"`
+	LastUsed  *time.Time `json:"lastUsed,omitempty"`
+}
+
+func (t *APIToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+type IssueTokenRequest struct {
+	Scopes []string `json:"scopes"`
+}
+
+type IssueTokenResponse struct {
+	Token  string   `json:"token"`
+	Scopes []string `json:"scopes"`
+}
+
+type RevokeTokensResponse struct {
+	Revoked int64 `json:"revoked"`
+}
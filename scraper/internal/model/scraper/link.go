@@ -9,4 +9,15 @@ type Link struct {
 	Filters     []string   `json:"filters"`
 	LastUpdated *time.Time `json:"last_updated"`
 	ChatID      int64      `json:"chatId"`
+	Health      LinkHealth `json:"health"`
+}
+
+// LinkHealth describes how polling the link went lately. Failures counts
+// polls in a row that ended with an error; a broken link is not polled.
+type LinkHealth struct {
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	LastStatus  int        `json:"lastStatus,omitempty"`
+	Failures    int        `json:"failures"`
+	Broken      bool       `json:"broken"`
 }
@@ -0,0 +1,29 @@
+package scraper
+
+import "time"
+
+// LinkUpdateSchemaVersion is bumped on every incompatible change of LinkEvent.
+const LinkUpdateSchemaVersion = 1
+
+// EventLinkRemoved is sent once a link is dropped from tracking; Reason tells
+// the bot why.
+const EventLinkRemoved = "link.removed"
+
+const (
+	RemovalUnsupported = "unsupported"
+	RemovalNotFound    = "not_found"
+	RemovalDeleted     = "deleted"
+	RemovalAccessLost  = "access_lost"
+)
+
+type LinkEvent struct {
+	Provider  string    `json:"provider"`
+	Type      string    `json:"type"`
+	ItemID    string    `json:"itemId"`
+	Title     string    `json:"title,omitempty"`
+	Author    string    `json:"author,omitempty"`
+	URL       string    `json:"url"`
+	Timestamp time.Time `json:"timestamp"`
+	Excerpt   string    `json:"excerpt,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+}
@@ -1,8 +1,10 @@
 package scraper
 
 type LinkUpdate struct {
-	ID          int    `json:"id"`
-	URL         string `json:"url"`
-	Description string `json:"description"`
-	TgChatIDs   []int  `json:"tgChatIds"`
+	ID            int         `json:"id"`
+	EventID       string      `json:"eventId,omitempty"`
+	URL           string      `json:"url"`
+	SchemaVersion int         `json:"schemaVersion,omitempty"`
+	Events        []LinkEvent `json:"events,omitempty"`
+	TgChatIDs     []int       `json:"tgChatIds"`
 }
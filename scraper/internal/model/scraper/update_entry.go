@@ -0,0 +1,34 @@
+package scraper
+
+import "time"
+
+// UpdateEntry is an event sent to a chat, as kept in the update history.
+// URL is the tracked link, ItemURL the issue, answer or comment it is about.
+type UpdateEntry struct {
+	ID        int64     `json:"id"`
+	ChatID    int64     `json:"chatId"`
+	LinkID    int64     `json:"linkId"`
+	URL       string    `json:"url"`
+	Tags      []string  `json:"tags"`
+	Provider  string    `json:"provider"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	ItemURL   string    `json:"itemUrl"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// HistoryQuery selects a page of the chat's update history, latest first.
+// Empty URL, Since and Until do not filter.
+type HistoryQuery struct {
+	ChatID int64
+	URL    string
+	Since  time.Time
+	Until  time.Time
+	Limit  uint64
+	Offset uint64
+}
+
+type ListUpdatesResponse struct {
+	Updates []UpdateEntry `json:"updates"`
+	Size    int           `json:"size"`
+}
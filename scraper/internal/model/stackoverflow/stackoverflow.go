@@ -10,10 +10,14 @@ type StackOverflowQuestion struct {
 }
 
 type Item struct {
-	Title     string `json:"title"`
-	User      User   `json:"owner"`
-	UpdatedAt int64  `json:"creation_date"`
-	Body      string `json:"body"`
+	Title      string `json:"title"`
+	AnswerID   int64  `json:"answer_id"`
+	CommentID  int64  `json:"comment_id"`
+	PostID     int64  `json:"post_id"`
+	QuestionID int64  `json:"question_id"`
+	User       User   `json:"owner"`
+	UpdatedAt  int64  `json:"creation_date"`
+	Body       string `json:"body"`
 }
 
 type User struct {
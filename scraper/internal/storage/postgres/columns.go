@@ -0,0 +1,33 @@
+package postgres
+
+import "scraper/internal/model/scraper"
+
+// linkColumns are read into a link by linkFields, in the same order.
+const linkColumns = "id, link, tags, filters, lastUpdated, chatId, failures, broken, last_success, " +
+	"COALESCE(last_error, ''), COALESCE(last_status, 0)"
+
+func linkFields(link *scraper.Link) []any {
+	return []any{
+		&link.ID, &link.URL, &link.Tags, &link.Filters, &link.LastUpdated, &link.ChatID,
+		&link.Health.Failures, &link.Health.Broken, &link.Health.LastSuccess, &link.Health.LastError,
+		&link.Health.LastStatus,
+	}
+}
+
+// tokenColumns are read into a token by tokenFields, in the same order.
+const tokenColumns = "id, chatid, scopes, created_at, last_used"
+
+func tokenFields(token *scraper.APIToken) []any {
+	return []any{&token.ID, &token.ChatID, &token.Scopes, &token.CreatedAt, &token.LastUsed}
+}
+
+// historyColumns are read into an update entry by historyFields, in the same
+// order.
+const historyColumns = "id, chatid, linkid, link, tags, provider, event_type, title, url, occurred_at"
+
+func historyFields(entry *scraper.UpdateEntry) []any {
+	return []any{
+		&entry.ID, &entry.ChatID, &entry.LinkID, &entry.URL, &entry.Tags, &entry.Provider, &entry.Type,
+		&entry.Title, &entry.ItemURL, &entry.Timestamp,
+	}
+}
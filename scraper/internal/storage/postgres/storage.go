@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"scraper/internal/model/scraper"
 )
@@ -10,12 +11,23 @@ import (
 type Storage interface {
 	CreateNewChat(ctx context.Context, chatID int64) error
 	DeleteChat(ctx context.Context, chatID int64) error
-	GetLinks(ctx context.Context, limit, offset uint64) ([]scraper.Link, error)
+	GetLinks(ctx context.Context, limit uint64, afterID int64) ([]scraper.Link, error)
 	GetLinksByChatID(ctx context.Context, chatID int64) ([]scraper.Link, error)
 	AddLink(ctx context.Context, chatID int64, link *scraper.Link) (*scraper.Link, error)
 	RemoveLink(ctx context.Context, chatID int64, link string) (*scraper.Link, error)
 	UpdateLink(ctx context.Context, link *scraper.Link) (*scraper.Link, error)
+	RenameLink(ctx context.Context, link *scraper.Link, newURL string) (*scraper.Link, error)
+	RecordSuccess(ctx context.Context, link *scraper.Link, status int) error
+	RecordFailure(ctx context.Context, link *scraper.Link, status int, lastError string, gone bool) (int, error)
+	MarkBroken(ctx context.Context, link *scraper.Link) error
 	UpdateMetric(ctx context.Context, metricType string) (int64, error)
+	CreateToken(ctx context.Context, chatID int64, hash string, scopes []string) (*scraper.APIToken, error)
+	UseToken(ctx context.Context, hash string) (*scraper.APIToken, error)
+	RevokeTokens(ctx context.Context, chatID int64) (int64, error)
+	AddHistory(ctx context.Context, entries []scraper.UpdateEntry) error
+	GetHistory(ctx context.Context, query *scraper.HistoryQuery) ([]scraper.UpdateEntry, error)
+	DeleteHistory(ctx context.Context, before time.Time) (int64, error)
+	Ping(ctx context.Context) error
 }
 
 const (
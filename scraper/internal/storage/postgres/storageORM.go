@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"scraper/internal/storage"
+	"time"
 
 	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"scraper/internal/model/scraper"
@@ -46,6 +48,10 @@ func (s *ORMStorage) Stop() error {
 	return nil
 }
 
+func (s *ORMStorage) Ping(ctx context.Context) error {
+	return s.DB.Ping(ctx)
+}
+
 func (s *ORMStorage) CreateNewChat(ctx context.Context, chatID int64) error {
 	const op = "storage.createNewChat"
 
@@ -98,13 +104,17 @@ func (s *ORMStorage) DeleteChat(ctx context.Context, chatID int64) error {
 	return nil
 }
 
-func (s *ORMStorage) GetLinks(ctx context.Context, limit, offset uint64) ([]scraper.Link, error) {
+// GetLinks returns up to limit links with ids above afterID in id order.
+func (s *ORMStorage) GetLinks(ctx context.Context, limit uint64, afterID int64) ([]scraper.Link, error) {
 	const op = "storage.getLinks"
 
-	query, args, err := squirrel.Select("id", "link", "tags", "filters", "lastUpdated, chatId").
+	// Broken links are kept for their chats but no longer polled.
+	query, args, err := squirrel.Select(linkColumns).
 		From("links").
+		Where("NOT broken").
+		Where(squirrel.Gt{"id": afterID}).
+		OrderBy("id").
 		Limit(limit).
-		Offset(offset).
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
 
@@ -123,7 +133,7 @@ func (s *ORMStorage) GetLinks(ctx context.Context, limit, offset uint64) ([]scra
 	for rows.Next() {
 		var link scraper.Link
 
-		if err = rows.Scan(&link.ID, &link.URL, &link.Tags, &link.Filters, &link.LastUpdated, &link.ChatID); err != nil {
+		if err = rows.Scan(linkFields(&link)...); err != nil {
 			return nil, fmt.Errorf("%s: failed to scan link: %w", op, err)
 		}
 
@@ -136,7 +146,7 @@ func (s *ORMStorage) GetLinks(ctx context.Context, limit, offset uint64) ([]scra
 func (s *ORMStorage) GetLinksByChatID(ctx context.Context, chatID int64) ([]scraper.Link, error) {
 	const op = "storage.getLinks"
 
-	query, args, err := squirrel.Select("id", "link", "tags", "filters", "lastUpdated, chatId").
+	query, args, err := squirrel.Select(linkColumns).
 		From("links").
 		Where("chatId = ?", chatID).
 		PlaceholderFormat(squirrel.Dollar).
@@ -157,7 +167,7 @@ func (s *ORMStorage) GetLinksByChatID(ctx context.Context, chatID int64) ([]scra
 	for rows.Next() {
 		var link scraper.Link
 
-		if err = rows.Scan(&link.ID, &link.URL, &link.Tags, &link.Filters, &link.LastUpdated, &link.ChatID); err != nil {
+		if err = rows.Scan(linkFields(&link)...); err != nil {
 			return nil, fmt.Errorf("%s: failed to scan link: %w", op, err)
 		}
 
@@ -261,6 +271,129 @@ func (s *ORMStorage) UpdateLink(ctx context.Context, link *scraper.Link) (*scrap
 	return &updatedLink, nil
 }
 
+func (s *ORMStorage) RenameLink(ctx context.Context, link *scraper.Link, newURL string) (*scraper.Link, error) {
+	const op = "storage.renameLink"
+
+	var renamedLink scraper.Link
+
+	query, args, err := squirrel.Update("links").
+		Set("link", newURL).
+		Where("chatId = ?", link.ChatID).
+		Where("link = ?", link.URL).
+		Suffix("RETURNING id, link, tags, filters, lastUpdated, chatId").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to build query: %w", op, err)
+	}
+
+	err = s.DB.QueryRow(ctx, query, args...).Scan(&renamedLink.ID, &renamedLink.URL,
+		&renamedLink.Tags, &renamedLink.Filters, &renamedLink.LastUpdated, &renamedLink.ChatID)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+
+		switch {
+		case errors.As(err, &pgErr) && pgErr.Code == "23505":
+			return nil, storage.ErrAlreadyExists
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, storage.ErrNotExists
+		default:
+			return nil, fmt.Errorf("%s: failed to rename link: %w", op, err)
+		}
+	}
+
+	return &renamedLink, nil
+}
+
+func (s *ORMStorage) RecordSuccess(ctx context.Context, link *scraper.Link, status int) error {
+	const op = "storage.recordSuccess"
+
+	query, args, err := squirrel.Update("links").
+		Set("failures", 0).
+		Set("gone_polls", 0).
+		Set("last_success", squirrel.Expr("NOW()")).
+		Set("last_status", status).
+		Set("last_error", nil).
+		Where("chatId = ?", link.ChatID).
+		Where("link = ?", link.URL).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("%s: failed to build query: %w", op, err)
+	}
+
+	if _, err = s.DB.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("%s: failed to record success: %w", op, err)
+	}
+
+	return nil
+}
+
+// RecordFailure returns the number of polls in a row that found the link
+// gone; a failure that is not gone resets it.
+func (s *ORMStorage) RecordFailure(ctx context.Context, link *scraper.Link, status int, lastError string, gone bool) (int, error) {
+	const op = "storage.recordFailure"
+
+	var failures int
+
+	query, args, err := squirrel.Update("links").
+		Set("failures", squirrel.Expr("failures + 1")).
+		Set("gone_polls", squirrel.Expr("CASE WHEN ? THEN gone_polls + 1 ELSE 0 END", gone)).
+		Set("last_status", squirrel.Expr("NULLIF(?, 0)", status)).
+		Set("last_error", lastError).
+		Where("chatId = ?", link.ChatID).
+		Where("link = ?", link.URL).
+		Suffix("RETURNING gone_polls").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to build query: %w", op, err)
+	}
+
+	err = s.DB.QueryRow(ctx, query, args...).Scan(&failures)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return 0, storage.ErrNotExists
+		default:
+			return 0, fmt.Errorf("%s: failed to record failure: %w", op, err)
+		}
+	}
+
+	return failures, nil
+}
+
+func (s *ORMStorage) MarkBroken(ctx context.Context, link *scraper.Link) error {
+	const op = "storage.markBroken"
+
+	query, args, err := squirrel.Update("links").
+		Set("broken", true).
+		Where("chatId = ?", link.ChatID).
+		Where("link = ?", link.URL).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("%s: failed to build query: %w", op, err)
+	}
+
+	commandTag, err := s.DB.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: failed to mark link broken: %w", op, err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return storage.ErrNotExists
+	}
+
+	return nil
+}
+
 func (s *ORMStorage) UpdateMetric(ctx context.Context, metricType string) (int64, error) {
 	const op = "storage.UpdateMetric"
 
@@ -283,3 +416,186 @@ func (s *ORMStorage) UpdateMetric(ctx context.Context, metricType string) (int64
 
 	return count, nil
 }
+
+func (s *ORMStorage) CreateToken(ctx context.Context, chatID int64, hash string, scopes []string) (*scraper.APIToken,
+	error) {
+	const op = "storage.createToken"
+
+	var token scraper.APIToken
+
+	query, args, err := squirrel.Insert("api_tokens").
+		Columns("chatid", "token_hash", "scopes").
+		Values(chatID, hash, scopes).
+		Suffix("RETURNING " + tokenColumns).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to build query: %w", op, err)
+	}
+
+	err = s.DB.QueryRow(ctx, query, args...).Scan(tokenFields(&token)...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, storage.ErrNotExists
+		}
+
+		return nil, fmt.Errorf("%s: failed to create token: %w", op, err)
+	}
+
+	return &token, nil
+}
+
+// UseToken looks a token up by its hash and marks it used. Revoked tokens
+// are reported as missing.
+func (s *ORMStorage) UseToken(ctx context.Context, hash string) (*scraper.APIToken, error) {
+	const op = "storage.useToken"
+
+	var token scraper.APIToken
+
+	query, args, err := squirrel.Update("api_tokens").
+		Set("last_used", squirrel.Expr("NOW()")).
+		Where("token_hash = ?", hash).
+		Where("revoked_at IS NULL").
+		Suffix("RETURNING " + tokenColumns).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to build query: %w", op, err)
+	}
+
+	err = s.DB.QueryRow(ctx, query, args...).Scan(tokenFields(&token)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrNotExists
+		}
+
+		return nil, fmt.Errorf("%s: failed to use token: %w", op, err)
+	}
+
+	return &token, nil
+}
+
+func (s *ORMStorage) RevokeTokens(ctx context.Context, chatID int64) (int64, error) {
+	const op = "storage.revokeTokens"
+
+	query, args, err := squirrel.Update("api_tokens").
+		Set("revoked_at", squirrel.Expr("NOW()")).
+		Where("chatid = ?", chatID).
+		Where("revoked_at IS NULL").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to build query: %w", op, err)
+	}
+
+	commandTag, err := s.DB.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to revoke tokens: %w", op, err)
+	}
+
+	return commandTag.RowsAffected(), nil
+}
+
+func (s *ORMStorage) AddHistory(ctx context.Context, entries []scraper.UpdateEntry) error {
+	const op = "storage.addHistory"
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	builder := squirrel.Insert("update_history").
+		Columns("chatid", "linkid", "link", "tags", "provider", "event_type", "title", "url", "occurred_at")
+
+	for i := range entries {
+		entry := &entries[i]
+		builder = builder.Values(entry.ChatID, entry.LinkID, entry.URL, entry.Tags, entry.Provider, entry.Type,
+			entry.Title, entry.ItemURL, entry.Timestamp.UTC())
+	}
+
+	query, args, err := builder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: failed to build query: %w", op, err)
+	}
+
+	if _, err = s.DB.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("%s: failed to add history: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *ORMStorage) GetHistory(ctx context.Context, query *scraper.HistoryQuery) ([]scraper.UpdateEntry, error) {
+	const op = "storage.getHistory"
+
+	builder := squirrel.Select(historyColumns).
+		From("update_history").
+		Where("chatid = ?", query.ChatID)
+
+	if query.URL != "" {
+		builder = builder.Where("link = ?", query.URL)
+	}
+
+	if !query.Since.IsZero() {
+		builder = builder.Where("occurred_at > ?", query.Since.UTC())
+	}
+
+	if !query.Until.IsZero() {
+		builder = builder.Where("occurred_at <= ?", query.Until.UTC())
+	}
+
+	sqlQuery, args, err := builder.
+		OrderBy("occurred_at DESC", "id DESC").
+		Limit(query.Limit).
+		Offset(query.Offset).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to build query: %w", op, err)
+	}
+
+	rows, err := s.DB.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get history: %w", op, err)
+	}
+	defer rows.Close()
+
+	entries := []scraper.UpdateEntry{}
+
+	for rows.Next() {
+		var entry scraper.UpdateEntry
+
+		if err = rows.Scan(historyFields(&entry)...); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan history: %w", op, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (s *ORMStorage) DeleteHistory(ctx context.Context, before time.Time) (int64, error) {
+	const op = "storage.deleteHistory"
+
+	query, args, err := squirrel.Delete("update_history").
+		Where("occurred_at < ?", before.UTC()).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to build query: %w", op, err)
+	}
+
+	commandTag, err := s.DB.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to delete history: %w", op, err)
+	}
+
+	return commandTag.RowsAffected(), nil
+}
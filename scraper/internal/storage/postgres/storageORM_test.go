@@ -3,7 +3,10 @@ package postgres_test
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"scraper/internal/storage"
 	"scraper/internal/storage/postgres"
+	"strings"
 	"testing"
 	"time"
 
@@ -118,12 +121,173 @@ func TestORMStorage(t *testing.T) {
 		require.NotNil(t, links)
 		require.NotEmpty(t, links)
 
+		// Следующая страница начинается после id последней ссылки.
+		next, err := storageORM.GetLinks(ctx, 10, addedLink.ID)
+		require.NoError(t, err)
+
+		for _, l := range next {
+			require.Greater(t, l.ID, addedLink.ID)
+		}
+
 		// Проверяем, что ссылки для данного чата существуют.
 		linksByChat, err := storageORM.GetLinksByChatID(ctx, chatID)
 		require.NoError(t, err)
 		require.NotNil(t, linksByChat)
 		require.NotEmpty(t, linksByChat)
 	})
+
+	t.Run("Rename link and mark it broken", func(t *testing.T) {
+		chatID := int64(1234567)
+		link := &scraper.Link{URL: "https://github.com/old/repo", ChatID: chatID}
+
+		err = storageORM.CreateNewChat(ctx, chatID)
+		require.NoError(t, err)
+
+		_, err = storageORM.AddLink(ctx, chatID, link)
+		require.NoError(t, err)
+
+		renamed, err := storageORM.RenameLink(ctx, link, "https://github.com/new/repo")
+		require.NoError(t, err)
+		require.Equal(t, "https://github.com/new/repo", renamed.URL)
+
+		link.URL = renamed.URL
+
+		failures, err := storageORM.RecordFailure(ctx, link, http.StatusNotFound, "404 Not Found", true)
+		require.NoError(t, err)
+		require.Equal(t, 1, failures)
+
+		failures, err = storageORM.RecordFailure(ctx, link, http.StatusNotFound, "404 Not Found", true)
+		require.NoError(t, err)
+		require.Equal(t, 2, failures)
+
+		require.NoError(t, storageORM.RecordSuccess(ctx, link, http.StatusOK))
+
+		failures, err = storageORM.RecordFailure(ctx, link, http.StatusNotFound, "404 Not Found", true)
+		require.NoError(t, err)
+		require.Equal(t, 1, failures)
+
+		// Другая ошибка между ответами 404 начинает счет заново.
+		failures, err = storageORM.RecordFailure(ctx, link, http.StatusBadGateway, "502 Bad Gateway", false)
+		require.NoError(t, err)
+		require.Equal(t, 0, failures)
+
+		failures, err = storageORM.RecordFailure(ctx, link, http.StatusNotFound, "404 Not Found", true)
+		require.NoError(t, err)
+		require.Equal(t, 1, failures)
+
+		require.NoError(t, storageORM.MarkBroken(ctx, link))
+
+		// Broken links are no longer polled but stay with the chat.
+		links, err := storageORM.GetLinks(ctx, 100, 0)
+		require.NoError(t, err)
+
+		for _, l := range links {
+			require.NotEqual(t, link.URL, l.URL)
+		}
+
+		linksByChat, err := storageORM.GetLinksByChatID(ctx, chatID)
+		require.NoError(t, err)
+		require.Len(t, linksByChat, 1)
+		require.True(t, linksByChat[0].Health.Broken)
+		require.Equal(t, 1, linksByChat[0].Health.Failures)
+		require.Equal(t, http.StatusNotFound, linksByChat[0].Health.LastStatus)
+		require.Equal(t, "404 Not Found", linksByChat[0].Health.LastError)
+		require.NotNil(t, linksByChat[0].Health.LastSuccess)
+	})
+
+	t.Run("Issue, use and revoke tokens", func(t *testing.T) {
+		chatID := int64(12345678)
+		hash := strings.Repeat("a", 64)
+
+		_, err = storageORM.CreateToken(ctx, chatID, hash, []string{scraper.ScopeLinksRead})
+		require.ErrorIs(t, err, storage.ErrNotExists)
+
+		err = storageORM.CreateNewChat(ctx, chatID)
+		require.NoError(t, err)
+
+		created, err := storageORM.CreateToken(ctx, chatID, hash, []string{scraper.ScopeLinksRead})
+		require.NoError(t, err)
+		require.Equal(t, chatID, created.ChatID)
+		require.Nil(t, created.LastUsed)
+
+		used, err := storageORM.UseToken(ctx, hash)
+		require.NoError(t, err)
+		require.Equal(t, created.ID, used.ID)
+		require.Equal(t, []string{scraper.ScopeLinksRead}, used.Scopes)
+		require.NotNil(t, used.LastUsed)
+
+		_, err = storageORM.UseToken(ctx, strings.Repeat("b", 64))
+		require.ErrorIs(t, err, storage.ErrNotExists)
+
+		revoked, err := storageORM.RevokeTokens(ctx, chatID)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), revoked)
+
+		_, err = storageORM.UseToken(ctx, hash)
+		require.ErrorIs(t, err, storage.ErrNotExists)
+	})
+
+	t.Run("Add, query and expire history", func(t *testing.T) {
+		chatID := int64(23456789)
+		base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+		err = storageORM.CreateNewChat(ctx, chatID)
+		require.NoError(t, err)
+
+		entries := make([]scraper.UpdateEntry, 0, 3)
+
+		for i, url := range []string{"https://github.com/a/b", "https://github.com/c/d", "https://github.com/a/b"} {
+			entries = append(entries, scraper.UpdateEntry{
+				ChatID:    chatID,
+				LinkID:    int64(i + 1),
+				URL:       url,
+				Tags:      []string{"work"},
+				Provider:  "github",
+				Type:      "issue",
+				Title:     fmt.Sprintf("Issue %d", i),
+				ItemURL:   fmt.Sprintf("%s/issues/%d", url, i),
+				Timestamp: base.Add(time.Duration(i) * time.Hour),
+			})
+		}
+
+		err = storageORM.AddHistory(ctx, entries)
+		require.NoError(t, err)
+
+		history, err := storageORM.GetHistory(ctx, &scraper.HistoryQuery{ChatID: chatID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		require.Equal(t, "Issue 2", history[0].Title)
+		require.Equal(t, []string{"work"}, history[0].Tags)
+		require.True(t, base.Add(2*time.Hour).Equal(history[0].Timestamp))
+
+		history, err = storageORM.GetHistory(ctx, &scraper.HistoryQuery{
+			ChatID: chatID,
+			URL:    "https://github.com/a/b",
+			Limit:  1,
+			Offset: 1,
+		})
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		require.Equal(t, "Issue 0", history[0].Title)
+
+		history, err = storageORM.GetHistory(ctx, &scraper.HistoryQuery{
+			ChatID: chatID,
+			Since:  base,
+			Until:  base.Add(time.Hour),
+			Limit:  10,
+		})
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		require.Equal(t, "Issue 1", history[0].Title)
+
+		deleted, err := storageORM.DeleteHistory(ctx, base.Add(90*time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, int64(2), deleted)
+
+		history, err = storageORM.GetHistory(ctx, &scraper.HistoryQuery{ChatID: chatID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+	})
 }
 
 func startPostgresContainer(ctx context.Context) (testcontainers.Container, error) {
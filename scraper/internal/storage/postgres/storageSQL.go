@@ -5,10 +5,13 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"scraper/internal/model/scraper"
 	"scraper/internal/storage"
+	"strings"
+	"time"
 
 	// Needed.
 	_ "github.com/lib/pq"
@@ -44,6 +47,10 @@ func (s *SQLStorage) Stop() error {
 	return nil
 }
 
+func (s *SQLStorage) Ping(ctx context.Context) error {
+	return s.db.Ping(ctx)
+}
+
 func (s *SQLStorage) CreateNewChat(ctx context.Context, chatID int64) error {
 	const op = "storage.createNewChat"
 
@@ -80,12 +87,14 @@ func (s *SQLStorage) DeleteChat(ctx context.Context, chatID int64) error {
 	return nil
 }
 
-func (s *SQLStorage) GetLinks(ctx context.Context, limit, offset uint64) ([]scraper.Link, error) {
+// GetLinks returns up to limit links with ids above afterID in id order.
+func (s *SQLStorage) GetLinks(ctx context.Context, limit uint64, afterID int64) ([]scraper.Link, error) {
 	const op = "storage.getLinks"
 
-	query := "SELECT id, link, tags, filters, lastUpdated, chatId FROM links LIMIT $1 OFFSET $2"
+	// Broken links are kept for their chats but no longer polled.
+	query := "SELECT " + linkColumns + " FROM links WHERE NOT broken AND id > $1 ORDER BY id LIMIT $2"
 
-	rows, err := s.db.Query(ctx, query, limit, offset)
+	rows, err := s.db.Query(ctx, query, afterID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to get links: %w", op, err)
 	}
@@ -96,7 +105,7 @@ func (s *SQLStorage) GetLinks(ctx context.Context, limit, offset uint64) ([]scra
 	for rows.Next() {
 		var link scraper.Link
 
-		if err = rows.Scan(&link.ID, &link.URL, &link.Tags, &link.Filters, &link.LastUpdated, &link.ChatID); err != nil {
+		if err = rows.Scan(linkFields(&link)...); err != nil {
 			return nil, fmt.Errorf("%s: failed to scan link: %w", op, err)
 		}
 
@@ -109,7 +118,7 @@ func (s *SQLStorage) GetLinks(ctx context.Context, limit, offset uint64) ([]scra
 func (s *SQLStorage) GetLinksByChatID(ctx context.Context, chatID int64) ([]scraper.Link, error) {
 	const op = "storage.getLinks"
 
-	query := "SELECT id, link, tags, filters, lastUpdated, chatId FROM links WHERE chatId = $1"
+	query := "SELECT " + linkColumns + " FROM links WHERE chatId = $1"
 
 	rows, err := s.db.Query(ctx, query, chatID)
 	if err != nil {
@@ -122,7 +131,7 @@ func (s *SQLStorage) GetLinksByChatID(ctx context.Context, chatID int64) ([]scra
 	for rows.Next() {
 		var link scraper.Link
 
-		if err = rows.Scan(&link.ID, &link.URL, &link.Tags, &link.Filters, &link.LastUpdated, &link.ChatID); err != nil {
+		if err = rows.Scan(linkFields(&link)...); err != nil {
 			return nil, fmt.Errorf("%s: failed to scan link: %w", op, err)
 		}
 
@@ -195,6 +204,84 @@ func (s *SQLStorage) UpdateLink(ctx context.Context, link *scraper.Link) (*scrap
 	return &updatedLink, nil
 }
 
+func (s *SQLStorage) RenameLink(ctx context.Context, link *scraper.Link, newURL string) (*scraper.Link, error) {
+	const op = "storage.renameLink"
+
+	var renamedLink scraper.Link
+
+	query := "UPDATE links SET link = $1 WHERE chatId = $2 AND link = $3 RETURNING id, link, tags, filters, lastUpdated, chatId"
+
+	err := s.db.QueryRow(ctx, query, newURL, link.ChatID, link.URL).Scan(&renamedLink.ID, &renamedLink.URL,
+		&renamedLink.Tags, &renamedLink.Filters, &renamedLink.LastUpdated, &renamedLink.ChatID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+
+		switch {
+		case errors.As(err, &pgErr) && pgErr.Code == "23505":
+			return nil, storage.ErrAlreadyExists
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, storage.ErrNotExists
+		default:
+			return nil, fmt.Errorf("%s: failed to rename link: %w", op, err)
+		}
+	}
+
+	return &renamedLink, nil
+}
+
+func (s *SQLStorage) RecordSuccess(ctx context.Context, link *scraper.Link, status int) error {
+	const op = "storage.recordSuccess"
+
+	query := "UPDATE links SET failures = 0, gone_polls = 0, last_success = NOW(), last_status = $1, " +
+		"last_error = NULL WHERE chatId = $2 AND link = $3"
+
+	if _, err := s.db.Exec(ctx, query, status, link.ChatID, link.URL); err != nil {
+		return fmt.Errorf("%s: failed to record success: %w", op, err)
+	}
+
+	return nil
+}
+
+// RecordFailure returns the number of polls in a row that found the link
+// gone; a failure that is not gone resets it.
+func (s *SQLStorage) RecordFailure(ctx context.Context, link *scraper.Link, status int, lastError string, gone bool) (int, error) {
+	const op = "storage.recordFailure"
+
+	var failures int
+
+	query := "UPDATE links SET failures = failures + 1, " +
+		"gone_polls = CASE WHEN $1 THEN gone_polls + 1 ELSE 0 END, last_status = NULLIF($2, 0), last_error = $3 " +
+		"WHERE chatId = $4 AND link = $5 RETURNING gone_polls"
+
+	err := s.db.QueryRow(ctx, query, gone, status, lastError, link.ChatID, link.URL).Scan(&failures)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, storage.ErrNotExists
+		}
+
+		return 0, fmt.Errorf("%s: failed to record failure: %w", op, err)
+	}
+
+	return failures, nil
+}
+
+func (s *SQLStorage) MarkBroken(ctx context.Context, link *scraper.Link) error {
+	const op = "storage.markBroken"
+
+	query := "UPDATE links SET broken = TRUE WHERE chatId = $1 AND link = $2"
+
+	commandTag, err := s.db.Exec(ctx, query, link.ChatID, link.URL)
+	if err != nil {
+		return fmt.Errorf("%s: failed to mark link broken: %w", op, err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return storage.ErrNotExists
+	}
+
+	return nil
+}
+
 func (s *SQLStorage) UpdateMetric(ctx context.Context, metricType string) (int64, error) {
 	const op = "storage.UpdateMetric"
 
@@ -211,3 +298,143 @@ func (s *SQLStorage) UpdateMetric(ctx context.Context, metricType string) (int64
 
 	return count, nil
 }
+
+func (s *SQLStorage) CreateToken(ctx context.Context, chatID int64, hash string, scopes []string) (*scraper.APIToken,
+	error) {
+	const op = "storage.createToken"
+
+	var token scraper.APIToken
+
+	query := "INSERT INTO api_tokens (chatid, token_hash, scopes) VALUES ($1, $2, $3) RETURNING " + tokenColumns
+
+	err := s.db.QueryRow(ctx, query, chatID, hash, scopes).Scan(tokenFields(&token)...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, storage.ErrNotExists
+		}
+
+		return nil, fmt.Errorf("%s: failed to create token: %w", op, err)
+	}
+
+	return &token, nil
+}
+
+// UseToken looks a token up by its hash and marks it used. Revoked tokens
+// are reported as missing.
+func (s *SQLStorage) UseToken(ctx context.Context, hash string) (*scraper.APIToken, error) {
+	const op = "storage.useToken"
+
+	var token scraper.APIToken
+
+	query := "UPDATE api_tokens SET last_used = NOW() WHERE token_hash = $1 AND revoked_at IS NULL RETURNING " +
+		tokenColumns
+
+	err := s.db.QueryRow(ctx, query, hash).Scan(tokenFields(&token)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrNotExists
+		}
+
+		return nil, fmt.Errorf("%s: failed to use token: %w", op, err)
+	}
+
+	return &token, nil
+}
+
+func (s *SQLStorage) RevokeTokens(ctx context.Context, chatID int64) (int64, error) {
+	const op = "storage.revokeTokens"
+
+	query := "UPDATE api_tokens SET revoked_at = NOW() WHERE chatid = $1 AND revoked_at IS NULL"
+
+	commandTag, err := s.db.Exec(ctx, query, chatID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to revoke tokens: %w", op, err)
+	}
+
+	return commandTag.RowsAffected(), nil
+}
+
+func (s *SQLStorage) AddHistory(ctx context.Context, entries []scraper.UpdateEntry) error {
+	const op = "storage.addHistory"
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	query := "INSERT INTO update_history (chatid, linkid, link, tags, provider, event_type, title, url, occurred_at) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+
+	batch := &pgx.Batch{}
+
+	for i := range entries {
+		entry := &entries[i]
+		batch.Queue(query, entry.ChatID, entry.LinkID, entry.URL, entry.Tags, entry.Provider, entry.Type,
+			entry.Title, entry.ItemURL, entry.Timestamp.UTC())
+	}
+
+	if err := s.db.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("%s: failed to add history: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *SQLStorage) GetHistory(ctx context.Context, query *scraper.HistoryQuery) ([]scraper.UpdateEntry, error) {
+	const op = "storage.getHistory"
+
+	conditions := []string{"chatid = $1"}
+	args := []any{query.ChatID}
+
+	if query.URL != "" {
+		args = append(args, query.URL)
+		conditions = append(conditions, fmt.Sprintf("link = $%d", len(args)))
+	}
+
+	if !query.Since.IsZero() {
+		args = append(args, query.Since.UTC())
+		conditions = append(conditions, fmt.Sprintf("occurred_at > $%d", len(args)))
+	}
+
+	if !query.Until.IsZero() {
+		args = append(args, query.Until.UTC())
+		conditions = append(conditions, fmt.Sprintf("occurred_at <= $%d", len(args)))
+	}
+
+	args = append(args, query.Limit, query.Offset)
+
+	sqlQuery := fmt.Sprintf("SELECT %s FROM update_history WHERE %s ORDER BY occurred_at DESC, id DESC "+
+		"LIMIT $%d OFFSET $%d", historyColumns, strings.Join(conditions, " AND "), len(args)-1, len(args))
+
+	rows, err := s.db.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get history: %w", op, err)
+	}
+	defer rows.Close()
+
+	entries := []scraper.UpdateEntry{}
+
+	for rows.Next() {
+		var entry scraper.UpdateEntry
+
+		if err = rows.Scan(historyFields(&entry)...); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan history: %w", op, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (s *SQLStorage) DeleteHistory(ctx context.Context, before time.Time) (int64, error) {
+	const op = "storage.deleteHistory"
+
+	commandTag, err := s.db.Exec(ctx, "DELETE FROM update_history WHERE occurred_at < $1", before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to delete history: %w", op, err)
+	}
+
+	return commandTag.RowsAffected(), nil
+}
@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"github.com/Shopify/sarama"
+	"go.opentelemetry.io/otel"
+
+	"context"
+)
+
+// headersCarrier lets the propagator write trace context into the headers of
+// a Kafka message.
+type headersCarrier struct {
+	msg *sarama.ProducerMessage
+}
+
+func (c headersCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+
+	return ""
+}
+
+func (c headersCarrier) Set(key, value string) {
+	for i, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+
+	c.msg.Headers = append(c.msg.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c headersCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		keys = append(keys, string(h.Key))
+	}
+
+	return keys
+}
+
+// InjectKafka adds the trace context of ctx to the headers of msg, so that
+// the bot's consumer continues the trace.
+func InjectKafka(ctx context.Context, msg *sarama.ProducerMessage) {
+	otel.GetTextMapPropagator().Inject(ctx, headersCarrier{msg: msg})
+}
@@ -0,0 +1,59 @@
+package tracing_test
+
+import (
+	"scraper/internal/config"
+	"scraper/internal/tracing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSetup_WithoutEndpoint(t *testing.T) {
+	shutdown, err := tracing.Setup(context.Background(), &config.TracingConfig{SampleRatio: 1})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+}
+
+func TestInjectKafka(t *testing.T) {
+	_, err := tracing.Setup(context.Background(), &config.TracingConfig{})
+	require.NoError(t, err)
+
+	traceID, err := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	require.NoError(t, err)
+
+	spanID, err := trace.SpanIDFromHex("b7ad6b7169203331")
+	require.NoError(t, err)
+
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	msg := &sarama.ProducerMessage{Headers: []sarama.RecordHeader{{Key: []byte("event-id"), Value: []byte("1")}}}
+	tracing.InjectKafka(ctx, msg)
+
+	var parent string
+
+	for _, h := range msg.Headers {
+		if string(h.Key) == "traceparent" {
+			parent = string(h.Value)
+		}
+	}
+
+	require.Len(t, msg.Headers, 2)
+	require.True(t, strings.Contains(parent, traceID.String()), parent)
+	require.True(t, strings.Contains(parent, spanID.String()), parent)
+
+	t.Run("without a span", func(t *testing.T) {
+		msg := &sarama.ProducerMessage{}
+		tracing.InjectKafka(context.Background(), msg)
+
+		require.Empty(t, msg.Headers)
+	})
+}
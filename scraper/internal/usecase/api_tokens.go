@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"scraper/internal/model/scraper"
+	storageErrors "scraper/internal/storage"
+
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// tokenPrefix makes tokens easy to spot in logs and secret scanners.
+const tokenPrefix = "lt_"
+
+var (
+	ErrUnknownScope = errors.New("unknown token scope")
+	ErrInvalidToken = errors.New("invalid API token")
+)
+
+var (
+	knownScopes   = []string{scraper.ScopeLinksRead, scraper.ScopeLinksWrite}
+	defaultScopes = []string{scraper.ScopeLinksRead, scraper.ScopeLinksWrite}
+)
+
+// IssueToken creates a token for the public API of the chat. Only its hash is
+// stored, so the returned token can't be shown again.
+func (a *UseCase) IssueToken(ctx context.Context, id int64, scopes []string) (string, *scraper.APIToken, error) {
+	const op = "Scraper.IssueToken"
+
+	log := a.l.With(
+		slog.String("op", op),
+	)
+
+	log.Info("attempting to issue token")
+
+	if len(scopes) == 0 {
+		scopes = defaultScopes
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(knownScopes, scope) {
+			return "", nil, fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+
+	scopes = slices.Compact(slices.Sorted(slices.Values(scopes)))
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	token := tokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	created, err := a.storage.CreateToken(ctx, id, hashToken(token), scopes)
+	if err != nil {
+		log.Error("failed to issue token", slog.String("error", err.Error()))
+		return "", nil, err
+	}
+
+	return token, created, nil
+}
+
+// Authenticate returns the live token matching the presented one.
+func (a *UseCase) Authenticate(ctx context.Context, token string) (*scraper.APIToken, error) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+
+	found, err := a.storage.UseToken(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, storageErrors.ErrNotExists) {
+			return nil, ErrInvalidToken
+		}
+
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// RevokeTokens revokes every token of the chat and returns how many there were.
+func (a *UseCase) RevokeTokens(ctx context.Context, id int64) (int64, error) {
+	const op = "Scraper.RevokeTokens"
+
+	log := a.l.With(
+		slog.String("op", op),
+	)
+
+	log.Info("attempting to revoke tokens")
+
+	revoked, err := a.storage.RevokeTokens(ctx, id)
+	if err != nil {
+		log.Error("failed to revoke tokens", slog.String("error", err.Error()))
+		return 0, err
+	}
+
+	return revoked, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
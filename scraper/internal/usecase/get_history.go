@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	"scraper/internal/model/scraper"
+
+	"context"
+	"log/slog"
+)
+
+// MaxHistoryLimit caps a page of the update history. A zero limit asks for
+// the largest page.
+const MaxHistoryLimit = 100
+
+// GetHistory returns a page of the chat's update history, latest first.
+func (a *UseCase) GetHistory(ctx context.Context, query *scraper.HistoryQuery) ([]scraper.UpdateEntry, error) {
+	const op = "Scraper.GetHistory"
+
+	log := a.l.With(
+		slog.String("op", op),
+	)
+
+	log.Info("attempting to get history")
+
+	if query.Limit == 0 || query.Limit > MaxHistoryLimit {
+		query.Limit = MaxHistoryLimit
+	}
+
+	updates, err := a.storage.GetHistory(ctx, query)
+	if err != nil {
+		log.Error("failed to get history", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return updates, nil
+}
@@ -13,6 +13,10 @@ type storage interface {
 	GetLinksByChatID(ctx context.Context, chatID int64) ([]scraper.Link, error)
 	AddLink(ctx context.Context, chatID int64, link *scraper.Link) (*scraper.Link, error)
 	RemoveLink(ctx context.Context, chatID int64, link string) (*scraper.Link, error)
+	CreateToken(ctx context.Context, chatID int64, hash string, scopes []string) (*scraper.APIToken, error)
+	UseToken(ctx context.Context, hash string) (*scraper.APIToken, error)
+	RevokeTokens(ctx context.Context, chatID int64) (int64, error)
+	GetHistory(ctx context.Context, query *scraper.HistoryQuery) ([]scraper.UpdateEntry, error)
 }
 
 type MetricManager interface {
@@ -10,6 +10,7 @@ import (
 )
 
 func RespondWithError(writer http.ResponseWriter, statusCode int, description, code, exceptionName, exceptionMessage string) {
+	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(statusCode)
 
 	stackTrace := make([]string, 0)